@@ -0,0 +1,153 @@
+// Package aggregation provides standard aggregation functions for the aggregation operations of spaces.
+//
+// The aggregation functions are registered in the global function registry under namespaces which are
+// stable across Go versions, such that any peer importing goSpace can resolve them.
+// Unlike user defined aggregation functions, which are folded over pairs of tuples, the aggregation
+// functions of this package are applied to all matched tuples at once.
+//
+// Aggregation functions operate on the data fields of plain and labelled tuples.
+// If any of the aggregated tuples is labelled, the result is labelled with the union of their labels.
+package aggregation
+
+import (
+	"encoding/gob"
+	"strings"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// Namespace is the prefix of the namespaces under which the aggregation functions are registered.
+const Namespace = "func://gospace/aggregation/"
+
+// Aggregator is an interface for aggregation values which aggregate all matched tuples at once.
+type Aggregator interface {
+	Aggregate(ts ...container.Intertuple) container.Intertuple
+}
+
+// Function is the type of the aggregation functions of this package.
+type Function = func(...container.Intertuple) container.Intertuple
+
+// library maps the names of the aggregation functions to the functions themselves.
+var library = map[string]Function{
+	"count": Count,
+	"sum":   Sum,
+	"min":   Min,
+	"max":   Max,
+	"mean":  Mean,
+	"first": First,
+	"last":  Last,
+}
+
+// libraryNames contains the function names of the aggregation functions.
+var libraryNames = map[string]bool{}
+
+func init() {
+	if function.GlobalRegistry == nil {
+		fr := function.NewRegistry()
+		function.GlobalRegistry = &fr
+	}
+
+	for name, fun := range library {
+		function.GlobalRegistry.RegisterNamespace(fun, function.NewNamespace(strings.Join([]string{Namespace, name}, "")))
+		libraryNames[function.Name(fun)] = true
+	}
+
+	gob.Register(GroupBy{})
+}
+
+// IsAggregator returns true if fun is an aggregation function of this package or an Aggregator, and false otherwise.
+func IsAggregator(fun interface{}) (b bool) {
+	if _, ok := fun.(Aggregator); ok {
+		b = true
+	} else if fun != nil && function.IsFunc(fun) {
+		b = libraryNames[function.Name(fun)]
+	}
+
+	return b
+}
+
+// Aggregate applies the aggregation function or value fun to all tuples ts.
+// Aggregate applies user defined aggregation functions pairwise from left to right.
+// Aggregate returns nil if fun can not be used for aggregation.
+func Aggregate(fun interface{}, ts ...container.Intertuple) (result container.Intertuple) {
+	switch f := fun.(type) {
+	case Aggregator:
+		result = f.Aggregate(ts...)
+	case Function:
+		if IsAggregator(f) || len(ts) <= 1 {
+			result = f(ts...)
+		} else {
+			result = ts[0]
+			for _, t := range ts[1:] {
+				result = f(result, t)
+			}
+		}
+	}
+
+	return result
+}
+
+// split returns the labels lbls and the data fields flds of tuple t.
+// split returns nil labels if t is not labelled.
+func split(t container.Intertuple) (lbls container.Labels, flds []interface{}) {
+	switch lt := t.(type) {
+	case *container.LabelledTuple:
+		lbls = lt.Labels()
+		dt := lt.Tuple()
+		flds = dt.Fields()
+	case *container.Tuple:
+		flds = lt.Fields()
+
+		if len(flds) > 0 {
+			if l, ok := flds[0].(container.Labels); ok {
+				lbls, flds = l, flds[1:]
+			}
+		}
+	default:
+		if t != nil {
+			flds = t.Fields()
+		}
+	}
+
+	return lbls, flds
+}
+
+// rows returns the data fields of all tuples ts, together with the union of their labels.
+// rows returns true if any tuple in ts is labelled, and false otherwise.
+func rows(ts []container.Intertuple) (flds [][]interface{}, lbls container.Labels, labelled bool) {
+	flds = make([][]interface{}, 0, len(ts))
+	lbls = container.NewLabels()
+
+	for _, t := range ts {
+		tl, tf := split(t)
+
+		if tl != nil {
+			labelled = true
+			for _, l := range tl.Set() {
+				lbls.Add(container.NewLabel(l.ID()))
+			}
+		}
+
+		flds = append(flds, tf)
+	}
+
+	return flds, lbls, labelled
+}
+
+// result creates the result of an aggregation with the data fields flds.
+// result creates a labelled tuple with labels lbls if labelled is true, and a tuple otherwise.
+func result(flds []interface{}, lbls container.Labels, labelled bool) (t container.Intertuple) {
+	if labelled {
+		ltf := make([]interface{}, len(flds)+1)
+		ltf[0] = lbls
+		copy(ltf[1:], flds)
+		lt := container.NewLabelledTuple(ltf...)
+		t = &lt
+	} else {
+		tp := container.NewTuple(flds...)
+		t = &tp
+	}
+
+	return t
+}
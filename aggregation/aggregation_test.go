@@ -0,0 +1,147 @@
+package aggregation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+func tuples(rows ...[]interface{}) (ts []container.Intertuple) {
+	ts = make([]container.Intertuple, len(rows))
+	for i, row := range rows {
+		t := container.NewTuple(row...)
+		ts[i] = &t
+	}
+
+	return ts
+}
+
+func TestLibraryFunctions(t *testing.T) {
+	// Setup
+	ts := tuples([]interface{}{"a", 3, 1.5}, []interface{}{"b", 1, 2.5}, []interface{}{"c", 2, 5.0})
+
+	cases := []struct {
+		name     string
+		fun      Function
+		expected container.Tuple
+	}{
+		{"count", Count, container.NewTuple(3)},
+		{"sum", Sum, container.NewTuple("a", 6, 9.0)},
+		{"min", Min, container.NewTuple("a", 1, 1.5)},
+		{"max", Max, container.NewTuple("c", 3, 5.0)},
+		{"mean", Mean, container.NewTuple("a", 2.0, 3.0)},
+		{"first", First, container.NewTuple("a", 3, 1.5)},
+		{"last", Last, container.NewTuple("c", 2, 5.0)},
+	}
+
+	for _, c := range cases {
+		actual := Aggregate(c.fun, ts...)
+		if !reflect.DeepEqual(actual, &c.expected) {
+			t.Errorf("Aggregate() with %s gave %v, should be %v", c.name, actual, c.expected)
+		}
+
+		if !IsAggregator(c.fun) {
+			t.Errorf("IsAggregator() with %s gave false, should be true", c.name)
+		}
+	}
+}
+
+func TestMixedSum(t *testing.T) {
+	// Mixed integer and floating point fields are summed as float64, whichever comes first.
+	cases := []struct {
+		name string
+		ts   []container.Intertuple
+	}{
+		{"int first", tuples([]interface{}{1}, []interface{}{2.5})},
+		{"float first", tuples([]interface{}{2.5}, []interface{}{1})},
+	}
+
+	expected := container.NewTuple(3.5)
+
+	for _, c := range cases {
+		actual := Aggregate(Sum, c.ts...)
+		if !reflect.DeepEqual(actual, &expected) {
+			t.Errorf("Aggregate() with sum of %s gave %v, should be %v", c.name, actual, expected)
+		}
+	}
+}
+
+func TestLibraryNamespaces(t *testing.T) {
+	// Setup
+	ns := function.GlobalRegistry.Encode(Sum)
+
+	if ns == nil || ns.String() != Namespace+"sum" {
+		t.Errorf("Encode() gave %v, should be %s", ns, Namespace+"sum")
+	}
+
+	fun := function.GlobalRegistry.Decode(function.NewNamespace(Namespace + "count"))
+	if fun == nil || function.Name(*fun) != function.Name(Count) {
+		t.Errorf("Decode() gave %v, should be the count aggregation function", fun)
+	}
+}
+
+func TestUserFunctionIsFolded(t *testing.T) {
+	// Setup
+	calls := 0
+	fun := func(ts ...container.Intertuple) container.Intertuple {
+		calls++
+		return Sum(ts...)
+	}
+
+	ts := tuples([]interface{}{1}, []interface{}{2}, []interface{}{3})
+
+	actual := Aggregate(fun, ts...)
+	expected := container.NewTuple(6)
+
+	if !reflect.DeepEqual(actual, &expected) || calls != 2 {
+		t.Errorf("Aggregate() gave %v in %d calls, should be %v in %d calls", actual, calls, expected, 2)
+	}
+
+	if IsAggregator(fun) {
+		t.Errorf("IsAggregator() gave true for a user function, should be false")
+	}
+}
+
+func TestLabelledTuples(t *testing.T) {
+	// Setup
+	lt1 := container.NewLabelledTuple(container.NewLabels(container.NewLabel("x")), 2)
+	lt2 := container.NewLabelledTuple(container.NewLabels(container.NewLabel("y")), 5)
+	tp := container.NewTuple(container.NewLabels(container.NewLabel("x")), 1)
+
+	actual := Sum(&lt1, &lt2, &tp)
+
+	lt, ok := actual.(*container.LabelledTuple)
+	if !ok {
+		t.Fatalf("Sum() gave %v, should be a labelled tuple", actual)
+	}
+
+	if dt := lt.Tuple(); !reflect.DeepEqual(dt, container.NewTuple(8)) {
+		t.Errorf("Sum() gave data %v, should be %v", dt, container.NewTuple(8))
+	}
+
+	lbls := lt.Labels()
+	if lbls.Retrieve("x") == nil || lbls.Retrieve("y") == nil || len(lbls.Set()) != 2 {
+		t.Errorf("Sum() gave labels %v, should be the union of %s and %s", lbls, "x", "y")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	// Setup
+	ts := tuples([]interface{}{"a", 1}, []interface{}{"b", 2}, []interface{}{"a", 3}, []interface{}{"c"})
+
+	actual := NewGroupBy(0, Sum).Aggregate(ts...)
+	expected := container.NewTuple(container.NewTuple("a", "a", 4), container.NewTuple("b", "b", 2), container.NewTuple("c", "c"))
+
+	if !reflect.DeepEqual(actual, &expected) {
+		t.Errorf("Aggregate() with sum gave %v, should be %v", actual, expected)
+	}
+
+	actual = Aggregate(NewGroupBy(1, Count), ts...)
+	expected = container.NewTuple(container.NewTuple(1, 1), container.NewTuple(2, 1), container.NewTuple(3, 1))
+
+	if !reflect.DeepEqual(actual, &expected) {
+		t.Errorf("Aggregate() with count gave %v, should be %v", actual, expected)
+	}
+}
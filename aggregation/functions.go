@@ -0,0 +1,224 @@
+package aggregation
+
+import (
+	"reflect"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// Count aggregates the tuples ts into a tuple containing the amount of tuples.
+func Count(ts ...container.Intertuple) container.Intertuple {
+	_, lbls, labelled := rows(ts)
+	return result([]interface{}{len(ts)}, lbls, labelled)
+}
+
+// Sum aggregates the tuples ts by summing their numeric fields position by position.
+// Non-numeric fields are taken from the first tuple.
+func Sum(ts ...container.Intertuple) container.Intertuple {
+	return combine(ts, func(acc interface{}, val interface{}) interface{} {
+		return arith(acc, val, func(x, y float64) float64 { return x + y }, func(x, y int64) int64 { return x + y }, func(x, y uint64) uint64 { return x + y })
+	})
+}
+
+// Min aggregates the tuples ts by taking the smallest numeric or string field position by position.
+// Other fields are taken from the first tuple.
+func Min(ts ...container.Intertuple) container.Intertuple {
+	return combine(ts, func(acc interface{}, val interface{}) interface{} {
+		if less(val, acc) {
+			return val
+		}
+
+		return acc
+	})
+}
+
+// Max aggregates the tuples ts by taking the largest numeric or string field position by position.
+// Other fields are taken from the first tuple.
+func Max(ts ...container.Intertuple) container.Intertuple {
+	return combine(ts, func(acc interface{}, val interface{}) interface{} {
+		if less(acc, val) {
+			return val
+		}
+
+		return acc
+	})
+}
+
+// Mean aggregates the tuples ts by averaging their numeric fields position by position.
+// The averages are of type float64, and non-numeric fields are taken from the first tuple.
+func Mean(ts ...container.Intertuple) container.Intertuple {
+	flds, lbls, labelled := rows(ts)
+
+	if len(flds) == 0 {
+		return result([]interface{}{}, lbls, labelled)
+	}
+
+	mean := make([]interface{}, len(flds[0]))
+	copy(mean, flds[0])
+
+	for i := range mean {
+		if _, ok := float(mean[i]); !ok {
+			continue
+		}
+
+		var sum float64
+		var n int
+
+		for _, row := range flds {
+			if i < len(row) {
+				if x, ok := float(row[i]); ok {
+					sum += x
+					n++
+				}
+			}
+		}
+
+		mean[i] = sum / float64(n)
+	}
+
+	return result(mean, lbls, labelled)
+}
+
+// First aggregates the tuples ts by taking the first tuple.
+func First(ts ...container.Intertuple) container.Intertuple {
+	flds, lbls, labelled := rows(ts)
+
+	if len(flds) == 0 {
+		return result([]interface{}{}, lbls, labelled)
+	}
+
+	return result(flds[0], lbls, labelled)
+}
+
+// Last aggregates the tuples ts by taking the last tuple.
+func Last(ts ...container.Intertuple) container.Intertuple {
+	flds, lbls, labelled := rows(ts)
+
+	if len(flds) == 0 {
+		return result([]interface{}{}, lbls, labelled)
+	}
+
+	return result(flds[len(flds)-1], lbls, labelled)
+}
+
+// combine folds the fields at each position of the tuples ts with function op, starting from the fields of the first tuple.
+func combine(ts []container.Intertuple, op func(acc interface{}, val interface{}) interface{}) container.Intertuple {
+	flds, lbls, labelled := rows(ts)
+
+	if len(flds) == 0 {
+		return result([]interface{}{}, lbls, labelled)
+	}
+
+	acc := make([]interface{}, len(flds[0]))
+	copy(acc, flds[0])
+
+	for _, row := range flds[1:] {
+		for i := 0; i < len(acc) && i < len(row); i++ {
+			acc[i] = op(acc[i], row[i])
+		}
+	}
+
+	return result(acc, lbls, labelled)
+}
+
+// Numeric kinds of values.
+const (
+	kindNone = iota
+	kindInt
+	kindUint
+	kindFloat
+)
+
+// kind returns the numeric kind of value val.
+func kind(val interface{}) (k int) {
+	if val == nil {
+		return kindNone
+	}
+
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		k = kindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		k = kindUint
+	case reflect.Float32, reflect.Float64:
+		k = kindFloat
+	default:
+		k = kindNone
+	}
+
+	return k
+}
+
+// float returns value val as a float64, and false if val is not numeric.
+func float(val interface{}) (x float64, b bool) {
+	v := reflect.ValueOf(val)
+
+	switch kind(val) {
+	case kindInt:
+		x, b = float64(v.Int()), true
+	case kindUint:
+		x, b = float64(v.Uint()), true
+	case kindFloat:
+		x, b = v.Float(), true
+	}
+
+	return x, b
+}
+
+// arith applies an arithmetic operation to the numeric values acc and val, and returns a value of the same type as acc.
+// arith uses integer arithmetic if both values are integers of the same signedness, and floating point arithmetic otherwise.
+// If only one of the values is a floating point number, the result is a float64 rather than of the type of acc,
+// such that the result does not depend on the order of the values.
+// arith returns acc if either value is not numeric.
+func arith(acc interface{}, val interface{}, fop func(x, y float64) float64, iop func(x, y int64) int64, uop func(x, y uint64) uint64) (res interface{}) {
+	ka, kv := kind(acc), kind(val)
+
+	if ka == kindNone || kv == kindNone {
+		return acc
+	}
+
+	va, vv := reflect.ValueOf(acc), reflect.ValueOf(val)
+
+	var r reflect.Value
+	if ka == kindInt && kv == kindInt {
+		r = reflect.ValueOf(iop(va.Int(), vv.Int()))
+	} else if ka == kindUint && kv == kindUint {
+		r = reflect.ValueOf(uop(va.Uint(), vv.Uint()))
+	} else {
+		x, _ := float(acc)
+		y, _ := float(val)
+		r = reflect.ValueOf(fop(x, y))
+	}
+
+	if (ka == kindFloat) != (kv == kindFloat) {
+		return r.Interface()
+	}
+
+	res = r.Convert(va.Type()).Interface()
+
+	return res
+}
+
+// less returns true if value x is less than value y, where both are either numeric or strings.
+// less returns false if the values can not be compared.
+func less(x interface{}, y interface{}) (b bool) {
+	kx, ky := kind(x), kind(y)
+
+	if kx != kindNone && ky != kindNone {
+		if kx == kindInt && ky == kindInt {
+			b = reflect.ValueOf(x).Int() < reflect.ValueOf(y).Int()
+		} else if kx == kindUint && ky == kindUint {
+			b = reflect.ValueOf(x).Uint() < reflect.ValueOf(y).Uint()
+		} else {
+			fx, _ := float(x)
+			fy, _ := float(y)
+			b = fx < fy
+		}
+	} else {
+		sx, xs := x.(string)
+		sy, ys := y.(string)
+		b = xs && ys && sx < sy
+	}
+
+	return b
+}
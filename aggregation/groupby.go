@@ -0,0 +1,89 @@
+package aggregation
+
+import (
+	"reflect"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// GroupBy is an aggregation value which groups tuples by one of their data fields and aggregates each group.
+// GroupBy aggregates into a tuple with one field per group, in the order the groups are first seen.
+// Each such field is a tuple containing the value of the group followed by the fields of its aggregate.
+type GroupBy struct {
+	Index      int
+	Aggregator container.FuncRef
+}
+
+// NewGroupBy creates a group-by aggregation gb grouping on the data field at index and aggregating each group with fun.
+// The aggregation function fun must be registered in the function registry of the peers performing the aggregation.
+func NewGroupBy(index int, fun Function) (gb GroupBy) {
+	if function.GlobalRegistry == nil {
+		fr := function.NewRegistry()
+		function.GlobalRegistry = &fr
+	}
+
+	ns := function.GlobalRegistry.Encode(fun)
+
+	gb = GroupBy{Index: index}
+
+	if ns != nil {
+		gb.Aggregator = container.NewFuncRef(ns.String())
+	}
+
+	return gb
+}
+
+// Aggregate aggregates the tuples ts by grouping them on the data field given by the index of gb.
+// Tuples without a data field at that index are left out.
+func (gb GroupBy) Aggregate(ts ...container.Intertuple) container.Intertuple {
+	var fun interface{}
+
+	if function.GlobalRegistry != nil {
+		if f := function.GlobalRegistry.Decode(function.NewNamespace(gb.Aggregator.Namespace)); f != nil {
+			fun = *f
+		}
+	}
+
+	_, lbls, labelled := rows(ts)
+
+	keys := []interface{}{}
+	groups := [][]container.Intertuple{}
+
+	for _, t := range ts {
+		_, flds := split(t)
+
+		if gb.Index < 0 || gb.Index >= len(flds) {
+			continue
+		}
+
+		key := flds[gb.Index]
+
+		i := 0
+		for i < len(keys) && !reflect.DeepEqual(keys[i], key) {
+			i++
+		}
+
+		if i == len(keys) {
+			keys = append(keys, key)
+			groups = append(groups, []container.Intertuple{})
+		}
+
+		groups[i] = append(groups[i], t)
+	}
+
+	flds := make([]interface{}, 0, len(keys))
+
+	for i, key := range keys {
+		gfs := []interface{}{key}
+
+		if agg := Aggregate(fun, groups[i]...); agg != nil {
+			_, afs := split(agg)
+			gfs = append(gfs, afs...)
+		}
+
+		flds = append(flds, container.NewTuple(gfs...))
+	}
+
+	return result(flds, lbls, labelled)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// Schemes of the credentials presented by the identities of this package.
+const (
+	SchemeNone  = ""
+	SchemeHMAC  = "hmac"
+	SchemeTLS   = "tls"
+	SchemeToken = "token"
+)
+
+// Anonymous is the name of the principal of unauthenticated peers.
+const Anonymous = "anonymous"
+
+// ErrUnauthenticated is returned when a peer presents no credentials to a space requiring authentication.
+var ErrUnauthenticated = errors.New("auth: authentication required")
+
+// Principal is the authenticated identity of a peer together with the labels it holds.
+type Principal struct {
+	Name   string
+	Labels container.Labels
+}
+
+// NewPrincipal creates a principal p named name holding the labels lbls.
+func NewPrincipal(name string, lbls ...container.Label) (p Principal) {
+	p = Principal{Name: name, Labels: container.NewLabels(lbls...)}
+	return p
+}
+
+// NewAnonymous creates the principal p of an unauthenticated peer holding the self-declared labels lbls.
+func NewAnonymous(lbls container.Labels) (p Principal) {
+	p = Principal{Name: Anonymous, Labels: lbls}
+	return p
+}
+
+// IsAnonymous returns true if principal p has not been authenticated, and false otherwise.
+func (p Principal) IsAnonymous() bool {
+	return p.Name == Anonymous
+}
+
+// String returns a print friendly representation of principal p.
+func (p Principal) String() string {
+	return fmt.Sprintf("%s%s", p.Name, p.Labels)
+}
+
+// Credentials is the package sent by a client to a space when a connection is set up.
+// Scheme names the authentication scheme, and the remaining fields are interpreted by the authenticator of that scheme.
+type Credentials struct {
+	Scheme string
+	Name   string
+	Issued int64
+	Nonce  []byte
+	Token  []byte
+}
+
+// Authenticator is an interface for spaces authenticating the peers connecting to them.
+// Accept is called on every accepted connection before anything is exchanged, and may wrap it, e.g. in TLS.
+// Authenticate is called with the wrapped connection and the credentials presented by the peer.
+type Authenticator interface {
+	Accept(conn net.Conn) (net.Conn, error)
+	Authenticate(conn net.Conn, creds Credentials) (Principal, error)
+}
+
+// Identity is an interface for clients proving who they are to the spaces they connect to.
+// Connect is called on every connection before anything is exchanged, and may wrap it, e.g. in TLS.
+// Credentials returns the credentials presented for a single connection.
+type Identity interface {
+	Connect(conn net.Conn) (net.Conn, error)
+	Credentials() (Credentials, error)
+}
+
+// schemeError returns an error for credentials creds presented to an authenticator of scheme scheme.
+func schemeError(scheme string, creds Credentials) error {
+	if creds.Scheme == SchemeNone {
+		return ErrUnauthenticated
+	}
+
+	return fmt.Errorf("auth: credentials of scheme %q presented to %s authenticator", creds.Scheme, scheme)
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+)
+
+func TestHMAC(t *testing.T) {
+	// Setup
+	secret := []byte("correct horse battery staple")
+
+	h := NewHMAC()
+	h.Add(NewPrincipal("alice", container.NewLabel("hr")), secret)
+
+	creds, err := NewHMACIdentity("alice", secret).Credentials()
+	if err != nil {
+		t.Fatalf("Credentials() gave %v, should be nil", err)
+	}
+
+	p, err := h.Authenticate(nil, creds)
+	if err != nil || p.Name != "alice" || p.Labels.Retrieve("hr") == nil {
+		t.Errorf("Authenticate() gave %v and %v, should be alice holding hr", p, err)
+	}
+
+	_, err = h.Authenticate(nil, creds)
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for a replayed token, should be an error")
+	}
+
+	creds, _ = NewHMACIdentity("alice", []byte("wrong")).Credentials()
+	_, err = h.Authenticate(nil, creds)
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for a token signed with a wrong secret, should be an error")
+	}
+
+	creds, _ = NewHMACIdentity("alice", secret).Credentials()
+	creds.Issued = time.Now().Add(-2 * h.MaxAge).UnixNano()
+	creds.Token = Sign(secret, creds.Name, creds.Issued, creds.Nonce)
+	_, err = h.Authenticate(nil, creds)
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for an expired token, should be an error")
+	}
+
+	_, err = h.Authenticate(nil, Credentials{})
+	if err != ErrUnauthenticated {
+		t.Errorf("Authenticate() gave %v without credentials, should be %v", err, ErrUnauthenticated)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	// Setup
+	m := NewMemory()
+	m.Add(NewPrincipal("bob"), "s3cret")
+
+	creds, _ := NewTokenIdentity("bob", "s3cret").Credentials()
+	p, err := m.Authenticate(nil, creds)
+	if err != nil || p.Name != "bob" {
+		t.Errorf("Authenticate() gave %v and %v, should be bob", p, err)
+	}
+
+	creds, _ = NewTokenIdentity("bob", "guess").Credentials()
+	_, err = m.Authenticate(nil, creds)
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for a wrong token, should be an error")
+	}
+
+	m.Remove("bob")
+	creds, _ = NewTokenIdentity("bob", "s3cret").Credentials()
+	_, err = m.Authenticate(nil, creds)
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for a removed principal, should be an error")
+	}
+}
+
+// testCertificate creates a certificate named cn with organizational units ous, signed by parent with key signer.
+// testCertificate signs the certificate itself if parent is nil.
+func testCertificate(t *testing.T, cn string, ous []string, parent *x509.Certificate, signer *ecdsa.PrivateKey) (cert *x509.Certificate, key *ecdsa.PrivateKey, chain tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, OrganizationalUnit: ous},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{cn},
+	}
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent = tmpl
+		signer = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	return cert, key, chain
+}
+
+func TestTLS(t *testing.T) {
+	// Setup
+	ca, caKey, _ := testCertificate(t, "ca", nil, nil, nil)
+	_, _, server := testCertificate(t, "space", nil, ca, caKey)
+	_, _, client := testCertificate(t, "carol", []string{"ops", "hr"}, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	authn := NewTLS(&tls.Config{Certificates: []tls.Certificate{server}, ClientCAs: pool})
+	id := NewTLSIdentity(&tls.Config{Certificates: []tls.Certificate{client}, RootCAs: pool, ServerName: "space"})
+
+	sc, cc := net.Pipe()
+
+	type accepted struct {
+		p   Principal
+		err error
+	}
+	result := make(chan accepted)
+
+	go func() {
+		conn, err := authn.Accept(sc)
+		if err != nil {
+			result <- accepted{err: err}
+			return
+		}
+
+		p, err := authn.Authenticate(conn, Credentials{Scheme: SchemeTLS})
+		result <- accepted{p, err}
+	}()
+
+	_, err := id.Connect(cc)
+	if err != nil {
+		t.Fatalf("Connect() gave %v, should be nil", err)
+	}
+
+	r := <-result
+	if r.err != nil || r.p.Name != "carol" || r.p.Labels.Retrieve("ops") == nil || r.p.Labels.Retrieve("hr") == nil {
+		t.Errorf("Authenticate() gave %v and %v, should be carol holding ops and hr", r.p, r.err)
+	}
+
+	_, err = authn.Authenticate(sc, Credentials{Scheme: SchemeTLS})
+	if err == nil {
+		t.Errorf("Authenticate() gave nil for a connection without TLS, should be an error")
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAge is the time for which an HMAC token is accepted after it has been issued.
+const DefaultMaxAge = 5 * time.Minute
+
+// nonceSize is the number of random bytes in the nonce of an HMAC token.
+const nonceSize = 16
+
+// HMAC authenticates principals by tokens signed with secrets shared between each principal and the space.
+// Tokens are only accepted within MaxAge of being issued, and only once.
+type HMAC struct {
+	MaxAge time.Duration
+	mu     *sync.Mutex
+	keys   map[string]hmacKey
+	seen   map[string]time.Time
+}
+
+// hmacKey is the secret shared with a principal.
+type hmacKey struct {
+	principal Principal
+	secret    []byte
+}
+
+// NewHMAC creates an HMAC authenticator h without principals.
+func NewHMAC() (h *HMAC) {
+	h = &HMAC{MaxAge: DefaultMaxAge, mu: new(sync.Mutex), keys: make(map[string]hmacKey), seen: make(map[string]time.Time)}
+	return h
+}
+
+// Add lets principal p authenticate with secret.
+func (h *HMAC) Add(p Principal, secret []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.keys[p.Name] = hmacKey{principal: p, secret: append([]byte{}, secret...)}
+}
+
+// Remove stops the principal named name from authenticating.
+func (h *HMAC) Remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.keys, name)
+}
+
+// Accept returns connection conn as is.
+func (h *HMAC) Accept(conn net.Conn) (net.Conn, error) {
+	return conn, nil
+}
+
+// Authenticate returns the principal p whose secret signed the token of credentials creds.
+// Authenticate returns an error err if the token is unsigned, wrongly signed, expired or replayed.
+func (h *HMAC) Authenticate(conn net.Conn, creds Credentials) (p Principal, err error) {
+	if creds.Scheme != SchemeHMAC {
+		return p, schemeError(SchemeHMAC, creds)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key, exists := h.keys[creds.Name]
+	if !exists || !hmac.Equal(creds.Token, Sign(key.secret, creds.Name, creds.Issued, creds.Nonce)) {
+		return p, fmt.Errorf("auth: invalid token for principal %s", creds.Name)
+	}
+
+	now := time.Now()
+	issued := time.Unix(0, creds.Issued)
+	if issued.After(now.Add(h.MaxAge)) || now.Sub(issued) > h.MaxAge {
+		return p, fmt.Errorf("auth: expired token for principal %s", creds.Name)
+	}
+
+	for nonce, t := range h.seen {
+		if now.Sub(t) > h.MaxAge {
+			delete(h.seen, nonce)
+		}
+	}
+
+	nonce := creds.Name + "\x00" + string(creds.Nonce)
+	if _, replayed := h.seen[nonce]; replayed {
+		return p, fmt.Errorf("auth: replayed token for principal %s", creds.Name)
+	}
+	h.seen[nonce] = issued
+
+	p = key.principal
+
+	return p, err
+}
+
+// Sign computes the HMAC-SHA256 token of the principal named name issued at time issued with nonce, using secret.
+func Sign(secret []byte, name string, issued int64, nonce []byte) (token []byte) {
+	mac := hmac.New(sha256.New, secret)
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(issued))
+
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(buf)
+	mac.Write(nonce)
+
+	token = mac.Sum(nil)
+
+	return token
+}
+
+// HMACIdentity is the identity of a principal sharing a secret with the spaces it connects to.
+type HMACIdentity struct {
+	Name   string
+	Secret []byte
+}
+
+// NewHMACIdentity creates an identity id for the principal named name sharing secret.
+func NewHMACIdentity(name string, secret []byte) (id HMACIdentity) {
+	id = HMACIdentity{Name: name, Secret: secret}
+	return id
+}
+
+// Connect returns connection conn as is.
+func (id HMACIdentity) Connect(conn net.Conn) (net.Conn, error) {
+	return conn, nil
+}
+
+// Credentials issues a fresh token signed with the secret of identity id.
+func (id HMACIdentity) Credentials() (creds Credentials, err error) {
+	nonce := make([]byte, nonceSize)
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return creds, err
+	}
+
+	issued := time.Now().UnixNano()
+
+	creds = Credentials{Scheme: SchemeHMAC, Name: id.Name, Issued: issued, Nonce: nonce, Token: Sign(id.Secret, id.Name, issued, nonce)}
+
+	return creds, err
+}
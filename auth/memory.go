@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Memory authenticates principals by static tokens kept in memory.
+// Memory is meant for tests and local setups, since tokens are sent in the clear.
+type Memory struct {
+	mu     *sync.RWMutex
+	tokens map[string]memoryEntry
+}
+
+// memoryEntry is the token of a principal.
+type memoryEntry struct {
+	principal Principal
+	token     string
+}
+
+// NewMemory creates a memory authenticator m without principals.
+func NewMemory() (m *Memory) {
+	m = &Memory{mu: new(sync.RWMutex), tokens: make(map[string]memoryEntry)}
+	return m
+}
+
+// Add lets principal p authenticate with token.
+func (m *Memory) Add(p Principal, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[p.Name] = memoryEntry{principal: p, token: token}
+}
+
+// Remove stops the principal named name from authenticating.
+func (m *Memory) Remove(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tokens, name)
+}
+
+// Accept returns connection conn as is.
+func (m *Memory) Accept(conn net.Conn) (net.Conn, error) {
+	return conn, nil
+}
+
+// Authenticate returns the principal p whose token is presented in credentials creds.
+func (m *Memory) Authenticate(conn net.Conn, creds Credentials) (p Principal, err error) {
+	if creds.Scheme != SchemeToken {
+		return p, schemeError(SchemeToken, creds)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, exists := m.tokens[creds.Name]
+	if !exists || subtle.ConstantTimeCompare([]byte(entry.token), creds.Token) != 1 {
+		return p, fmt.Errorf("auth: invalid token for principal %s", creds.Name)
+	}
+
+	p = entry.principal
+
+	return p, err
+}
+
+// TokenIdentity is the identity of a principal presenting a static token.
+type TokenIdentity struct {
+	Name  string
+	Token string
+}
+
+// NewTokenIdentity creates an identity id for the principal named name presenting token.
+func NewTokenIdentity(name string, token string) (id TokenIdentity) {
+	id = TokenIdentity{Name: name, Token: token}
+	return id
+}
+
+// Connect returns connection conn as is.
+func (id TokenIdentity) Connect(conn net.Conn) (net.Conn, error) {
+	return conn, nil
+}
+
+// Credentials returns the static token of identity id.
+func (id TokenIdentity) Credentials() (creds Credentials, err error) {
+	creds = Credentials{Scheme: SchemeToken, Name: id.Name, Token: []byte(id.Token)}
+	return creds, err
+}
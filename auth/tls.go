@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// TLS authenticates principals by the client certificates they present when connections are wrapped in TLS.
+// The principal is named by the common name of the certificate and holds a label per organizational unit.
+// The configuration must make the server require and verify client certificates.
+type TLS struct {
+	Config *tls.Config
+}
+
+// NewTLS creates a TLS authenticator t with server configuration config.
+// NewTLS requires and verifies client certificates if config does not say otherwise.
+func NewTLS(config *tls.Config) (t *TLS) {
+	cfg := config.Clone()
+
+	if cfg.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	t = &TLS{Config: cfg}
+
+	return t
+}
+
+// Accept wraps connection conn in TLS and completes the handshake.
+func (t *TLS) Accept(conn net.Conn) (net.Conn, error) {
+	tc := tls.Server(conn, t.Config)
+
+	err := tc.Handshake()
+
+	return tc, err
+}
+
+// Authenticate returns the principal p of the verified client certificate of connection conn.
+func (t *TLS) Authenticate(conn net.Conn, creds Credentials) (p Principal, err error) {
+	if creds.Scheme != SchemeTLS {
+		return p, schemeError(SchemeTLS, creds)
+	}
+
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return p, errors.New("auth: connection is not secured by TLS")
+	}
+
+	state := tc.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return p, errors.New("auth: no verified client certificate")
+	}
+
+	p = CertificatePrincipal(state.VerifiedChains[0][0])
+
+	if p.Name == "" {
+		return p, fmt.Errorf("auth: client certificate %s has no common name", state.VerifiedChains[0][0].SerialNumber)
+	}
+
+	return p, err
+}
+
+// CertificatePrincipal returns the principal p identified by certificate cert.
+func CertificatePrincipal(cert *x509.Certificate) (p Principal) {
+	lbls := make([]container.Label, 0, len(cert.Subject.OrganizationalUnit))
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		lbls = append(lbls, container.NewLabel(ou))
+	}
+
+	p = NewPrincipal(cert.Subject.CommonName, lbls...)
+
+	return p
+}
+
+// TLSIdentity is the identity of a principal presenting a client certificate.
+type TLSIdentity struct {
+	Config *tls.Config
+}
+
+// NewTLSIdentity creates an identity id with client configuration config, which must contain the client certificate.
+func NewTLSIdentity(config *tls.Config) (id TLSIdentity) {
+	id = TLSIdentity{Config: config}
+	return id
+}
+
+// Connect wraps connection conn in TLS and completes the handshake.
+func (id TLSIdentity) Connect(conn net.Conn) (net.Conn, error) {
+	tc := tls.Client(conn, id.Config)
+
+	err := tc.Handshake()
+
+	return tc, err
+}
+
+// Credentials returns credentials referring to the client certificate of the connection.
+func (id TLSIdentity) Credentials() (creds Credentials, err error) {
+	creds = Credentials{Scheme: SchemeTLS}
+	return creds, err
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/space"
+)
+
+// operation is an operation on a space which can be run from the command line.
+type operation struct {
+	formal bool // True if the operation takes a template, and false if it takes a tuple.
+	run    func(spc *space.Space, fields []interface{}) ([]container.Tuple, error)
+}
+
+// single adapts an operation fun on a space returning a single tuple to return a list of tuples.
+func single(fun func(*space.Space, ...interface{}) (container.Tuple, error)) func(*space.Space, []interface{}) ([]container.Tuple, error) {
+	return func(spc *space.Space, fields []interface{}) (ts []container.Tuple, err error) {
+		t, err := fun(spc, fields...)
+		if err == nil {
+			ts = []container.Tuple{t}
+		}
+
+		return ts, err
+	}
+}
+
+// multiple adapts an operation fun on a space returning a list of tuples.
+func multiple(fun func(*space.Space, ...interface{}) ([]container.Tuple, error)) func(*space.Space, []interface{}) ([]container.Tuple, error) {
+	return func(spc *space.Space, fields []interface{}) ([]container.Tuple, error) {
+		return fun(spc, fields...)
+	}
+}
+
+// operations contains the operations which can be run from the command line by name.
+var operations = map[string]operation{
+	"put":      {false, single((*space.Space).Put)},
+	"putp":     {false, single((*space.Space).PutP)},
+	"get":      {true, single((*space.Space).Get)},
+	"getp":     {true, single((*space.Space).GetP)},
+	"query":    {true, single((*space.Space).Query)},
+	"queryp":   {true, single((*space.Space).QueryP)},
+	"getall":   {true, multiple((*space.Space).GetAll)},
+	"queryall": {true, multiple((*space.Space).QueryAll)},
+}
+
+// parseFields parses the fields of the tuple literal s, or of the template literal s if formal is true,
+// in the literal format read by container.Parse.
+func parseFields(s string, formal bool) (fields []interface{}, err error) {
+	v, err := container.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch lit := v.(type) {
+	case container.Tuple:
+		fields = lit.Flds
+	case container.Template:
+		if !formal {
+			return nil, fmt.Errorf("tuples can not contain formal fields")
+		}
+
+		fields = lit.Flds
+	default:
+		return nil, fmt.Errorf("labelled tuples are not supported")
+	}
+
+	return fields, err
+}
+
+// describe returns a print friendly description of the error err of an operation.
+func describe(err error) (s string) {
+	if se, ok := err.(space.SpaceError); ok && se.Msg != "" {
+		return se.Msg
+	}
+
+	return err.Error()
+}
+
+// credentials are the flags naming the principal a command connects to a space as.
+type credentials struct {
+	user  *string
+	token *string
+}
+
+// clientFlags returns the flag set fs of the command named name, with the flags of credentials creds.
+func clientFlags(name string, e *env) (fs *flag.FlagSet, creds credentials) {
+	fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.err)
+
+	creds.user = fs.String("user", os.Getenv("GOSPACE_USER"), "name of the principal to connect as")
+	creds.token = fs.String("token", os.Getenv("GOSPACE_TOKEN"), "token of the principal to connect as")
+
+	return fs, creds
+}
+
+// connect returns the remote space spc at url, identified by credentials creds if a user is given.
+func connect(url string, creds credentials) (spc space.Space) {
+	spc = space.NewRemoteSpace(url)
+
+	if *creds.user != "" {
+		spc = spc.WithIdentity(auth.NewTokenIdentity(*creds.user, *creds.token))
+	}
+
+	return spc
+}
+
+// parseArgs parses the flags of fs from args, and returns the n positional arguments which must follow them.
+func parseArgs(fs *flag.FlagSet, args []string, n int) (pos []string, err error) {
+	err = fs.Parse(args)
+
+	if err != nil {
+		return nil, usageError("%s", err)
+	}
+
+	pos = fs.Args()
+
+	if len(pos) < n || (n == 1 && len(pos) > 1) {
+		return nil, usageError("expected %d arguments but got %d", n, len(pos))
+	}
+
+	// Literals may be given as several arguments, e.g. when they contain unquoted spaces.
+	if len(pos) > n {
+		pos = append(pos[:n-1], strings.Join(pos[n-1:], " "))
+	}
+
+	return pos, err
+}
+
+// printTuples writes tuples ts to w, one per line.
+func printTuples(w io.Writer, ts []container.Tuple) {
+	for _, t := range ts {
+		fmt.Fprintln(w, t)
+	}
+}
+
+// runOperation runs the operation named name on space spc with the tuple or template literal literal, and writes the resulting tuples to w.
+func runOperation(w io.Writer, spc *space.Space, name string, literal string) (err error) {
+	op := operations[name]
+
+	fields, err := parseFields(literal, op.formal)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	ts, err := op.run(spc, fields)
+
+	if err == nil {
+		printTuples(w, ts)
+	}
+
+	return err
+}
+
+// operate returns the command running the operation named name.
+func operate(name string) func(e *env, args []string) error {
+	return func(e *env, args []string) (err error) {
+		fs, creds := clientFlags(name, e)
+		timeout := fs.Duration("timeout", 0, "time to wait for the operation to finish, or 0 to wait forever")
+
+		pos, err := parseArgs(fs, args, 2)
+		if err != nil {
+			return err
+		}
+
+		spc := connect(pos[0], creds)
+
+		if *timeout <= 0 {
+			return runOperation(e.out, &spc, name, pos[1])
+		}
+
+		// Blocking operations can not be abandoned, so they are left running once the command has given up on them.
+		result := make(chan error, 1)
+		go func() {
+			result <- runOperation(e.out, &spc, name, pos[1])
+		}()
+
+		select {
+		case err = <-result:
+		case <-time.After(*timeout):
+			err = fmt.Errorf("no result within %s", *timeout)
+		}
+
+		return err
+	}
+}
+
+// size writes the number of tuples in a space.
+func size(e *env, args []string) (err error) {
+	fs, creds := clientFlags("size", e)
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	spc := connect(pos[0], creds)
+
+	n, err := spc.Size()
+
+	if err == nil {
+		fmt.Fprintln(e.out, n)
+	}
+
+	return err
+}
+
+// watch writes the tuples of a space matching a template, and then those placed later on as they appear.
+// Spaces notify no peers of tuples being placed, so watch queries the space at an interval and writes the tuples it has not seen yet.
+// Tuples placed and retrieved again between two queries are never seen, hence never written.
+func watch(e *env, args []string) (err error) {
+	fs, creds := clientFlags("watch", e)
+	interval := fs.Duration("interval", time.Second, "time between queries of the space, missing tuples placed and retrieved in between")
+	count := fs.Int("n", 0, "number of tuples to write before stopping, or 0 to never stop")
+
+	pos, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	fields, err := parseFields(pos[1], true)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	spc := connect(pos[0], creds)
+
+	// Tuples are compared by their printed form, counting duplicates.
+	seen := make(map[string]int)
+	written := 0
+
+	for {
+		ts, err := spc.QueryAll(fields...)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]int)
+		for _, t := range ts {
+			s := t.String()
+			current[s]++
+
+			if current[s] > seen[s] {
+				fmt.Fprintln(e.out, s)
+				written++
+
+				if *count > 0 && written >= *count {
+					return nil
+				}
+			}
+		}
+
+		seen = current
+
+		select {
+		case <-e.stop:
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// stats writes what a space holds and who is connected to it. Only administrators of the space may inspect it.
+func stats(e *env, args []string) (err error) {
+	fs, creds := clientFlags("stats", e)
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	spc := connect(pos[0], creds)
+
+	return writeStats(e.out, &spc)
+}
+
+// writeStats writes statistics on space spc to w.
+func writeStats(w io.Writer, spc *space.Space) (err error) {
+	page, err := spc.InspectTuples(0, 1)
+	if err != nil {
+		return err
+	}
+
+	pi, err := spc.InspectPolicy()
+	if err != nil {
+		return err
+	}
+
+	names, err := spc.InspectFunctions()
+	if err != nil {
+		return err
+	}
+
+	waiting, err := spc.InspectWaitingClients()
+	if err != nil {
+		return err
+	}
+
+	conns, err := spc.InspectConnections()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "tuples: %d\n", page.Total)
+	fmt.Fprintf(w, "policy: version %d\n", pi.Version)
+	fmt.Fprintf(w, "functions: %s\n", strings.Join(names, ", "))
+
+	fmt.Fprintf(w, "waiting clients: %d\n", len(waiting))
+	for _, wc := range waiting {
+		fmt.Fprintf(w, "  %s %s for %d tuples, waiting %s\n", wc.Operation, wc.Template, wc.Count, wc.Age.Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(w, "connections: %d\n", len(conns))
+	for _, c := range conns {
+		principal := c.Principal
+		if principal == "" {
+			principal = "-"
+		}
+
+		fmt.Fprintf(w, "  %s %s %s, open %s\n", c.Remote, principal, c.Operation, c.Age.Round(time.Millisecond))
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+// Command gospace hosts spaces and operates on them from the command line.
+//
+// Usage:
+//
+//	gospace serve -config gospace.yaml
+//	gospace put|putp [-timeout duration] [-user name -token token] URL TUPLE
+//	gospace get|getp|query|queryp|getall|queryall [-timeout duration] [-user name -token token] URL TEMPLATE
+//	gospace size [-user name -token token] URL
+//	gospace watch [-interval 1s] [-n count] [-user name -token token] URL TEMPLATE
+//	gospace stats [-user name -token token] URL
+//	gospace shell [-history file] [-user name -token token] URL
+//
+// Tuples and templates are written as literals in the format of container.Parse, such as ("job", 42, 3.5),
+// and templates may name types instead of values, such as ("job", int, ?price:float64). Credentials default to the environment variables
+// GOSPACE_USER and GOSPACE_TOKEN, such that tokens need not be given on the command line.
+// Blocking operations wait until the space can be reached and a tuple matches, unless a timeout is given.
+// watch samples the space by querying it every interval, so it misses tuples which are placed and retrieved again within an interval.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// env is the environment a command runs in.
+type env struct {
+	in   io.Reader
+	out  io.Writer
+	err  io.Writer
+	stop <-chan struct{} // Closed when long running commands should stop.
+}
+
+// command is a subcommand of gospace, run with the arguments following its name.
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+// commands contains the subcommands of gospace by name.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve": {"serve -config file", serve},
+		"size":  {"size [-user name -token token] URL", size},
+		"watch": {"watch [-interval duration] [-n count] [-user name -token token] URL TEMPLATE", watch},
+		"stats": {"stats [-user name -token token] URL", stats},
+		"shell": {"shell [-history file] [-user name -token token] URL", shell},
+	}
+
+	for name, op := range operations {
+		literal := "TEMPLATE"
+		if !op.formal {
+			literal = "TUPLE"
+		}
+
+		commands[name] = command{fmt.Sprintf("%s [-timeout duration] [-user name -token token] URL %s", name, literal), operate(name)}
+	}
+}
+
+// errUsage is returned by commands which are given invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+// usageError returns an error err wrapping errUsage with the message given by format and args.
+func usageError(format string, args ...interface{}) (err error) {
+	err = fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+	return err
+}
+
+// usage writes the usage of all subcommands to w.
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  gospace %s\n", commands[name].usage)
+	}
+}
+
+// run runs the subcommand named by the first of args in environment e.
+// run returns the exit code of gospace: 0 on success, 1 if the command failed, and 2 if it was used wrongly.
+func run(args []string, e *env) (code int) {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		usage(e.err)
+
+		if len(args) == 0 {
+			return 2
+		}
+
+		return 0
+	}
+
+	cmd, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(e.err, "gospace: unknown command %q\n", args[0])
+		usage(e.err)
+		return 2
+	}
+
+	err := cmd.run(e, args[1:])
+
+	switch {
+	case err == nil:
+		code = 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(e.err, "gospace %s: %s\nUsage: gospace %s\n", args[0], strings.TrimPrefix(err.Error(), errUsage.Error()+": "), cmd.usage)
+		code = 2
+	default:
+		fmt.Fprintf(e.err, "gospace %s: %s\n", args[0], describe(err))
+		code = 1
+	}
+
+	return code
+}
+
+func main() {
+	stop := make(chan struct{})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	os.Exit(run(os.Args[1:], &env{in: os.Stdin, out: os.Stdout, err: os.Stderr, stop: stop}))
+}
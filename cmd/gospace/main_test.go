@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/space"
+)
+
+// gospace runs gospace with arguments args and input in, and returns its exit code and output.
+func gospace(in string, args ...string) (code int, out string, errOut string) {
+	var stdout, stderr bytes.Buffer
+
+	e := &env{in: strings.NewReader(in), out: &stdout, err: &stderr, stop: make(chan struct{})}
+	code = run(args, e)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		literal  string
+		formal   bool
+		expected []interface{}
+	}{
+		{`("job", 42, 3.5)`, false, []interface{}{"job", 42, 3.5}},
+		{`(-1, -2.5, true, false, ` + "`raw`" + `)`, false, []interface{}{-1, -2.5, true, false, "raw"}},
+		{`()`, false, []interface{}{}},
+		{`("job", int, float64, string, bool)`, true, []interface{}{"job", container.CreateTypeField(0), container.CreateTypeField(0.0), container.CreateTypeField(""), container.CreateTypeField(false)}},
+	}
+
+	for _, test := range tests {
+		fields, err := parseFields(test.literal, test.formal)
+		if err != nil || !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("parseFields(%s, %t) gave %v and error %v, should be %v", test.literal, test.formal, fields, err, test.expected)
+		}
+	}
+
+	invalid := []string{`"job", 42`, `("job" 42)`, `("job", int)`, `("job",)`, `("job"`, `("job") x`, `(x)`, `(-"job")`, `({|a|} : 1)`}
+	for _, literal := range invalid {
+		if fields, err := parseFields(literal, false); err == nil {
+			t.Errorf("parseFields(%s, false) gave %v, should be an error", literal, fields)
+		}
+	}
+}
+
+func TestOperations(t *testing.T) {
+	// Setup
+	url := "tcp://localhost:31725/cli"
+	spc := space.NewSpace(url)
+
+	// Test that tuples are placed and retrieved with literals.
+	code, out, errOut := gospace("", "put", url, `("job", 42, 3.5)`)
+	if code != 0 || out != "(\"job\", 42, 3.5)\n" {
+		t.Errorf("put gave code %d, output %q and errors %q, should place the tuple", code, out, errOut)
+	}
+
+	gospace("", "put", url, `("job",`, `43, 4.5)`)
+
+	if n, _ := spc.Size(); n != 2 {
+		t.Errorf("Size() gave %d after putting, should be 2", n)
+	}
+
+	code, out, _ = gospace("", "size", url)
+	if code != 0 || out != "2\n" {
+		t.Errorf("size gave code %d and output %q, should be 2", code, out)
+	}
+
+	code, out, _ = gospace("", "query", url, `("job", 43, float64)`)
+	if code != 0 || out != "(\"job\", 43, 4.5)\n" {
+		t.Errorf("query gave code %d and output %q, should find the second job", code, out)
+	}
+
+	code, out, _ = gospace("", "getall", url, `("job", int, float64)`)
+	if code != 0 || out != "(\"job\", 42, 3.5)\n(\"job\", 43, 4.5)\n" {
+		t.Errorf("getall gave code %d and output %q, should retrieve both jobs", code, out)
+	}
+
+	// Test that failures and misuse are reported by the exit code.
+	code, out, errOut = gospace("", "getp", url, `("job", int, float64)`)
+	if code != 1 || out != "" || errOut == "" {
+		t.Errorf("getp gave code %d, output %q and errors %q on an empty space, should fail with 1", code, out, errOut)
+	}
+
+	code, _, errOut = gospace("", "get", "-timeout", "50ms", url, `("job", int, float64)`)
+	if code != 1 || !strings.Contains(errOut, "no result within 50ms") {
+		t.Errorf("get gave code %d and errors %q on an empty space, should time out with 1", code, errOut)
+	}
+
+	for _, args := range [][]string{{"put", url}, {"put", url, `("job", int)`}, {"unknown"}, {}} {
+		if code, _, _ = gospace("", args...); code != 2 {
+			t.Errorf("gospace %v gave code %d, should be 2", args, code)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	// Setup
+	url := "tcp://localhost:31726/cli"
+	spc := space.NewSpace(url)
+
+	spc.Put("event", 1)
+
+	var code int
+	var out string
+
+	done := make(chan struct{})
+	go func() {
+		code, out, _ = gospace("", "watch", "-interval", "10ms", "-n", "3", url, `("event", int)`)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	spc.Put("other", 2)
+	spc.Put("event", 2)
+	spc.Put("event", 1)
+
+	// Test that present and later tuples are written once each.
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch did not stop after 3 tuples")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(lines) != 3 || lines[0] != "(\"event\", 1)" {
+		t.Errorf("watch gave code %d and output %q, should write the 3 events", code, out)
+	}
+}
+
+func TestStats(t *testing.T) {
+	// Setup
+	url := "tcp://localhost:31727/cli"
+	spc := space.NewSpace(url)
+
+	spc.Put("job", 1)
+
+	authn := auth.NewMemory()
+	authn.Add(auth.NewPrincipal("root"), "root-token")
+	spc.SetAuthenticator(authn)
+	spc.SetAdmins("root")
+
+	// Test that only administrators get statistics.
+	code, out, errOut := gospace("", "stats", "-user", "root", "-token", "root-token", url)
+	if code != 0 || !strings.Contains(out, "tuples: 1\n") || !strings.Contains(out, "root InspectConnections") {
+		t.Errorf("stats gave code %d, output %q and errors %q, should describe the space", code, out, errOut)
+	}
+
+	if code, _, _ = gospace("", "stats", url); code != 1 {
+		t.Errorf("stats gave code %d without credentials, should be 1", code)
+	}
+}
+
+func TestShell(t *testing.T) {
+	// Setup
+	url := "tcp://localhost:31728/cli"
+	space.NewSpace(url)
+
+	history := filepath.Join(t.TempDir(), "history")
+	ioutil.WriteFile(history, []byte("put (\"old\", 0)\n"), 0600)
+
+	script := "put (\"job\", 1)\nput(\"job\", 2)\nsize\n!1\nfrobnicate\nget (\"job\", int)\nhistory\nexit\nsize\n"
+
+	// Test that commands run in order, including those recalled from the history.
+	code, out, errOut := gospace(script, "shell", "-history", history, url)
+	for _, expected := range []string{"(\"job\", 1)\n", "gospace> 2\n", "put (\"old\", 0)\n(\"old\", 0)\n", "    6  frobnicate\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("shell gave output %q, should contain %q", out, expected)
+		}
+	}
+
+	if code != 0 || !strings.Contains(errOut, "unknown command \"frobnicate\"") {
+		t.Errorf("shell gave code %d and errors %q, should report the unknown command", code, errOut)
+	}
+
+	if n := strings.Count(out, "gospace> "); n != 8 {
+		t.Errorf("shell prompted %d times, should stop at exit after 8 prompts", n)
+	}
+
+	data, _ := ioutil.ReadFile(history)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 9 || lines[8] != "exit" {
+		t.Errorf("shell kept history %q, should append all 8 commands", data)
+	}
+}
+
+func TestServe(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+
+	cfg := "version: 1\nprincipals:\n  - name: root\n    token: root-token\nadmins: [root]\nspaces:\n  - name: jobs\n    url: tcp://localhost:31729/jobs\n"
+	path := filepath.Join(dir, "gospace.yaml")
+	ioutil.WriteFile(path, []byte(cfg), 0600)
+
+	var stdout, stderr bytes.Buffer
+	stop := make(chan struct{})
+	done := make(chan int)
+
+	go func() {
+		done <- run([]string{"serve", "-config", path}, &env{out: &stdout, err: &stderr, stop: stop})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Test that the configured spaces are hosted with their principals and administrators.
+	url := "tcp://localhost:31729/jobs"
+
+	code, _, _ := gospace("", "put", "-user", "root", "-token", "root-token", url, `("job", 1)`)
+	if code != 0 {
+		t.Errorf("put gave code %d with credentials, should be 0", code)
+	}
+
+	code, out, _ := gospace("", "stats", "-user", "root", "-token", "root-token", url)
+	if code != 0 || !strings.Contains(out, "tuples: 1\n") {
+		t.Errorf("stats gave code %d and output %q, should count the tuple placed", code, out)
+	}
+
+	if code, _, _ = gospace("", "putp", "-user", "root", "-token", "wrong", url, `("job", 2)`); code != 1 {
+		t.Errorf("putp gave code %d with a wrong token, should be 1", code)
+	}
+
+	close(stop)
+
+	if code = <-done; code != 0 || stdout.String() != "serving jobs at "+url+"\n" {
+		t.Errorf("serve gave code %d, output %q and errors %q, should serve the jobs space", code, stdout.String(), stderr.String())
+	}
+
+	// Test that invalid configuration files are reported.
+	ioutil.WriteFile(path, []byte("version: 2\n"), 0600)
+	if code, _, _ = gospace("", "serve", "-config", path); code != 1 {
+		t.Errorf("serve gave code %d for an unsupported version, should be 1", code)
+	}
+}
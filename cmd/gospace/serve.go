@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+	"github.com/pspaces/gospace/space"
+	"gopkg.in/yaml.v3"
+)
+
+// configVersion is the version of the configuration file format.
+const configVersion = 1
+
+// config is the structure of the configuration file of a server.
+//
+//	version: 1
+//	principals:
+//	  - name: root
+//	    token: secret
+//	    labels: [hr]
+//	admins: [root]
+//	spaces:
+//	  - name: jobs
+//	    url: tcp://localhost:31415/jobs
+//	    policy: jobs-policy.yaml
+//
+// Principals authenticate with their tokens if any are listed, and the spaces are open to anyone otherwise.
+// Policies are policy files, relative to the directory of the configuration file.
+type config struct {
+	Version    int               `yaml:"version"`
+	Principals []configPrincipal `yaml:"principals"`
+	Admins     []string          `yaml:"admins"`
+	Spaces     []configSpace     `yaml:"spaces"`
+}
+
+// configPrincipal is a principal of a configuration file.
+type configPrincipal struct {
+	Name   string   `yaml:"name"`
+	Token  string   `yaml:"token"`
+	Labels []string `yaml:"labels"`
+}
+
+// configSpace is a space of a configuration file.
+type configSpace struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`
+	Policy string `yaml:"policy"`
+}
+
+// loadConfig reads the configuration file at path.
+func loadConfig(path string) (cfg config, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	err = yaml.Unmarshal(data, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if cfg.Version != configVersion {
+		return cfg, fmt.Errorf("%s: unsupported version %d, expected %d", path, cfg.Version, configVersion)
+	}
+
+	names := make(map[string]bool)
+	for i, s := range cfg.Spaces {
+		if s.Name == "" || s.URL == "" {
+			return cfg, fmt.Errorf("%s: space %d needs a name and a url", path, i+1)
+		}
+
+		if names[s.Name] {
+			return cfg, fmt.Errorf("%s: space %s is listed twice", path, s.Name)
+		}
+
+		names[s.Name] = true
+
+		if s.Policy != "" && !filepath.IsAbs(s.Policy) {
+			cfg.Spaces[i].Policy = filepath.Join(filepath.Dir(path), s.Policy)
+		}
+	}
+
+	return cfg, err
+}
+
+// authenticator returns the authenticator of the principals of configuration cfg, or nil if it lists none.
+func (cfg config) authenticator() (a auth.Authenticator) {
+	if len(cfg.Principals) == 0 {
+		return nil
+	}
+
+	m := auth.NewMemory()
+	for _, p := range cfg.Principals {
+		lbls := make([]container.Label, len(p.Labels))
+		for i, id := range p.Labels {
+			lbls[i] = container.NewLabel(id)
+		}
+
+		m.Add(auth.NewPrincipal(p.Name, lbls...), p.Token)
+	}
+
+	return m
+}
+
+// host adds the spaces of configuration cfg to repository r.
+func host(r *space.Repository, cfg config) (err error) {
+	a := cfg.authenticator()
+
+	for _, s := range cfg.Spaces {
+		var cps []*policy.Composable
+
+		if s.Policy != "" {
+			cp, err := policy.LoadFile(s.Policy)
+			if err != nil {
+				return fmt.Errorf("%s: %s", s.Policy, err)
+			}
+
+			cps = append(cps, cp)
+		}
+
+		spc, _ := r.Add(s.Name, s.URL, cps...)
+
+		if a != nil {
+			spc.SetAuthenticator(a)
+		}
+
+		spc.SetAdmins(cfg.Admins...)
+	}
+
+	return err
+}
+
+// serve hosts the spaces of a configuration file until it is stopped.
+func serve(e *env, args []string) (err error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(e.err)
+	path := fs.String("config", "gospace.yaml", "configuration file listing the spaces to host")
+
+	err = fs.Parse(args)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	if fs.NArg() > 0 {
+		return usageError("unexpected argument %q", fs.Arg(0))
+	}
+
+	cfg, err := loadConfig(*path)
+	if err != nil {
+		return err
+	}
+
+	r := space.NewRepository()
+
+	err = host(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range r.Names() {
+		url, _ := r.URL(name)
+		fmt.Fprintf(e.out, "serving %s at %s\n", name, url)
+	}
+
+	<-e.stop
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pspaces/gospace/space"
+)
+
+// shellHelp describes the commands of the shell.
+const shellHelp = `Commands:
+  put TUPLE, putp TUPLE          place a tuple, e.g. put ("job", 42, 3.5)
+  get TEMPLATE, getp TEMPLATE    retrieve a tuple, e.g. get ("job", int, float64)
+  query TEMPLATE, queryp TEMPLATE
+  getall TEMPLATE, queryall TEMPLATE
+  size                           number of tuples in the space
+  stats                          statistics of the space, for administrators
+  history                        numbered list of previous commands
+  !N                             run command N of the history again
+  help                           this text
+  exit, quit                     leave the shell`
+
+// defaultHistory returns the path of the history file used if none is given, or an empty path if there is no home directory.
+func defaultHistory() (path string) {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		path = filepath.Join(home, ".gospace_history")
+	}
+
+	return path
+}
+
+// shell runs commands on a space read line by line, keeping a history of them.
+func shell(e *env, args []string) (err error) {
+	fs, creds := clientFlags("shell", e)
+	historyPath := fs.String("history", defaultHistory(), "file keeping the history of commands, or empty to keep none")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	spc := connect(pos[0], creds)
+
+	sh := &session{env: e, spc: &spc}
+
+	if *historyPath != "" {
+		sh.load(*historyPath)
+
+		f, err := os.OpenFile(*historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		sh.file = f
+	}
+
+	return sh.loop()
+}
+
+// session is a running shell connected to a space.
+type session struct {
+	*env
+	spc     *space.Space
+	history []string
+	file    io.Writer // Receives the commands added to the history, if it is kept in a file.
+}
+
+// load reads the history of previous sessions kept in the file at path, if any.
+func (sh *session) load(path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sh.history = append(sh.history, line)
+		}
+	}
+}
+
+// remember adds the command line to the history of session sh.
+func (sh *session) remember(line string) {
+	sh.history = append(sh.history, line)
+
+	if sh.file != nil {
+		fmt.Fprintln(sh.file, line)
+	}
+}
+
+// loop reads and runs commands until the input ends or the shell is left.
+func (sh *session) loop() (err error) {
+	lines := bufio.NewScanner(sh.in)
+
+	for {
+		fmt.Fprint(sh.out, "gospace> ")
+
+		if !lines.Scan() {
+			fmt.Fprintln(sh.out)
+			return lines.Err()
+		}
+
+		line := strings.TrimSpace(lines.Text())
+
+		if strings.HasPrefix(line, "!") {
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(sh.history) {
+				fmt.Fprintf(sh.err, "no command %s in the history\n", line[1:])
+				continue
+			}
+
+			line = sh.history[n-1]
+			fmt.Fprintln(sh.out, line)
+		}
+
+		if line == "" {
+			continue
+		}
+
+		sh.remember(line)
+
+		if !sh.exec(line) {
+			return nil
+		}
+	}
+}
+
+// exec runs the command line, and returns false if the shell should be left.
+func (sh *session) exec(line string) (cont bool) {
+	name, literal := line, ""
+	if i := strings.IndexAny(line, " \t("); i >= 0 {
+		name, literal = line[:i], strings.TrimSpace(line[i:])
+	}
+
+	var err error
+
+	switch name {
+	case "exit", "quit":
+		return false
+	case "help":
+		fmt.Fprintln(sh.out, shellHelp)
+	case "history":
+		for i, cmd := range sh.history {
+			fmt.Fprintf(sh.out, "%5d  %s\n", i+1, cmd)
+		}
+	case "size":
+		var n int
+		n, err = sh.spc.Size()
+		if err == nil {
+			fmt.Fprintln(sh.out, n)
+		}
+	case "stats":
+		err = writeStats(sh.out, sh.spc)
+	default:
+		if _, exists := operations[name]; !exists {
+			fmt.Fprintf(sh.err, "unknown command %q, try help\n", name)
+			return true
+		}
+
+		err = runOperation(sh.out, sh.spc, name, literal)
+	}
+
+	if err != nil {
+		fmt.Fprintf(sh.err, "%s: %s\n", name, strings.TrimPrefix(describe(err), errUsage.Error()+": "))
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package container
+
+import (
+	"github.com/pspaces/gospace/function"
+)
+
+// FuncRef is a reference to a function given by the namespace of the function.
+// Functions in tuples and templates are sent between peers as function references.
+type FuncRef struct {
+	Namespace string `json:"namespace"`
+}
+
+// NewFuncRef creates a function reference ref to the function with namespace ns.
+func NewFuncRef(ns string) (ref FuncRef) {
+	ref = FuncRef{Namespace: ns}
+	return ref
+}
+
+// RefOf creates a function reference ref to function fun.
+func RefOf(fun interface{}) (ref FuncRef) {
+	ref = NewFuncRef(string(function.NamespaceOf(fun)))
+	return ref
+}
+
+// String returns a print friendly representation of the function reference ref.
+func (ref FuncRef) String() (s string) {
+	s = ref.Namespace
+	return s
+}
+
+// isFuncRef returns true if field is a function reference, and false otherwise.
+func isFuncRef(field interface{}) (b bool) {
+	_, b = field.(FuncRef)
+	return b
+}
+
+// namespaceOf returns the namespace ns of a function or function reference field.
+// namespaceOf returns false if field is neither a function nor a function reference.
+func namespaceOf(field interface{}) (ns string, b bool) {
+	if ref, isRef := field.(FuncRef); isRef {
+		ns, b = ref.Namespace, true
+	} else if field != nil && function.IsFunc(field) {
+		ns, b = string(function.NamespaceOf(field)), true
+	}
+
+	return ns, b
+}
+
+// funcRefMatch returns true if the fields f and g refer to the same function, where at least one of them is a function reference.
+func funcRefMatch(f interface{}, g interface{}) (b bool) {
+	fns, fb := namespaceOf(f)
+	gns, gb := namespaceOf(g)
+	b = fb && gb && fns == gns
+	return b
+}
@@ -0,0 +1,57 @@
+package container
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func funcRefTestFunction(x int) int {
+	return x
+}
+
+// Test to see if function references match the functions they refer to.
+func TestFuncRefMatch(t *testing.T) {
+	// Setup
+	ref := RefOf(funcRefTestFunction)
+
+	testTuple := NewTuple("f", ref)
+
+	if !testTuple.Match(NewTemplate("f", funcRefTestFunction)) {
+		t.Errorf("Match() on tuple %v gave false for the referenced function, should be true", testTuple)
+	}
+
+	if !testTuple.Match(NewTemplate("f", NewFuncRef(ref.Namespace))) {
+		t.Errorf("Match() on tuple %v gave false for an equal reference, should be true", testTuple)
+	}
+
+	// A plain string equal to the namespace is not a reference.
+	if testTuple.Match(NewTemplate("f", ref.Namespace)) {
+		t.Errorf("Match() on tuple %v gave true for a string, should be false", testTuple)
+	}
+}
+
+// Test to see if function references survive encoding.
+func TestFuncRefEncoding(t *testing.T) {
+	// Setup
+	actualRef := RefOf(funcRefTestFunction)
+
+	var buf bytes.Buffer
+	var gobRef FuncRef
+	gob.NewEncoder(&buf).Encode(actualRef)
+	gob.NewDecoder(&buf).Decode(&gobRef)
+
+	if !reflect.DeepEqual(gobRef, actualRef) {
+		t.Errorf("gob encoding gave %v, should be %v", gobRef, actualRef)
+	}
+
+	var jsonRef FuncRef
+	data, _ := json.Marshal(actualRef)
+	json.Unmarshal(data, &jsonRef)
+
+	if !reflect.DeepEqual(jsonRef, actualRef) {
+		t.Errorf("JSON encoding gave %v from %s, should be %v", jsonRef, data, actualRef)
+	}
+}
@@ -2,8 +2,6 @@ package container
 
 import (
 	"fmt"
-	"reflect"
-	"strings"
 )
 
 // LabelledTuple is a labelled tuple containing a list of fields and a label set.
@@ -147,29 +145,13 @@ func (lt LabelledTuple) Delimiter() string {
 	return ", "
 }
 
-// String returns a print friendly representation of the tuple.
+// String returns a print friendly representation of the tuple, in the literal format read by Parse.
 func (lt LabelledTuple) String() (s string) {
 	ld, rd := lt.ParenthesisType()
 
 	delim := lt.Delimiter()
 
-	strs := make([]string, lt.Length())
-
-	for i := range strs {
-		field := lt.GetFieldAt(i)
-
-		if field != nil {
-			if reflect.TypeOf(field).Kind() == reflect.String {
-				strs[i] = fmt.Sprintf("%s%s%s", "\"", field, "\"")
-			} else {
-				strs[i] = fmt.Sprintf("%v", field)
-			}
-		} else {
-			strs[i] = "nil"
-		}
-	}
-
-	s = fmt.Sprintf("%s%s%s%s%s", ld, lt.Labels(), " : ", strings.Join(strs, delim), rd)
+	s = fmt.Sprintf("%s%s%s%s%s", ld, lt.Labels(), " : ", formatFields(lt.Flds[1:], delim), rd)
 
 	return s
 }
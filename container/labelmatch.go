@@ -0,0 +1,116 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabelMatch is a template field matching the label set of a labelled tuple by the labels it contains, rather than by equality.
+// A label match matches a label set if it contains all the included labels and none of the excluded labels.
+type LabelMatch struct {
+	Incl Labels `json:"include"`
+	Excl Labels `json:"exclude"`
+}
+
+// AnyLabels creates a label match lm matching any label set.
+func AnyLabels() (lm LabelMatch) {
+	lm = LabelMatch{Incl: NewLabels(), Excl: NewLabels()}
+	return lm
+}
+
+// HasLabels creates a label match lm matching label sets containing all the labels ll.
+func HasLabels(ll ...Label) (lm LabelMatch) {
+	lm = AnyLabels().Has(ll...)
+	return lm
+}
+
+// LacksLabels creates a label match lm matching label sets containing none of the labels ll.
+func LacksLabels(ll ...Label) (lm LabelMatch) {
+	lm = AnyLabels().Lacks(ll...)
+	return lm
+}
+
+// Has returns a label match hm matching the label sets matched by lm which also contain all the labels ll.
+func (lm LabelMatch) Has(ll ...Label) (hm LabelMatch) {
+	hm = lm.copy()
+
+	for _, l := range ll {
+		hm.Incl.Add(l.DeepCopy())
+	}
+
+	return hm
+}
+
+// Lacks returns a label match hm matching the label sets matched by lm which also contain none of the labels ll.
+func (lm LabelMatch) Lacks(ll ...Label) (hm LabelMatch) {
+	hm = lm.copy()
+
+	for _, l := range ll {
+		hm.Excl.Add(l.DeepCopy())
+	}
+
+	return hm
+}
+
+// copy returns a copy cm of the label match lm.
+func (lm LabelMatch) copy() (cm LabelMatch) {
+	cm = AnyLabels()
+
+	for _, l := range lm.Incl {
+		cm.Incl.Add(l.DeepCopy())
+	}
+
+	for _, l := range lm.Excl {
+		cm.Excl.Add(l.DeepCopy())
+	}
+
+	return cm
+}
+
+// Matches returns true if the labels of labelled tuple lt are matched by the label match lm, and false otherwise.
+func (lm LabelMatch) Matches(lt *LabelledTuple) (b bool) {
+	b = lt != nil && len(lt.Flds) > 0
+
+	if b {
+		_, b = lt.Flds[0].(Labels)
+	}
+
+	if b && len(lm.Incl) > 0 {
+		var mls *Labels
+		mls, b = lt.MatchLabels(lm.Incl)
+		b = b && len(*mls) == len(lm.Incl)
+	}
+
+	if b && len(lm.Excl) > 0 {
+		_, excluded := lt.MatchLabels(lm.Excl)
+		b = !excluded
+	}
+
+	return b
+}
+
+// String returns a print friendly representation of the label match lm, listing included labels with + and excluded labels with -.
+func (lm LabelMatch) String() (s string) {
+	ids := make([]string, 0, len(lm.Incl)+len(lm.Excl))
+
+	for _, id := range lm.Incl.Labelling() {
+		ids = append(ids, "+"+id)
+	}
+
+	for _, id := range lm.Excl.Labelling() {
+		ids = append(ids, "-"+id)
+	}
+
+	sort.Strings(ids)
+
+	s = fmt.Sprintf("[%s]", strings.Join(ids, ", "))
+
+	return s
+}
+
+// isLabelMatch returns true if field is a label match, and false otherwise.
+func isLabelMatch(field interface{}) (b bool) {
+	_, b = field.(LabelMatch)
+	return b
+}
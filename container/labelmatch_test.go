@@ -0,0 +1,44 @@
+package container
+
+import (
+	"testing"
+)
+
+func TestLabelMatch(t *testing.T) {
+	// Setup
+	a := NewLabel("a")
+	b := NewLabel("b")
+	c := NewLabel("c")
+
+	tuple := NewTuple(NewLabels(a, b), "reading", 1)
+
+	tests := []struct {
+		lm    LabelMatch
+		match bool
+	}{
+		{AnyLabels(), true},
+		{HasLabels(a), true},
+		{HasLabels(a, b), true},
+		{HasLabels(a, c), false},
+		{LacksLabels(c), true},
+		{LacksLabels(b), false},
+		{HasLabels(a).Lacks(c), true},
+		{HasLabels(a).Lacks(b), false},
+	}
+
+	for _, test := range tests {
+		tp := NewTemplate(test.lm, "reading", 1)
+		if tuple.Match(tp) != test.match {
+			t.Errorf("Match(%v) gave %t, should be %t", tp, !test.match, test.match)
+		}
+	}
+
+	unlabelled := NewTuple("reading", 1, 2)
+	if unlabelled.Match(NewTemplate(AnyLabels(), 1, 2)) {
+		t.Errorf("Match() gave true for an unlabelled tuple, should be false")
+	}
+
+	if s := HasLabels(a).Lacks(c).String(); s != "[+a, -c]" {
+		t.Errorf("String() gave %q, should be %q", s, "[+a, -c]")
+	}
+}
@@ -0,0 +1,563 @@
+package container
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pspaces/gospace/function"
+)
+
+// Tuples, templates and labelled tuples are written as literals in the format printed by their String methods:
+//
+//	literal = "(" [ labels ":" ] [ field { "," field } ] ")"
+//	field   = value | formal | match
+//	value   = "nil" | "true" | "false" | string | number | typed | bytes | strings | funcref | tuple | list | labels
+//	string  = Go string literal, e.g. "job" or `job`
+//	number  = [ "-" ] digits [ "." digits ] [ ( "e" | "E" ) [ "+" | "-" ] digits ]
+//	typed   = type "(" number ")" | ( "float32" | "float64" ) "(" ( "+Inf" | "-Inf" | "NaN" ) ")"
+//	bytes   = "[]byte(" string ")"
+//	strings = "[]string{" [ string { "," string } ] "}"
+//	funcref = "func://" path
+//	tuple   = "(" [ value { "," value } ] ")"
+//	list    = "[" [ value { "," value } ] "]"
+//	labels  = "{" [ "|" id "|" { "," "|" id "|" } ] "}"
+//	formal  = type | "?" name ":" type
+//	match   = "[" ( "+" | "-" ) id { "," ( "+" | "-" ) id } "]"
+//	type    = "bool" | "string" | "int" | "int8" | "int16" | "int32" | "int64"
+//	        | "uint" | "uint8" | "uint16" | "uint32" | "uint64" | "float32" | "float64"
+//
+// Numbers without a fraction or an exponent are of type int, other numbers of type float64,
+// and numbers of other types are written with their type, e.g. int64(5) or float32(1.5), as are infinite floats and NaN, e.g. float64(+Inf).
+// Byte slices and string slices are written as in Go, e.g. []byte("hi") or []string{"a", "b"}.
+// Nested tuples are of type Tuple, lists of type []interface{} and function references of type FuncRef.
+// Formal fields and label matches make a literal a template, where the name of a formal field only documents it,
+// and labels followed by a colon make it a labelled tuple, e.g. ({|a|, |b|} : "job", 42).
+
+// literalTypes contains zero values of the types literals can name, by name.
+var literalTypes = map[string]interface{}{
+	"bool":    false,
+	"string":  "",
+	"int":     int(0),
+	"int8":    int8(0),
+	"int16":   int16(0),
+	"int32":   int32(0),
+	"int64":   int64(0),
+	"uint":    uint(0),
+	"uint8":   uint8(0),
+	"uint16":  uint16(0),
+	"uint32":  uint32(0),
+	"uint64":  uint64(0),
+	"float32": float32(0),
+	"float64": float64(0),
+}
+
+// formatField returns field in the format of literals, or as printed by fmt if it has no literal.
+func formatField(field interface{}) (s string) {
+	if field == nil {
+		return "nil"
+	}
+
+	switch v := field.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool, int:
+		return fmt.Sprintf("%v", v)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Sprintf("float64(%s)", s)
+		}
+
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+
+		return s
+	case float32:
+		return fmt.Sprintf("float32(%s)", strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%T(%v)", v, v)
+	case []byte:
+		return fmt.Sprintf("[]byte(%s)", strconv.Quote(string(v)))
+	case []string:
+		strs := make([]string, len(v))
+		for i, str := range v {
+			strs[i] = strconv.Quote(str)
+		}
+
+		return fmt.Sprintf("[]string{%s}", strings.Join(strs, ", "))
+	case Tuple:
+		return v.String()
+	case []interface{}:
+		strs := make([]string, len(v))
+		for i, f := range v {
+			strs[i] = formatField(f)
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(strs, ", "))
+	}
+
+	if function.IsFunc(field) {
+		return fmt.Sprintf("%s %s", function.Name(field), function.Signature(field))
+	}
+
+	return fmt.Sprintf("%v", field)
+}
+
+// formatFields returns fields in the format of literals, separated by delimiter delim.
+func formatFields(fields []interface{}, delim string) (s string) {
+	strs := make([]string, len(fields))
+	for i, field := range fields {
+		strs[i] = formatField(field)
+	}
+
+	s = strings.Join(strs, delim)
+
+	return s
+}
+
+// ParseError is an error found in a literal at a column.
+type ParseError struct {
+	Column int
+	Msg    string
+}
+
+// Error returns a print friendly representation of the parse error err.
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", err.Column, err.Msg)
+}
+
+// Parse parses the tuple, template or labelled tuple literal s, such that parsing the output of String gives the tuple,
+// template or labelled tuple printed as long as its fields have literals.
+// Parse returns a Template v if s contains formal fields or label matches, a LabelledTuple if it starts with labels,
+// and a Tuple otherwise, or a ParseError err if s is not a valid literal.
+// Templates without formal fields are printed like tuples, and are parsed as tuples.
+func Parse(s string) (v interface{}, err error) {
+	p := &parser{s: s}
+
+	v = p.literal()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return v, err
+}
+
+// MustParse parses the literal s like Parse, and panics if s is not a valid literal.
+// MustParse is meant for literals known to be valid, e.g. in tests.
+func MustParse(s string) (v interface{}) {
+	v, err := Parse(s)
+
+	if err != nil {
+		panic(fmt.Sprintf("container: MustParse(%q): %s", s, err))
+	}
+
+	return v
+}
+
+// parser parses a literal s, keeping the first error err found.
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+// fail records the error described by format and args at the current position of parser p, unless an error has been found already.
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = &ParseError{Column: p.pos + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// peek returns the next character after any white space, or 0 at the end of the literal.
+func (p *parser) peek() (c byte) {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+
+	if p.pos < len(p.s) {
+		c = p.s[p.pos]
+	}
+
+	return c
+}
+
+// accept skips the next character if it is c, and returns true if it did.
+func (p *parser) accept(c byte) (b bool) {
+	b = p.peek() == c
+	if b {
+		p.pos++
+	}
+
+	return b
+}
+
+// expect skips the next character if it is c, and fails otherwise.
+func (p *parser) expect(c byte) (b bool) {
+	b = p.accept(c)
+	if !b {
+		p.fail("expected %q but found %s", c, p.found())
+	}
+
+	return b
+}
+
+// found describes the next character for errors.
+func (p *parser) found() (s string) {
+	if c := p.peek(); c != 0 {
+		return fmt.Sprintf("%q", c)
+	}
+
+	return "the end"
+}
+
+// until returns the text up to the next of the characters in stop or the end, without surrounding white space.
+func (p *parser) until(stop string) (s string) {
+	start := p.pos
+
+	for p.pos < len(p.s) && strings.IndexByte(stop, p.s[p.pos]) < 0 {
+		p.pos++
+	}
+
+	s = strings.TrimSpace(p.s[start:p.pos])
+
+	return s
+}
+
+// word returns the identifier at the current position, which is empty if there is none.
+func (p *parser) word() (w string) {
+	p.peek()
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9' || p.pos == start) {
+			break
+		}
+
+		p.pos++
+	}
+
+	w = p.s[start:p.pos]
+
+	return w
+}
+
+// literal parses a complete literal.
+func (p *parser) literal() (v interface{}) {
+	if !p.expect('(') {
+		return nil
+	}
+
+	// Labels followed by a colon label the tuple, and are a field of it otherwise.
+	var lbls Labels
+	labelled := false
+
+	if p.peek() == '{' {
+		start := p.pos
+		lbls = p.labels()
+
+		if labelled = p.accept(':'); !labelled {
+			p.pos = start
+		}
+	}
+
+	fields, formal := p.fields(')', !labelled)
+
+	if p.err == nil && p.peek() != 0 {
+		p.fail("unexpected %s after the literal", p.found())
+	}
+
+	switch {
+	case labelled:
+		v = NewLabelledTuple(append([]interface{}{lbls}, fields...)...)
+	case formal:
+		v = NewTemplate(fields...)
+	default:
+		v = NewTuple(fields...)
+	}
+
+	return v
+}
+
+// fields parses fields separated by commas up to the closing character end.
+// fields parses formal fields and label matches as well if formal is true, and reports whether any were found as isFormal.
+func (p *parser) fields(end byte, formal bool) (fields []interface{}, isFormal bool) {
+	fields = []interface{}{}
+
+	if p.accept(end) {
+		return fields, false
+	}
+
+	for p.err == nil {
+		field, fieldFormal := p.field(formal)
+		fields = append(fields, field)
+		isFormal = isFormal || fieldFormal
+
+		if p.accept(end) {
+			break
+		}
+
+		if !p.accept(',') {
+			p.fail("expected ',' or %q but found %s", end, p.found())
+		}
+	}
+
+	return fields, isFormal
+}
+
+// field parses a single field, and formal fields and label matches as well if formal is true.
+// field reports whether the field is formal as isFormal.
+func (p *parser) field(formal bool) (v interface{}, isFormal bool) {
+	switch c := p.peek(); {
+	case c == '"' || c == '`':
+		v = p.str()
+	case strings.HasPrefix(p.s[p.pos:], "[]byte("):
+		p.pos += len("[]byte(")
+		v = []byte(p.str())
+		p.expect(')')
+	case strings.HasPrefix(p.s[p.pos:], "[]string{"):
+		p.pos += len("[]string{")
+		v = p.strs()
+	case c == '(':
+		p.pos++
+		fields, _ := p.fields(')', false)
+		v = NewTuple(fields...)
+	case c == '[':
+		p.pos++
+		if next := p.peek(); formal && (next == '+' || next == '-') && !p.digitAt(p.pos+1) {
+			return p.match(), true
+		}
+
+		v, _ = p.fields(']', false)
+	case c == '{':
+		v = p.labels()
+	case c == '?':
+		p.pos++
+		if !formal {
+			p.fail("formal fields are only allowed in templates")
+			return nil, false
+		}
+
+		if p.word() == "" || !p.expect(':') {
+			p.fail("expected a name and a type after '?'")
+			return nil, false
+		}
+
+		return p.formal(p.word()), true
+	case c == '-' || (c >= '0' && c <= '9') || c == '.':
+		v = p.number("")
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		w := p.word()
+
+		switch _, isType := literalTypes[w]; {
+		case w == "nil":
+			v = nil
+		case w == "true" || w == "false":
+			v = w == "true"
+		case w == "func" && strings.HasPrefix(p.s[p.pos:], "://"):
+			v = NewFuncRef(w + p.until(",)] \t\r\n"))
+		case isType && p.accept('('):
+			v = p.number(w)
+			p.expect(')')
+		case isType && formal:
+			return p.formal(w), true
+		case isType:
+			p.fail("the type %s is only allowed in templates", w)
+		default:
+			p.fail("unknown value %s", w)
+		}
+	default:
+		p.fail("expected a field but found %s", p.found())
+	}
+
+	return v, false
+}
+
+// str parses a string.
+func (p *parser) str() (s string) {
+	p.peek()
+
+	quoted, err := strconv.QuotedPrefix(p.s[p.pos:])
+	if err != nil {
+		p.fail("invalid string")
+		return s
+	}
+
+	p.pos += len(quoted)
+	s, _ = strconv.Unquote(quoted)
+
+	return s
+}
+
+// strs parses strings separated by commas up to a closing brace.
+func (p *parser) strs() (ss []string) {
+	ss = []string{}
+
+	if p.accept('}') {
+		return ss
+	}
+
+	for p.err == nil {
+		ss = append(ss, p.str())
+
+		if p.accept('}') {
+			break
+		}
+
+		if !p.accept(',') {
+			p.fail("expected ',' or '}' but found %s", p.found())
+		}
+	}
+
+	return ss
+}
+
+// digitAt returns true if the character at position i is a digit, and false otherwise.
+func (p *parser) digitAt(i int) (b bool) {
+	b = i < len(p.s) && p.s[i] >= '0' && p.s[i] <= '9'
+	return b
+}
+
+// number parses a number of the type named typ, or of type int or float64 depending on its form if typ is empty.
+func (p *parser) number(typ string) (v interface{}) {
+	p.peek()
+
+	start := p.pos
+
+	// Infinite floats and NaN have no digits, and are only written with their type.
+	if typ == "float32" || typ == "float64" {
+		for _, special := range []string{"+Inf", "-Inf", "NaN"} {
+			if strings.HasPrefix(p.s[p.pos:], special) {
+				p.pos += len(special)
+				f, _ := strconv.ParseFloat(special, 64)
+				return reflect.ValueOf(f).Convert(reflect.TypeOf(literalTypes[typ])).Interface()
+			}
+		}
+	}
+
+	if p.pos < len(p.s) && p.s[p.pos] == '-' {
+		p.pos++
+	}
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		exp := (c == '+' || c == '-') && (p.s[p.pos-1] == 'e' || p.s[p.pos-1] == 'E')
+		if !exp && c != '.' && c != 'e' && c != 'E' && (c < '0' || c > '9') {
+			break
+		}
+
+		p.pos++
+	}
+
+	text := p.s[start:p.pos]
+	if typ == "" {
+		typ = "int"
+		if strings.ContainsAny(text, ".eE") {
+			typ = "float64"
+		}
+	}
+
+	zero := literalTypes[typ]
+	rt := reflect.TypeOf(zero)
+
+	var err error
+	switch rt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(text, 10, rt.Bits())
+		v = reflect.ValueOf(n).Convert(rt).Interface()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		n, err = strconv.ParseUint(text, 10, rt.Bits())
+		v = reflect.ValueOf(n).Convert(rt).Interface()
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(text, rt.Bits())
+		if math.IsInf(f, 0) {
+			err = fmt.Errorf("out of range")
+		}
+
+		v = reflect.ValueOf(f).Convert(rt).Interface()
+	default:
+		err = fmt.Errorf("%s is not a number type", typ)
+	}
+
+	if err != nil {
+		p.pos = start
+		p.fail("invalid %s %q", typ, text)
+	}
+
+	return v
+}
+
+// formal returns the formal field of the type named typ.
+func (p *parser) formal(typ string) (tf interface{}) {
+	zero, isType := literalTypes[typ]
+
+	if !isType {
+		p.fail("unknown type %q", typ)
+		return nil
+	}
+
+	tf = CreateTypeField(zero)
+
+	return tf
+}
+
+// labels parses a set of labels.
+func (p *parser) labels() (lbls Labels) {
+	lbls = NewLabels()
+
+	p.expect('{')
+
+	if p.accept('}') {
+		return lbls
+	}
+
+	for p.err == nil {
+		if !p.expect('|') {
+			break
+		}
+
+		lbls.Add(NewLabel(p.until("|")))
+
+		if !p.expect('|') || p.accept('}') {
+			break
+		}
+
+		p.expect(',')
+	}
+
+	return lbls
+}
+
+// match parses a label match following its opening bracket.
+func (p *parser) match() (lm LabelMatch) {
+	lm = AnyLabels()
+
+	for p.err == nil {
+		switch p.peek() {
+		case '+':
+			p.pos++
+			lm = lm.Has(NewLabel(p.until(",]")))
+		case '-':
+			p.pos++
+			lm = lm.Lacks(NewLabel(p.until(",]")))
+		default:
+			p.fail("expected '+' or '-' but found %s", p.found())
+		}
+
+		if p.accept(']') {
+			break
+		}
+
+		p.expect(',')
+	}
+
+	return lm
+}
@@ -0,0 +1,94 @@
+package container
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	// Setup
+	var i int
+	var s string
+
+	a := NewLabel("a")
+	b := NewLabel("b")
+
+	values := []interface{}{
+		NewTuple("job", 42, 3.5),
+		NewTuple(),
+		NewTuple(nil, true, false, -7, 2.0, 1e21, -0.25, "quote \" and\nnewline"),
+		NewTuple(int8(-8), int16(16), int32(32), int64(-64), uint(1), uint8(8), uint16(16), uint32(32), uint64(64), float32(1.5)),
+		NewTuple(math.Inf(1), math.Inf(-1), float32(math.Inf(1)), float32(math.Inf(-1))),
+		NewTuple([]byte("hi"), []byte("\x00\xff\n"), []byte{}, []string{"a", "b, c"}, []string{}),
+		NewTuple("nested", NewTuple("inner", 1, NewTuple()), []interface{}{1, "two", []interface{}{}}),
+		NewTuple(NewFuncRef("func://gospace/aggregation/sum"), NewLabels(a, b)),
+		NewTemplate("job", &i, &s),
+		NewTemplate(HasLabels(a).Lacks(b), "reading", 1.5),
+		NewLabelledTuple(NewLabels(a, b), "reading", 1),
+		NewLabelledTuple(NewLabels(a)),
+	}
+
+	// Test that parsing the printed values gives the values.
+	for _, v := range values {
+		literal := v.(interface{ String() string }).String()
+
+		pv, err := Parse(literal)
+		if err != nil || !reflect.DeepEqual(pv, v) {
+			t.Errorf("Parse(%s) gave %#v and error %v, should be %#v", literal, pv, err, v)
+		}
+	}
+
+	// Test that NaN, which is not equal to itself, is parsed as NaN.
+	nan := NewTuple(math.NaN(), float32(math.NaN()))
+
+	pv, err := Parse(nan.String())
+	pt, _ := pv.(Tuple)
+	if err != nil || pt.Length() != 2 || !math.IsNaN(pt.GetFieldAt(0).(float64)) || !math.IsNaN(float64(pt.GetFieldAt(1).(float32))) {
+		t.Errorf("Parse(%s) gave %v and error %v, should be %v", nan.String(), pv, err, nan)
+	}
+
+	// Test that literals may be written by hand.
+	written := map[string]interface{}{
+		` ( "job" ,42,  ` + "`raw`" + ` ) `:  NewTuple("job", 42, "raw"),
+		`("job", ?n:int, ?name:string)`:      NewTemplate("job", &i, &s),
+		`({} : "x")`:                         NewLabelledTuple(NewLabels(), "x"),
+		`({|a|}, "x")`:                       NewTuple(NewLabels(a), "x"),
+		`([-b, +a], uint8(255), float64(1))`: NewTemplate(HasLabels(a).Lacks(b), uint8(255), 1.0),
+	}
+
+	for literal, v := range written {
+		pv, err := Parse(literal)
+		if err != nil || !reflect.DeepEqual(pv, v) {
+			t.Errorf("Parse(%s) gave %v and error %v, should be %v", literal, pv, err, v)
+		}
+	}
+
+	// Test that invalid literals are reported.
+	invalid := []string{
+		``, `"job", 42`, `("job" 42)`, `("job",)`, `("job"`, `("job") x`, `(x)`, `(-"job")`,
+		`("unterminated)`, `(int8(128))`, `(uint(-1))`, `(float32(1e39))`, `(("inner", int))`,
+		`([int])`, `({|a|} : int)`, `(?x)`, `(?x:foo)`, `({|a| : 1)`, `([+a, 1])`,
+		`(+Inf)`, `(NaN)`, `(int(NaN))`, `(float64(Inf))`, `([]byte(1))`, `([]byte("a")`, `([]string{"a" "b"})`, `([]string{1})`,
+	}
+
+	for _, literal := range invalid {
+		if v, err := Parse(literal); err == nil {
+			t.Errorf("Parse(%s) gave %v, should be an error", literal, v)
+		}
+	}
+
+	if _, err := Parse(`("job", 42`); err == nil || err.Error() != "column 11: expected ',' or ')' but found the end" {
+		t.Errorf("Parse() gave error %v, should locate the missing parenthesis", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("MustParse() did not panic on an invalid literal")
+			}
+		}()
+
+		MustParse(`(`)
+	}()
+}
@@ -27,8 +27,8 @@ func NewSignature(rd uint, val interface{}) (s Signature) {
 	case reflect.Func:
 		halg.Write([]byte(fmt.Sprintf("%v%v%v", reflect.ValueOf(val).Pointer(), function.Name(val), function.Signature(val))))
 	case reflect.Array, reflect.Slice:
-		if rd >= 0 {
-			params := val.([]interface{})
+		params, generic := val.([]interface{})
+		if rd >= 0 && generic {
 			for _, param := range params {
 				subsign := rec(rd-1, param)
 				halg.Write([]byte(fmt.Sprintf("%+v", reflect.TypeOf(param))))
@@ -133,8 +133,11 @@ func NewTypeSignature(rd uint, val interface{}) (s Signature) {
 	case reflect.Func:
 		halg.Write([]byte(fmt.Sprintf("%v%v%v", reflect.ValueOf(val).Pointer(), function.Name(val), function.Signature(val))))
 	case reflect.Array, reflect.Slice:
-		if rd >= 0 {
-			params := val.([]interface{})
+		// Typed slices, e.g. []string, are signed by their type like values of any other type.
+		params, generic := val.([]interface{})
+		if !generic {
+			halg.Write([]byte(fmt.Sprintf("%+v", reflect.TypeOf(val))))
+		} else if rd >= 0 {
 			var tv reflect.Type
 			var subsign Signature
 			for _, param := range params {
@@ -3,7 +3,6 @@ package container
 import (
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/pspaces/gospace/function"
 )
@@ -55,6 +54,8 @@ func (tp *Template) Equal(tq *Template) (e bool) {
 				atpf := tpf.(TypeField)
 				btpf := tqf.(TypeField)
 				e = e && atpf.Equal(btpf)
+			} else if isFuncRef(tpf) || isFuncRef(tqf) {
+				e = e && funcRefMatch(tpf, tqf)
 			} else if function.IsFunc(tpf) && function.IsFunc(tqf) {
 				e = e && function.Name(tpf) == function.Name(tqf) && function.Signature(tpf) == function.Signature(tqf)
 			} else {
@@ -91,6 +92,8 @@ func (tp *Template) Match(tq *Template) (e bool) {
 				} else if bis {
 					e = e && reflect.TypeOf(tpf) == btpf.GetType()
 				}
+			} else if isFuncRef(tpf) || isFuncRef(tqf) {
+				e = e && funcRefMatch(tpf, tqf)
 			} else if function.IsFunc(tpf) && function.IsFunc(tqf) {
 				e = e && function.Name(tpf) == function.Name(tqf) && function.Signature(tpf) == function.Signature(tqf)
 			} else {
@@ -138,6 +141,13 @@ func (tp *Template) ExactMatch(tq *Template) (e bool, pno, qno uint) {
 				if e {
 					qno++
 				}
+			} else if isFuncRef(tpf) || isFuncRef(tqf) {
+				e = e && funcRefMatch(tpf, tqf)
+
+				if e {
+					pno++
+					qno++
+				}
 			} else if function.IsFunc(tpf) && function.IsFunc(tqf) {
 				nm := function.Name(tpf) == function.Name(tqf)
 				sm := function.Signature(tpf) == function.Signature(tqf)
@@ -263,26 +273,11 @@ func (tp Template) GetDelimiter() string {
 	return ", "
 }
 
-// String returns a print friendly representation of the template.
+// String returns a print friendly representation of the template, in the literal format read by Parse.
 func (tp Template) String() string {
 	lp, rp := tp.GetParenthesisType()
 
 	delim := tp.GetDelimiter()
 
-	strs := make([]string, tp.Length())
-
-	for i := range strs {
-		field := tp.GetFieldAt(i)
-		if field != nil {
-			if reflect.TypeOf(field).Kind() == reflect.String {
-				strs[i] = fmt.Sprintf("%s%s%s", "\"", field, "\"")
-			} else {
-				strs[i] = fmt.Sprintf("%v", field)
-			}
-		} else {
-			strs[i] = "nil"
-		}
-	}
-
-	return fmt.Sprintf("%s%s%s", lp, strings.Join(strs, delim), rp)
+	return fmt.Sprintf("%s%s%s", lp, formatFields(tp.Flds, delim), rp)
 }
@@ -3,7 +3,6 @@ package container
 import (
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/pspaces/gospace/function"
 )
@@ -115,6 +114,12 @@ func (t *Tuple) Match(tp Template) (b bool) {
 		// Check if the field of the template is an encapsulated formal or actual field.
 		if reflect.TypeOf(tpf) == reflect.TypeOf(TypeField{}) {
 			b = reflect.TypeOf(tf) == tpf.(TypeField).GetType()
+		} else if isLabelMatch(tpf) {
+			// Label matches only apply to the labels of a labelled tuple, which are its first field.
+			lt := LabelledTuple(*t)
+			b = i == 0 && tpf.(LabelMatch).Matches(&lt)
+		} else if isFuncRef(tf) || isFuncRef(tpf) {
+			b = funcRefMatch(tf, tpf)
 		} else if function.IsFunc(tf) && function.IsFunc(tpf) {
 			// We can do better. Functions are not being moved or rewritten while one is executing, no?
 			// If a function has a static address, and one has two functions with the same static address,
@@ -141,30 +146,13 @@ func (t Tuple) GetDelimiter() string {
 	return ", "
 }
 
-// String returns a print friendly representation of the tuple.
+// String returns a print friendly representation of the tuple, in the literal format read by Parse.
 func (t Tuple) String() string {
 	ld, rd := t.GetParenthesisType()
 
 	delim := t.GetDelimiter()
 
-	strs := make([]string, t.Length())
-
-	for i := range strs {
-		field := t.GetFieldAt(i)
-		if field != nil {
-			if reflect.TypeOf(field).Kind() == reflect.String {
-				strs[i] = fmt.Sprintf("%s%s%s", "\"", field, "\"")
-			} else if function.IsFunc(field) {
-				strs[i] = fmt.Sprintf("%s %s", function.Name(field), function.Signature(field))
-			} else {
-				strs[i] = fmt.Sprintf("%v", field)
-			}
-		} else {
-			strs[i] = "nil"
-		}
-	}
-
-	return fmt.Sprintf("%s%s%s", ld, strings.Join(strs, delim), rd)
+	return fmt.Sprintf("%s%s%s", ld, formatFields(t.Flds, delim), rd)
 }
 
 // WriteToVariables will overwrite the values pointed to by pointers with
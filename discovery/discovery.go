@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scheme is the URL scheme used for logical space names.
+const Scheme = "discover"
+
+// DefaultTimeout is the time spent resolving a logical name if no timeout is given.
+const DefaultTimeout = 2 * time.Second
+
+// Entry describes a space advertised under a logical name.
+type Entry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// NewEntry creates an entry e advertising the space at url under the logical name name.
+func NewEntry(name string, url string) (e Entry) {
+	e = Entry{Name: strings.Trim(name, "/"), URL: url}
+	return e
+}
+
+// String returns a print friendly representation of entry e.
+func (e Entry) String() string {
+	return fmt.Sprintf("%s -> %s", e.Name, e.URL)
+}
+
+// Advertiser is an interface for advertising spaces.
+// Withdraw stops advertising the spaces with the logical names names, such that they can no longer be resolved through the advertiser.
+type Advertiser interface {
+	Advertise(entries ...Entry) error
+	Withdraw(names ...string) error
+}
+
+// Resolver is an interface for resolving logical space names into URLs.
+type Resolver interface {
+	Resolve(name string, timeout time.Duration) (url string, err error)
+}
+
+// ErrNotFound is returned when a logical name could not be resolved.
+var ErrNotFound = errors.New("discovery: no space is advertised under this name")
+
+// resolvers contains the resolvers used by Resolve.
+var resolvers = struct {
+	mu   *sync.RWMutex
+	list []Resolver
+}{mu: new(sync.RWMutex)}
+
+// Use adds resolver r to the resolvers consulted by Resolve.
+// Resolvers are consulted in the order they have been added.
+func Use(r Resolver) {
+	resolvers.mu.Lock()
+	defer resolvers.mu.Unlock()
+
+	resolvers.list = append(resolvers.list, r)
+}
+
+// Reset removes all resolvers consulted by Resolve.
+func Reset() {
+	resolvers.mu.Lock()
+	defer resolvers.mu.Unlock()
+
+	resolvers.list = nil
+}
+
+// IsLogical returns true if rawurl is a logical name such as discover:///orders, and false otherwise.
+func IsLogical(rawurl string) (b bool) {
+	b = strings.HasPrefix(rawurl, Scheme+":")
+	return b
+}
+
+// Name returns the logical name contained in rawurl.
+func Name(rawurl string) (name string) {
+	name = strings.TrimPrefix(rawurl, Scheme+":")
+	name = strings.TrimLeft(name, "/")
+
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+// Resolve resolves a logical name or URL into the URL of a space by consulting all resolvers added through Use.
+// Resolve returns the URL unchanged if it is not a logical name.
+func Resolve(rawurl string, timeout ...time.Duration) (url string, err error) {
+	if !IsLogical(rawurl) {
+		return rawurl, nil
+	}
+
+	to := DefaultTimeout
+	if len(timeout) == 1 {
+		to = timeout[0]
+	}
+
+	resolvers.mu.RLock()
+	list := make([]Resolver, len(resolvers.list))
+	copy(list, resolvers.list)
+	resolvers.mu.RUnlock()
+
+	name := Name(rawurl)
+	err = ErrNotFound
+
+	for _, r := range list {
+		url, err = r.Resolve(name, to)
+
+		if err == nil {
+			break
+		}
+	}
+
+	return url, err
+}
@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMulticastResolve(t *testing.T) {
+	// Setup
+	lg := NewLocalGroup()
+	server := NewMulticast(lg.Join())
+	client := NewMulticast(lg.Join())
+	defer server.Close()
+	defer client.Close()
+
+	actualURL := "tcp://localhost:31415/orders"
+	server.Advertise(NewEntry("orders", actualURL))
+
+	testURL, err := client.Resolve("orders", time.Second)
+	if err != nil || testURL != actualURL {
+		t.Errorf("Resolve(%q) gave %q and %v, should be %q", "orders", testURL, err, actualURL)
+	}
+
+	_, err = client.Resolve("invoices", 50*time.Millisecond)
+	if err != ErrNotFound {
+		t.Errorf("Resolve(%q) gave %v, should be %v", "invoices", err, ErrNotFound)
+	}
+}
+
+func TestMulticastQueryLateJoiner(t *testing.T) {
+	// Setup
+	lg := NewLocalGroup()
+	server := NewMulticast(lg.Join())
+	defer server.Close()
+
+	actualURL := "tcp://localhost:31416/jobs"
+	server.Advertise(NewEntry("jobs", actualURL))
+
+	// A client joining after the announcement must learn about the space by querying.
+	client := NewMulticast(lg.Join())
+	defer client.Close()
+
+	testURL, err := client.Resolve("jobs", time.Second)
+	if err != nil || testURL != actualURL {
+		t.Errorf("Resolve(%q) gave %q and %v, should be %q", "jobs", testURL, err, actualURL)
+	}
+}
+
+func TestMulticastWithdraw(t *testing.T) {
+	// Setup
+	lg := NewLocalGroup()
+	server := NewMulticast(lg.Join())
+	client := NewMulticast(lg.Join())
+	defer server.Close()
+	defer client.Close()
+
+	server.Advertise(NewEntry("orders", "tcp://localhost:31415/orders"))
+
+	if _, err := client.Resolve("orders", time.Second); err != nil {
+		t.Fatalf("Resolve(%q) gave %v, should find the advertised space", "orders", err)
+	}
+
+	// Withdrawn entries are forgotten by peers and no longer answered for.
+	server.Withdraw("orders")
+
+	var err error
+	for deadline := time.Now().Add(time.Second); err == nil && time.Now().Before(deadline); {
+		_, err = client.Resolve("orders", 10*time.Millisecond)
+	}
+
+	if err != ErrNotFound {
+		t.Errorf("Resolve(%q) gave %v after Withdraw(), should be %v", "orders", err, ErrNotFound)
+	}
+
+	late := NewMulticast(lg.Join())
+	defer late.Close()
+
+	if _, err := late.Resolve("orders", 50*time.Millisecond); err != ErrNotFound {
+		t.Errorf("Resolve(%q) gave %v for a late joiner after Withdraw(), should be %v", "orders", err, ErrNotFound)
+	}
+}
+
+func TestSeedList(t *testing.T) {
+	// Setup
+	dir, _ := ioutil.TempDir("", "gospace")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "seeds")
+	ioutil.WriteFile(path, []byte("# Seeds.\norders tcp://localhost:31415/orders\n\njobs tcp://localhost:31416/jobs\n"), 0644)
+
+	Reset()
+	defer Reset()
+	Use(NewSeedList(path))
+
+	actualURL := "tcp://localhost:31416/jobs"
+	testURL, err := Resolve("discover:///jobs")
+	if err != nil || testURL != actualURL {
+		t.Errorf("Resolve(%q) gave %q and %v, should be %q", "discover:///jobs", testURL, err, actualURL)
+	}
+
+	ioutil.WriteFile(path, []byte("orders\n"), 0644)
+	_, err = NewSeedList(path).Entries()
+	if err == nil {
+		t.Errorf("Entries() accepted a malformed seed list")
+	}
+}
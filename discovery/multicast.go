@@ -0,0 +1,343 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGroup is the multicast group address used for announcements on the local network.
+const DefaultGroup = "239.255.31.41:31416"
+
+// maxPacket is the largest announcement accepted from a group.
+const maxPacket = 8192
+
+// Message types sent to a multicast group.
+const (
+	announceMessage = "announce"
+	queryMessage    = "query"
+	withdrawMessage = "withdraw"
+)
+
+// packet is the structure exchanged in a multicast group.
+type packet struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name,omitempty"`
+	Entries []Entry  `json:"entries,omitempty"`
+	Names   []string `json:"names,omitempty"`
+}
+
+// Group is an interface for sending and receiving datagrams within a multicast group.
+type Group interface {
+	Send(p []byte) error
+	Receive(p []byte) (n int, err error)
+	Close() error
+}
+
+// Multicast advertises and resolves spaces through announcements in a multicast group.
+// Multicast answers queries for the entries it advertises until they are withdrawn, and remembers the entries announced by its peers.
+type Multicast struct {
+	group   Group
+	mu      *sync.Mutex
+	local   map[string]Entry
+	seen    map[string]Entry
+	waiters map[string][]chan Entry
+}
+
+// NewMulticast creates a multicast advertiser and resolver m using group g.
+func NewMulticast(g Group) (m *Multicast) {
+	m = &Multicast{
+		group:   g,
+		mu:      new(sync.Mutex),
+		local:   make(map[string]Entry),
+		seen:    make(map[string]Entry),
+		waiters: make(map[string][]chan Entry),
+	}
+
+	go m.listen()
+
+	return m
+}
+
+// Advertise announces the entries to the group and answers later queries for them.
+func (m *Multicast) Advertise(entries ...Entry) (err error) {
+	m.mu.Lock()
+	for _, e := range entries {
+		m.local[e.Name] = e
+	}
+	m.mu.Unlock()
+
+	err = m.send(packet{Type: announceMessage, Entries: entries})
+
+	return err
+}
+
+// Withdraw stops answering queries for the entries with the logical names names, and tells the group to forget them.
+func (m *Multicast) Withdraw(names ...string) (err error) {
+	m.mu.Lock()
+	for _, name := range names {
+		delete(m.local, strings.Trim(name, "/"))
+	}
+	m.mu.Unlock()
+
+	err = m.send(packet{Type: withdrawMessage, Names: names})
+
+	return err
+}
+
+// Resolve returns the URL of the space advertised under the logical name name.
+// Resolve queries the group and waits at most timeout for an announcement.
+func (m *Multicast) Resolve(name string, timeout time.Duration) (url string, err error) {
+	wait := make(chan Entry, 1)
+
+	m.mu.Lock()
+	e, exists := m.local[name]
+	if !exists {
+		e, exists = m.seen[name]
+	}
+	if !exists {
+		m.waiters[name] = append(m.waiters[name], wait)
+	}
+	m.mu.Unlock()
+
+	if exists {
+		return e.URL, nil
+	}
+
+	err = m.send(packet{Type: queryMessage, Name: name})
+
+	if err != nil {
+		return url, err
+	}
+
+	select {
+	case e = <-wait:
+		url = e.URL
+	case <-time.After(timeout):
+		err = ErrNotFound
+	}
+
+	m.mu.Lock()
+	ws := m.waiters[name]
+	for i, w := range ws {
+		if w == wait {
+			m.waiters[name] = append(ws[:i], ws[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	return url, err
+}
+
+// Close leaves the multicast group.
+func (m *Multicast) Close() error {
+	return m.group.Close()
+}
+
+// send encodes and sends packet p to the group.
+func (m *Multicast) send(p packet) (err error) {
+	buf, err := json.Marshal(p)
+
+	if err == nil {
+		err = m.group.Send(buf)
+	}
+
+	return err
+}
+
+// listen processes announcements and queries until the group is closed.
+func (m *Multicast) listen() {
+	buf := make([]byte, maxPacket)
+
+	for {
+		n, err := m.group.Receive(buf)
+
+		if err != nil {
+			return
+		}
+
+		var p packet
+		if json.Unmarshal(buf[:n], &p) != nil {
+			continue
+		}
+
+		switch p.Type {
+		case announceMessage:
+			m.learn(p.Entries)
+		case withdrawMessage:
+			m.forget(p.Names)
+		case queryMessage:
+			m.mu.Lock()
+			e, exists := m.local[p.Name]
+			m.mu.Unlock()
+
+			if exists {
+				m.send(packet{Type: announceMessage, Entries: []Entry{e}})
+			}
+		}
+	}
+}
+
+// learn remembers announced entries and wakes up resolvers waiting for them.
+func (m *Multicast) learn(entries []Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range entries {
+		m.seen[e.Name] = e
+
+		for _, w := range m.waiters[e.Name] {
+			select {
+			case w <- e:
+			default:
+			}
+		}
+
+		delete(m.waiters, e.Name)
+	}
+}
+
+// forget drops withdrawn entries announced by peers.
+func (m *Multicast) forget(names []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range names {
+		delete(m.seen, strings.Trim(name, "/"))
+	}
+}
+
+// udpGroup is a multicast group on the local network.
+type udpGroup struct {
+	in  *net.UDPConn
+	out *net.UDPConn
+}
+
+// JoinUDP joins the UDP multicast group at address, or DefaultGroup if address is empty.
+func JoinUDP(address string) (g Group, err error) {
+	if address == "" {
+		address = DefaultGroup
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", address)
+
+	if err != nil {
+		return g, err
+	}
+
+	in, err := net.ListenMulticastUDP("udp4", nil, addr)
+
+	if err != nil {
+		return g, err
+	}
+
+	out, err := net.DialUDP("udp4", nil, addr)
+
+	if err != nil {
+		in.Close()
+		return g, err
+	}
+
+	g = &udpGroup{in: in, out: out}
+
+	return g, err
+}
+
+// Send sends datagram p to the group.
+func (g *udpGroup) Send(p []byte) (err error) {
+	_, err = g.out.Write(p)
+	return err
+}
+
+// Receive reads the next datagram of the group into p.
+func (g *udpGroup) Receive(p []byte) (n int, err error) {
+	n, _, err = g.in.ReadFromUDP(p)
+	return n, err
+}
+
+// Close leaves the group.
+func (g *udpGroup) Close() (err error) {
+	err = g.in.Close()
+	g.out.Close()
+	return err
+}
+
+// LocalGroup simulates a multicast group within a single process.
+// LocalGroup is meant for testing on loopback where no multicast routing is available.
+type LocalGroup struct {
+	mu      *sync.Mutex
+	members map[*localMember]bool
+}
+
+// localMember is a member of a simulated multicast group.
+type localMember struct {
+	group  *LocalGroup
+	inbox  chan []byte
+	closed chan struct{}
+	once   *sync.Once
+}
+
+// NewLocalGroup creates a simulated multicast group lg.
+func NewLocalGroup() (lg *LocalGroup) {
+	lg = &LocalGroup{mu: new(sync.Mutex), members: make(map[*localMember]bool)}
+	return lg
+}
+
+// Join returns a new member g of the simulated multicast group lg.
+func (lg *LocalGroup) Join() (g Group) {
+	lm := &localMember{group: lg, inbox: make(chan []byte, 64), closed: make(chan struct{}), once: new(sync.Once)}
+
+	lg.mu.Lock()
+	lg.members[lm] = true
+	lg.mu.Unlock()
+
+	g = lm
+
+	return g
+}
+
+// Send delivers datagram p to every member of the group, including the sender.
+// Like multicast, Send drops the datagram for members that can not keep up.
+func (lm *localMember) Send(p []byte) error {
+	lm.group.mu.Lock()
+	defer lm.group.mu.Unlock()
+
+	for m := range lm.group.members {
+		dp := make([]byte, len(p))
+		copy(dp, p)
+
+		select {
+		case m.inbox <- dp:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Receive reads the next datagram of the group into p.
+func (lm *localMember) Receive(p []byte) (n int, err error) {
+	select {
+	case dp := <-lm.inbox:
+		n = copy(p, dp)
+	case <-lm.closed:
+		err = net.ErrClosed
+	}
+
+	return n, err
+}
+
+// Close leaves the group.
+func (lm *localMember) Close() error {
+	lm.once.Do(func() {
+		lm.group.mu.Lock()
+		delete(lm.group.members, lm)
+		lm.group.mu.Unlock()
+		close(lm.closed)
+	})
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SeedList resolves logical names from a static seed-list file.
+// Every non-empty line of the file contains a logical name followed by the URL of a space.
+// Lines starting with # are comments.
+type SeedList struct {
+	path string
+}
+
+// NewSeedList creates a resolver sl reading the seed-list file at path.
+func NewSeedList(path string) (sl *SeedList) {
+	sl = &SeedList{path: path}
+	return sl
+}
+
+// Entries reads all entries from the seed-list file.
+// Entries reports the line number of the first malformed line in err.
+func (sl *SeedList) Entries() (entries []Entry, err error) {
+	f, err := os.Open(sl.path)
+
+	if err != nil {
+		return entries, err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+
+		if len(fields) != 2 {
+			return entries, fmt.Errorf("%s:%d: expected a name and a URL, got %q", sl.path, line, text)
+		}
+
+		entries = append(entries, NewEntry(fields[0], fields[1]))
+	}
+
+	err = scanner.Err()
+
+	return entries, err
+}
+
+// Resolve returns the URL advertised for the logical name name in the seed-list file.
+// The file is read on every call, such that changes to it are picked up.
+func (sl *SeedList) Resolve(name string, timeout time.Duration) (url string, err error) {
+	entries, err := sl.Entries()
+
+	if err != nil {
+		return url, err
+	}
+
+	name = strings.Trim(name, "/")
+
+	for _, e := range entries {
+		if e.Name == name {
+			return e.URL, nil
+		}
+	}
+
+	return url, ErrNotFound
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -372,8 +373,21 @@ func NewRegistry() (fr Registry) {
 // Register performs a registring of function fun to function registry fr.
 // Register returns true if registring of function fun was succesful, and false otherwise.
 func (fr *Registry) Register(fun Function) (status bool) {
+	status = fr.register(fun, externalNamespace(fun))
+	return status
+}
+
+// RegisterNamespace performs a registring of function fun to function registry fr under the external namespace ns.
+// RegisterNamespace allows a function to be known under a namespace that is stable across language versions.
+// RegisterNamespace returns true if registring of function fun was succesful, and false otherwise.
+func (fr *Registry) RegisterNamespace(fun Function, ns Namespace) (status bool) {
+	status = fr.register(fun, ns)
+	return status
+}
+
+// register binds function fun to the external namespace external in function registry fr.
+func (fr *Registry) register(fun Function, external Namespace) (status bool) {
 	internal := internalNamespace(fun)
-	external := externalNamespace(fun)
 
 	addedInternal := (*fr).NameDict.Add(internal)
 	addedExternal := (*fr).NameDict.Add(external)
@@ -390,6 +404,10 @@ func (fr *Registry) Register(fun Function) (status bool) {
 		if bound {
 			status = (*fr).LangBind.Add(refInternal, refExternal)
 		}
+	} else if addedInternal {
+		(*fr).NameDict.Remove(internal)
+	} else if addedExternal {
+		(*fr).NameDict.Remove(external)
 	}
 
 	return status
@@ -399,21 +417,22 @@ func (fr *Registry) Register(fun Function) (status bool) {
 // Unregister returns true if unregistring of function fun was succesful, and false otherwise.
 func (fr *Registry) Unregister(fun Function) (status bool) {
 	internal := internalNamespace(fun)
-	external := externalNamespace(fun)
 
 	refInternal := (*fr).NameDict.Reference(internal)
-	refExternal := (*fr).NameDict.Reference(external)
+	refExternal := (*fr).LangBind.External(refInternal)
 
 	if refInternal != nil && refExternal != nil {
-		// Remove the namespace references.
-		(*fr).NameDict.Remove(internal)
-		(*fr).NameDict.Remove(external)
+		external := (*refExternal).DeepCopy()
+
+		// Remove the language binding.
+		(*fr).LangBind.RemoveInternal(refInternal)
 
 		// Remove the binding the reference to a namespace to the reference of the function.
 		(*fr).FuncBind.Remove(refInternal)
 
-		// Remove the language binding.
-		(*fr).LangBind.RemoveInternal(refInternal)
+		// Remove the namespace references.
+		(*fr).NameDict.Remove(internal)
+		(*fr).NameDict.Remove(external)
 	}
 
 	status = refInternal != nil && refExternal != nil
@@ -425,9 +444,8 @@ func (fr *Registry) Unregister(fun Function) (status bool) {
 // Check returns true if function fun is registered, and false otherwise.
 func (fr *Registry) Check(fun Function) (status bool) {
 	internal := internalNamespace(fun)
-	external := externalNamespace(fun)
 	refInternal := (*fr).NameDict.Reference(internal)
-	refExternal := (*fr).NameDict.Reference(external)
+	refExternal := (*fr).LangBind.External(refInternal)
 	status = refInternal != nil && refExternal != nil
 	return status
 }
@@ -459,6 +477,32 @@ func (fr *Registry) Decode(exter Namespace) (fun *Function) {
 	return fun
 }
 
+// Namespaces returns the sorted external namespaces nss of all functions registered in function registry fr.
+func (fr *Registry) Namespaces() (nss []Namespace) {
+	nss = []Namespace{}
+
+	(*fr).LangBind.ExternalToInternal.Range(func(k, v interface{}) bool {
+		nss = append(nss, (k.(*Namespace)).DeepCopy())
+		return true
+	})
+
+	sort.Slice(nss, func(i, j int) bool { return nss[i] < nss[j] })
+
+	return nss
+}
+
+// Version returns the version of the language runtime used in external namespaces of function registry fr.
+func (fr *Registry) Version() (v string) {
+	v = strings.Join([]string{"golang", ":", goVersion()}, "")
+	return v
+}
+
+// NamespaceOf returns the external namespace ns of function fun as it is used when functions are sent between peers.
+func NamespaceOf(fun Function) (ns Namespace) {
+	ns = externalNamespace(fun)
+	return ns
+}
+
 // interalNamespace returns an internal namespace for a function fun.
 func internalNamespace(fun Function) (s Namespace) {
 	funcName := strings.Replace(Name(fun), " ", "", -1)
@@ -471,8 +515,13 @@ func internalNamespace(fun Function) (s Namespace) {
 func externalNamespace(fun Function) (s Namespace) {
 	funcName := strings.Replace(Name(fun), " ", "", -1)
 	funcSign := strings.Replace(Signature(fun), " ", "", -1)
-	reVersion := regexp.MustCompile("(\\d)\\.(\\d)(\\.(\\d))?")
-	goVersion := reVersion.FindString(runtime.Version())
-	s = Namespace(strings.Join([]string{"func", "://", "golang", ":", goVersion, "/", funcName, ":", funcSign}, ""))
+	s = Namespace(strings.Join([]string{"func", "://", "golang", ":", goVersion(), "/", funcName, ":", funcSign}, ""))
 	return s
 }
+
+// goVersion returns the version of the Go runtime as used in external namespaces.
+func goVersion() (v string) {
+	reVersion := regexp.MustCompile("(\\d)\\.(\\d)(\\.(\\d))?")
+	v = reVersion.FindString(runtime.Version())
+	return v
+}
@@ -54,10 +54,47 @@ func NewSpace(name string, policy ...*ComposablePolicy) Space {
 }
 
 // NewRemoteSpace creates a structure that represents a remote space.
+// The name can either be a URL or a logical name such as discover:///space.
 func NewRemoteSpace(name string) Space {
 	return space.NewRemoteSpace(name)
 }
 
+// EvalError is the first field of the tuple placed in a space when an evaluation fails.
+const EvalError = space.EvalError
+
+// ShardedSpace defines a space partitioned over several spaces.
+type ShardedSpace = space.ShardedSpace
+
+// NewShardedSpace creates a structure that represents a space partitioned over shards by the key fields keys.
+func NewShardedSpace(shards []Space, keys ...int) ShardedSpace {
+	return space.NewShardedSpace(shards, keys...)
+}
+
+// SpaceGroup defines a collection of spaces that are operated on as one space.
+type SpaceGroup = space.SpaceGroup
+
+// Selection defines the order in which members of a space group are tried.
+type Selection = space.Selection
+
+// Selection constants for space groups.
+const (
+	SelectOrdered = space.SelectOrdered
+	SelectRandom  = space.SelectRandom
+)
+
+// NewSpaceGroup creates a structure that represents a group of spaces.
+func NewSpaceGroup(sel Selection, members ...Space) SpaceGroup {
+	return space.NewSpaceGroup(sel, members...)
+}
+
+// Repository defines a collection of named spaces.
+type Repository = space.Repository
+
+// NewRepository creates a structure that represents a repository of named spaces.
+func NewRepository() *Repository {
+	return space.NewRepository()
+}
+
 // SpaceFrame contains all interfaces that can operate on a space.
 type SpaceFrame interface {
 	space.Interspace
@@ -0,0 +1,128 @@
+// Package logging provides structured logging for spaces.
+//
+// Spaces log to a Logger, which *slog.Logger from log/slog satisfies.
+// Every space logs to the default logger unless it is given its own, and the default logger logs to slog.Default unless it is replaced.
+package logging
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Keys of the fields logged by spaces.
+const (
+	// Space is the name of the space.
+	Space = "space"
+	// Operation is the name of the operation, e.g. Put or GetAgg.
+	Operation = "operation"
+	// Remote is the address of the other end of a connection: the space at a peer, or the peer at a space.
+	Remote = "remote"
+	// Template is the tuple or template of the operation.
+	Template = "template"
+	// Function is the name of the function logging.
+	Function = "function"
+	// Error is the error which occurred.
+	Error = "error"
+)
+
+// Logger is an interface for logging messages with structured fields.
+// The fields args are alternating keys and values, as with log/slog.
+type Logger interface {
+	// Debug logs msg about normal events, e.g. operations refused to a peer.
+	Debug(msg string, args ...interface{})
+	// Info logs msg about notable events.
+	Info(msg string, args ...interface{})
+	// Warn logs msg about failures outside of the space, e.g. of connections to peers.
+	Warn(msg string, args ...interface{})
+	// Error logs msg about failures of the space itself.
+	Error(msg string, args ...interface{})
+}
+
+var (
+	muDefault     = new(sync.RWMutex)
+	defaultLogger Logger
+)
+
+// Default returns the logger l of spaces which have not been given their own.
+func Default() (l Logger) {
+	muDefault.RLock()
+	defer muDefault.RUnlock()
+
+	l = defaultLogger
+	if l == nil {
+		l = slog.Default()
+	}
+
+	return l
+}
+
+// SetDefault makes spaces which have not been given their own logger log to l, or to slog.Default if l is nil.
+func SetDefault(l Logger) {
+	muDefault.Lock()
+	defer muDefault.Unlock()
+
+	defaultLogger = l
+}
+
+// Discard is a logger discarding everything logged to it.
+var Discard Logger = discard{}
+
+// discard is a logger discarding everything logged to it.
+type discard struct{}
+
+// Debug discards msg.
+func (discard) Debug(msg string, args ...interface{}) {}
+
+// Info discards msg.
+func (discard) Info(msg string, args ...interface{}) {}
+
+// Warn discards msg.
+func (discard) Warn(msg string, args ...interface{}) {}
+
+// Error discards msg.
+func (discard) Error(msg string, args ...interface{}) {}
+
+// With returns a logger wl which logs to l, adding the fields args to every message.
+func With(l Logger, args ...interface{}) (wl Logger) {
+	if sl, ok := l.(*slog.Logger); ok {
+		return sl.With(args...)
+	}
+
+	wl = &fields{l: l, args: args}
+
+	return wl
+}
+
+// fields is a logger adding fields to every message.
+type fields struct {
+	l    Logger
+	args []interface{}
+}
+
+// join returns the fields of logger f followed by args.
+func (f *fields) join(args []interface{}) (all []interface{}) {
+	all = make([]interface{}, 0, len(f.args)+len(args))
+	all = append(all, f.args...)
+	all = append(all, args...)
+	return all
+}
+
+// Debug logs msg with the fields of f and args.
+func (f *fields) Debug(msg string, args ...interface{}) {
+	f.l.Debug(msg, f.join(args)...)
+}
+
+// Info logs msg with the fields of f and args.
+func (f *fields) Info(msg string, args ...interface{}) {
+	f.l.Info(msg, f.join(args)...)
+}
+
+// Warn logs msg with the fields of f and args.
+func (f *fields) Warn(msg string, args ...interface{}) {
+	f.l.Warn(msg, f.join(args)...)
+}
+
+// Error logs msg with the fields of f and args.
+func (f *fields) Error(msg string, args ...interface{}) {
+	f.l.Error(msg, f.join(args)...)
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+)
+
+// recorder is a logger recording the messages logged to it.
+type recorder struct {
+	msgs []string
+}
+
+func (r *recorder) log(level string, msg string, args []interface{}) {
+	r.msgs = append(r.msgs, fmt.Sprint(level, " ", msg, " ", args))
+}
+
+func (r *recorder) Debug(msg string, args ...interface{}) { r.log("debug", msg, args) }
+func (r *recorder) Info(msg string, args ...interface{})  { r.log("info", msg, args) }
+func (r *recorder) Warn(msg string, args ...interface{})  { r.log("warn", msg, args) }
+func (r *recorder) Error(msg string, args ...interface{}) { r.log("error", msg, args) }
+
+func TestLogging(t *testing.T) {
+	r := new(recorder)
+
+	SetDefault(r)
+	defer SetDefault(nil)
+
+	l := With(Default(), Space, "orders")
+	l.Warn("could not connect", Remote, "localhost:31415")
+	l.Debug("refused")
+
+	expected := []string{
+		"warn could not connect [space orders remote localhost:31415]",
+		"debug refused [space orders]",
+	}
+
+	if fmt.Sprint(r.msgs) != fmt.Sprint(expected) {
+		t.Errorf("Logged %q, should be %q", r.msgs, expected)
+	}
+
+	SetDefault(nil)
+
+	if Default() == Logger(r) {
+		t.Errorf("Default() gave the replaced logger after SetDefault(nil)")
+	}
+}
@@ -0,0 +1,79 @@
+// Package metrics provides instrumentation for spaces.
+//
+// A space reports its measurements to a Sink, which can forward them to any monitoring system.
+// Registry is a Sink keeping the measurements in memory and serving them in the Prometheus text format.
+package metrics
+
+import (
+	"time"
+)
+
+// Names of the measurements reported by spaces.
+const (
+	// Operations counts the operations requested by peers, by operation and outcome.
+	Operations = "gospace_operations_total"
+	// OperationDuration observes the time in seconds from receiving an operation until it has been answered, by operation.
+	// Blocking operations include the time spent waiting for a matching tuple.
+	OperationDuration = "gospace_operation_duration_seconds"
+	// AggregationDuration observes the time in seconds spent aggregating and transforming matched tuples.
+	AggregationDuration = "gospace_aggregation_duration_seconds"
+	// Tuples is the number of tuples stored, by arity.
+	Tuples = "gospace_tuples"
+	// WaitingClients is the number of operations blocked until a matching tuple is placed.
+	WaitingClients = "gospace_waiting_clients"
+	// ActiveConnections is the number of connections from peers being handled.
+	ActiveConnections = "gospace_active_connections"
+	// ReceivedBytes counts the bytes received from peers.
+	ReceivedBytes = "gospace_received_bytes_total"
+	// SentBytes counts the bytes sent to peers.
+	SentBytes = "gospace_sent_bytes_total"
+)
+
+// Outcomes of operations.
+const (
+	// Accepted is the outcome of operations permitted to the peer.
+	Accepted = "accepted"
+	// Refused is the outcome of operations refused to the peer, e.g. by a policy or for failing authentication.
+	Refused = "refused"
+)
+
+// descriptions contains the help texts of the measurements reported by spaces.
+var descriptions = map[string]string{
+	Operations:          "Operations requested by peers, by operation and outcome.",
+	OperationDuration:   "Seconds from receiving an operation until it has been answered, by operation.",
+	AggregationDuration: "Seconds spent aggregating and transforming matched tuples.",
+	Tuples:              "Tuples stored, by arity.",
+	WaitingClients:      "Operations blocked until a matching tuple is placed.",
+	ActiveConnections:   "Connections from peers being handled.",
+	ReceivedBytes:       "Bytes received from peers.",
+	SentBytes:           "Bytes sent to peers.",
+}
+
+// Label is a name and value distinguishing measurements of the same name, e.g. the operation of a measured duration.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// L creates a label l with name name and value value.
+func L(name string, value string) (l Label) {
+	l = Label{Name: name, Value: value}
+	return l
+}
+
+// Sink is an interface for receiving the measurements of a space.
+// Sinks must be safe for concurrent use.
+type Sink interface {
+	// Count increases the counter name with labels lbls by delta.
+	Count(name string, delta float64, lbls ...Label)
+	// Add changes the gauge name with labels lbls by delta, which may be negative.
+	Add(name string, delta float64, lbls ...Label)
+	// Observe records value in the histogram name with labels lbls.
+	Observe(name string, value float64, lbls ...Label)
+}
+
+// Since returns the seconds elapsed since start, as observed by duration histograms.
+func Since(start time.Time) (s float64) {
+	s = time.Since(start).Seconds()
+	return s
+}
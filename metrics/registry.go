@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets of a registry, as used by Prometheus by default.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Kinds of measurements.
+const (
+	counter   = "counter"
+	gauge     = "gauge"
+	histogram = "histogram"
+)
+
+// Registry is a sink keeping measurements in memory.
+// Registry serves its measurements over HTTP in the Prometheus text format, and is usually mounted at /metrics.
+type Registry struct {
+	mu      *sync.Mutex
+	buckets []float64
+	kinds   map[string]string
+	series  map[string]map[string]*series
+}
+
+// series is a measurement of a name and a label set.
+type series struct {
+	labels string    // Label set in the Prometheus text format.
+	value  float64   // Value of a counter or gauge, or sum of a histogram.
+	count  uint64    // Number of observations of a histogram.
+	counts []uint64  // Number of observations per bucket of a histogram, not cumulated.
+	bounds []float64 // Upper bounds of the buckets of a histogram.
+}
+
+// NewRegistry creates a registry r whose histograms use the buckets with upper bounds buckets, or DefaultBuckets if none are given.
+func NewRegistry(buckets ...float64) (r *Registry) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	bs := make([]float64, len(buckets))
+	copy(bs, buckets)
+	sort.Float64s(bs)
+
+	r = &Registry{mu: new(sync.Mutex), buckets: bs, kinds: make(map[string]string), series: make(map[string]map[string]*series)}
+
+	return r
+}
+
+// Count increases the counter name with labels lbls by delta.
+func (r *Registry) Count(name string, delta float64, lbls ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := r.lookup(counter, name, lbls); s != nil {
+		s.value += delta
+	}
+}
+
+// Add changes the gauge name with labels lbls by delta.
+func (r *Registry) Add(name string, delta float64, lbls ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := r.lookup(gauge, name, lbls); s != nil {
+		s.value += delta
+	}
+}
+
+// Observe records value in the histogram name with labels lbls.
+func (r *Registry) Observe(name string, value float64, lbls ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.lookup(histogram, name, lbls)
+	if s == nil {
+		return
+	}
+
+	s.value += value
+	s.count++
+
+	if i := sort.SearchFloat64s(s.bounds, value); i < len(s.bounds) {
+		s.counts[i]++
+	}
+}
+
+// Value returns the value v of the counter or gauge name with labels lbls, or the sum of the observations of the histogram name.
+// Value returns false if nothing has been measured for name and lbls.
+func (r *Registry) Value(name string, lbls ...Label) (v float64, b bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, b := r.series[name][formatLabels(lbls)]
+	if b {
+		v = s.value
+	}
+
+	return v, b
+}
+
+// lookup returns the series of kind with name name and labels lbls, creating it if needed.
+// lookup returns nil if name has been used for another kind of measurement.
+// The caller must hold the lock of registry r.
+func (r *Registry) lookup(kind string, name string, lbls []Label) (s *series) {
+	if k, exists := r.kinds[name]; exists && k != kind {
+		return nil
+	}
+
+	r.kinds[name] = kind
+
+	byLabels, exists := r.series[name]
+	if !exists {
+		byLabels = make(map[string]*series)
+		r.series[name] = byLabels
+	}
+
+	key := formatLabels(lbls)
+
+	s, exists = byLabels[key]
+	if !exists {
+		s = &series{labels: key}
+
+		if kind == histogram {
+			s.bounds = r.buckets
+			s.counts = make([]uint64, len(r.buckets))
+		}
+
+		byLabels[key] = s
+	}
+
+	return s
+}
+
+// WritePrometheus writes all measurements of registry r to w in the Prometheus text format, sorted by name and labels.
+func (r *Registry) WritePrometheus(w io.Writer) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	names := make([]string, 0, len(r.series))
+	for name := range r.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		kind := r.kinds[name]
+
+		if help, described := descriptions[name]; described {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, help)
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+
+		keys := make([]string, 0, len(r.series[name]))
+		for key := range r.series[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := r.series[name][key]
+
+			if kind != histogram {
+				fmt.Fprintf(bw, "%s%s %s\n", name, braces(s.labels), formatValue(s.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, bound := range s.bounds {
+				cumulative += s.counts[i]
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", name, braces(joinLabels(s.labels, fmt.Sprintf("le=%q", formatValue(bound)))), cumulative)
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, braces(joinLabels(s.labels, `le="+Inf"`)), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", name, braces(s.labels), formatValue(s.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", name, braces(s.labels), s.count)
+		}
+	}
+
+	err = bw.Flush()
+
+	return err
+}
+
+// ServeHTTP serves the measurements of registry r in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
+
+// formatLabels returns the label set lbls in the Prometheus text format without braces, sorted by name.
+func formatLabels(lbls []Label) (s string) {
+	pairs := make([]string, len(lbls))
+	for i, l := range lbls {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", l.Name, escape(l.Value))
+	}
+	sort.Strings(pairs)
+
+	s = strings.Join(pairs, ",")
+
+	return s
+}
+
+// joinLabels joins the label sets a and b in the Prometheus text format.
+func joinLabels(a string, b string) (s string) {
+	if a == "" {
+		return b
+	}
+
+	s = a + "," + b
+
+	return s
+}
+
+// braces encloses the label set lbls in braces, unless it is empty.
+func braces(lbls string) (s string) {
+	if lbls != "" {
+		s = "{" + lbls + "}"
+	}
+
+	return s
+}
+
+// escape escapes the label value v as required by the Prometheus text format.
+func escape(v string) (s string) {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return s
+}
+
+// formatValue returns the value v in the Prometheus text format.
+func formatValue(v float64) (s string) {
+	switch {
+	case math.IsInf(v, 1):
+		s = "+Inf"
+	case math.IsInf(v, -1):
+		s = "-Inf"
+	default:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return s
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	// Setup
+	r := NewRegistry(0.1, 1)
+
+	r.Count(Operations, 1, L("operation", "Get"), L("outcome", Accepted))
+	r.Count(Operations, 2, L("outcome", Accepted), L("operation", "Get"))
+	r.Count(Operations, 1, L("operation", "Put"), L("outcome", Refused))
+	r.Add(Tuples, 2, L("arity", "2"))
+	r.Add(Tuples, -1, L("arity", "2"))
+	r.Add("custom_gauge", 1, L("name", "a \"quoted\"\nvalue"))
+	r.Observe(OperationDuration, 0.05, L("operation", "Get"))
+	r.Observe(OperationDuration, 0.5, L("operation", "Get"))
+	r.Observe(OperationDuration, 5, L("operation", "Get"))
+
+	// Names of one kind of measurement can not be used for another.
+	r.Add(Operations, 1, L("operation", "Get"), L("outcome", Accepted))
+
+	if v, ok := r.Value(Operations, L("operation", "Get"), L("outcome", Accepted)); !ok || v != 3 {
+		t.Errorf("Value() gave %v and %t, should be %v and true", v, ok, 3)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Get() gave %v, should be nil", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	expected := `# TYPE custom_gauge gauge
+custom_gauge{name="a \"quoted\"\nvalue"} 1
+# HELP gospace_operation_duration_seconds Seconds from receiving an operation until it has been answered, by operation.
+# TYPE gospace_operation_duration_seconds histogram
+gospace_operation_duration_seconds_bucket{operation="Get",le="0.1"} 1
+gospace_operation_duration_seconds_bucket{operation="Get",le="1"} 2
+gospace_operation_duration_seconds_bucket{operation="Get",le="+Inf"} 3
+gospace_operation_duration_seconds_sum{operation="Get"} 5.55
+gospace_operation_duration_seconds_count{operation="Get"} 3
+# HELP gospace_operations_total Operations requested by peers, by operation and outcome.
+# TYPE gospace_operations_total counter
+gospace_operations_total{operation="Get",outcome="accepted"} 3
+gospace_operations_total{operation="Put",outcome="refused"} 1
+# HELP gospace_tuples Tuples stored, by arity.
+# TYPE gospace_tuples gauge
+gospace_tuples{arity="2"} 1
+`
+
+	if string(body) != expected {
+		t.Errorf("ServeHTTP() gave\n%s\nshould be\n%s", body, expected)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("ServeHTTP() gave content type %q, should be the Prometheus text format", ct)
+	}
+}
@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// AccessError defines an error occuring when a policy refuses an action.
+type AccessError struct {
+	msg string
+	a   Action
+	lbl container.Label
+}
+
+// Authorize finds the aggregation policy ap applying to action a in the composable policy cp and checks that it permits a to a caller holding the labels caller.
+// Authorize returns a nil policy if no aggregation policy applies to a, and an error err if the applying policy refuses a.
+// For a composite policy, the aggregation policies of its parts are combined as defined by its operator.
+func (cp *Composable) Authorize(a *Action, caller container.Labels) (ap *Aggregation, err error) {
+	if cp.IsComposite() {
+		return cp.authorizeComposite(a, caller)
+	}
+
+	l := cp.Find(a)
+
+	if l != nil {
+		ap = cp.Retrieve(*l)
+	}
+
+	if ap != nil && !ap.Permits(caller) {
+		var msg string
+
+		rule := ap.AggRule
+		if rule.Effect() == Deny {
+			msg = fmt.Sprintf("Action is denied by policy %s", ap.Lbl.ID())
+		} else {
+			required := rule.Required()
+			ids := (&required).Labelling()
+			sort.Strings(ids)
+			msg = fmt.Sprintf("Action requires the labels %s under policy %s", strings.Join(ids, ", "), ap.Lbl.ID())
+		}
+
+		err = &AccessError{msg: msg, a: *a, lbl: ap.Label()}
+	}
+
+	return ap, err
+}
+
+// Aggregable checks that the aggregation policy ap permits aggregating tuples labelled by the label sets inputs together.
+// Aggregable returns an error err if the tuples carry more than one of the exclusive labels of ap.
+func (ap *Aggregation) Aggregable(inputs ...container.Labels) (err error) {
+	if ap != nil && !ap.AggRule.Compatible(inputs...) {
+		excl := ap.AggRule.Exclusive()
+		ids := (&excl).Labelling()
+		sort.Strings(ids)
+		msg := fmt.Sprintf("Action aggregates tuples of more than one of the exclusive labels %s under policy %s", strings.Join(ids, ", "), ap.Lbl.ID())
+
+		err = &AccessError{msg: msg, a: ap.Action(), lbl: ap.Label()}
+	}
+
+	return err
+}
+
+// Error returns an error msg associated to the access error err.
+func (err *AccessError) Error() (msg string) {
+	msg = fmt.Sprintf("%s: %s", err.msg, describeAction(&err.a))
+	return msg
+}
+
+// Action returns the action refused by the policy.
+func (err *AccessError) Action() (a Action) {
+	return err.a
+}
+
+// Label returns the label of the policy refusing the action.
+func (err *AccessError) Label() (l container.Label) {
+	return err.lbl
+}
@@ -27,6 +27,12 @@ func (ap *Aggregation) Action() (a Action) {
 	return ap.AggRule.Object
 }
 
+// Permits returns true if the aggregation policy ap permits its action to a caller holding the labels caller, and false otherwise.
+func (ap *Aggregation) Permits(caller container.Labels) (b bool) {
+	b = ap == nil || ap.AggRule.Permits(caller)
+	return b
+}
+
 // Apply applies an aggregation policy onto the input action ia.
 // Apply returns a modified action oa
 func (*Aggregation) Apply(ia Action) (oa Action) {
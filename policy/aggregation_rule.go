@@ -1,15 +1,37 @@
 package policy
 
+import (
+	"github.com/pspaces/gospace/container"
+)
+
 // AggregationRule is a structure defining what transformations an action is subject to.
 // The action is the object and the transformations are the subjects which will be applied to the action.
+// The effect of the rule determines if the action is permitted at all, and the required labels must all be held by the caller for it to be permitted.
+// Tuples labelled by different exclusive labels, e.g. the labels of different tenants, may not be aggregated together.
 type AggregationRule struct {
 	Object  Action
 	Subject Transformations
+	Eff     Effect
+	Req     container.Labels
+	Excl    container.Labels
 }
 
 // NewAggregationRule constructs a new policy given an action a and a list of transformation trs.
 func NewAggregationRule(a Action, trs Transformations) (ar AggregationRule) {
-	ar = AggregationRule{Object: a, Subject: trs}
+	ar = AggregationRule{Object: a, Subject: trs, Eff: Allow}
+	return ar
+}
+
+// NewAccessRule constructs a new policy given an action a and an effect e, without transforming the action.
+// If labels are required, the action is only permitted to callers holding all of them.
+// A space without an authenticator takes the labels its callers declare at face value, so required labels only restrict authenticated callers.
+func NewAccessRule(a Action, e Effect, required ...container.Label) (ar AggregationRule) {
+	ar = AggregationRule{Object: a, Eff: e}
+
+	if len(required) > 0 {
+		ar.Req = container.NewLabels(required...)
+	}
+
 	return ar
 }
 
@@ -30,3 +52,73 @@ func (ar *AggregationRule) Transformations() (tr Transformations) {
 
 	return tr
 }
+
+// Effect returns the effect e of the aggregation rule ar.
+func (ar *AggregationRule) Effect() (e Effect) {
+	if ar != nil {
+		e = ar.Eff
+	}
+
+	return e
+}
+
+// Required returns the labels ls a caller must hold for the aggregation rule ar to permit its action.
+func (ar *AggregationRule) Required() (ls container.Labels) {
+	if ar != nil {
+		ls = ar.Req
+	}
+
+	return ls
+}
+
+// Permits returns true if the aggregation rule ar permits its action to a caller holding the labels caller, and false otherwise.
+func (ar *AggregationRule) Permits(caller container.Labels) (b bool) {
+	b = ar == nil || ar.Effect() == Allow
+
+	if b && ar != nil {
+		for id := range ar.Required() {
+			if _, held := caller[id]; !held {
+				b = false
+				break
+			}
+		}
+	}
+
+	return b
+}
+
+// Exclusive returns the labels ls of which the tuples aggregated under the aggregation rule ar may carry at most one.
+func (ar *AggregationRule) Exclusive() (ls container.Labels) {
+	if ar != nil {
+		ls = ar.Excl
+	}
+
+	return ls
+}
+
+// Compatible returns true if tuples labelled by the label sets inputs may be aggregated together under the aggregation rule ar, and false otherwise.
+// Tuples are incompatible if their labels contain more than one of the exclusive labels of ar.
+func (ar *AggregationRule) Compatible(inputs ...container.Labels) (b bool) {
+	excl := ar.Exclusive()
+
+	if len(excl) == 0 {
+		return true
+	}
+
+	seen := ""
+	for _, lbls := range inputs {
+		for id := range lbls {
+			if _, exclusive := excl[id]; !exclusive || id == seen {
+				continue
+			}
+
+			if seen != "" {
+				return false
+			}
+
+			seen = id
+		}
+	}
+
+	return true
+}
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -10,9 +11,12 @@ import (
 )
 
 // Composable is a structure for containing composable policies.
+// A composite policy created by Compose contains no policies itself, but combines its parts with an operator.
 type Composable struct {
-	ActionMap *sync.Map // [ActionTypeSignature]Set(Template,Label)
-	LabelMap  *sync.Map // [Label]AggregationPolicy
+	ActionMap *sync.Map     // [ActionTypeSignature]Set(Template,Label)
+	LabelMap  *sync.Map     // [Label]AggregationPolicy
+	Op        Operator      // Operator combining the parts of a composite policy.
+	Parts     []*Composable // Parts of a composite policy in order of precedence.
 }
 
 // NewComposable creates a composable policy cp from any amount of aggregation policies aps.
@@ -28,8 +32,9 @@ func NewComposable(aps ...Aggregation) (cp *Composable) {
 
 // Add adds an aggregation policy ap to the composable policy cp.
 // Add returns true if the aggregation policy ap has been added to the composable policy cp, and false otherwise.
+// Aggregation policies can not be added to composite policies, but only to their parts.
 func (cp *Composable) Add(ap Aggregation) (b bool) {
-	b = cp != nil
+	b = cp != nil && !cp.IsComposite()
 
 	var crit = &sync.Mutex{}
 
@@ -50,13 +55,11 @@ func (cp *Composable) Add(ap Aggregation) (b bool) {
 			polExist = ml != nil && (*ml).ID() == lid
 		}
 
-		// Create a new set for (Template, Label)-pairs.
-		if !valid {
-			instance := mapset.NewSet()
-			polPair := container.NewTuple(atemp, l)
-			instance.Add(&polPair)
-			set = &instance
-		}
+		// Create a new set for (Template, Label)-pairs, joined with the pairs of other policies for actions of the same signature below.
+		instance := mapset.NewSet()
+		polPair := container.NewTuple(atemp, l)
+		instance.Add(&polPair)
+		set = &instance
 
 		b = !polExist
 		if b {
@@ -89,11 +92,12 @@ func (cp *Composable) Add(ap Aggregation) (b bool) {
 
 // FindLabel checks for a set s, a template tp has an associated a label l.
 // FindLabel returns l if it is associated to a template tp, and nil otherwise.
+// Of several templates matching tp, the label of the most specific one is returned, and the smallest label identifier among equally specific ones.
 func FindLabel(s *mapset.Set, tp *container.Template) (l *container.Label) {
 	b := tp != nil
 
 	if b {
-		var mpno, mqno uint
+		var mpno uint
 
 		sit := (*s).Iterator()
 		for elem := range sit.C {
@@ -106,17 +110,14 @@ func FindLabel(s *mapset.Set, tp *container.Template) (l *container.Label) {
 				continue
 			}
 
-			match, pno, qno := tp.ExactMatch(&temp)
+			match, pno, _ := tp.ExactMatch(&temp)
 
-			if match {
-				if qno > mqno {
-					if pno > mpno {
-						mpno = pno
-						lc := label.DeepCopy()
-						l = &lc
-					}
-					mqno = qno
-				}
+			// Prefer the most specific template, i.e. the one with the most fields matched by value rather than by type.
+			// Equally specific templates are told apart by the identifiers of their labels, as the set is iterated in no particular order.
+			if match && (l == nil || pno > mpno || (pno == mpno && label.ID() < l.ID())) {
+				mpno = pno
+				lc := label.DeepCopy()
+				l = &lc
 			}
 		}
 	}
@@ -129,7 +130,13 @@ func (cp *Composable) Find(a *Action) (l *container.Label) {
 	b := cp != nil
 	l = nil
 
-	if b {
+	if b && cp.IsComposite() {
+		for _, part := range cp.Parts {
+			if l = part.Find(a); l != nil {
+				break
+			}
+		}
+	} else if b {
 		as := a.Signature()
 		atemp := container.NewTemplate(a.Parameters()...)
 		iset, exists := cp.ActionMap.Load(as)
@@ -150,7 +157,13 @@ func (cp *Composable) Retrieve(l container.Label) (ap *Aggregation) {
 	b := cp != nil
 	ap = nil
 
-	if b {
+	if b && cp.IsComposite() {
+		for _, part := range cp.Parts {
+			if ap = part.Retrieve(l); ap != nil {
+				break
+			}
+		}
+	} else if b {
 		lid := l.ID()
 		val, exists := cp.LabelMap.Load(lid)
 		if exists {
@@ -162,6 +175,35 @@ func (cp *Composable) Retrieve(l container.Label) (ap *Aggregation) {
 	return ap
 }
 
+// Aggregations returns the aggregation policies aps of the composable policy cp, sorted by label.
+// For a composite policy, these are the aggregation policies of its parts, where earlier parts take precedence for a label.
+func (cp *Composable) Aggregations() (aps []Aggregation) {
+	aps = []Aggregation{}
+
+	if cp.IsComposite() {
+		seen := make(map[string]bool)
+		for _, part := range cp.Parts {
+			for _, ap := range part.Aggregations() {
+				if id := ap.Lbl.ID(); !seen[id] {
+					seen[id] = true
+					aps = append(aps, ap)
+				}
+			}
+		}
+	} else if cp != nil {
+		cp.LabelMap.Range(func(k, v interface{}) bool {
+			aps = append(aps, v.(Aggregation))
+			return true
+		})
+
+		sort.Slice(aps, func(i, j int) bool {
+			return aps[i].Lbl.ID() < aps[j].Lbl.ID()
+		})
+	}
+
+	return aps
+}
+
 // Delete removes an aggregation policy with label l from the composable policy cp.
 // Delete returns true if an aggregation policy with label l has been deleted from the composable policy cp, and false otherwise.
 func (cp *Composable) Delete(l container.Label) (b bool) {
@@ -187,6 +229,10 @@ func (cp *Composable) Delete(l container.Label) (b bool) {
 func (cp Composable) String() (s string) {
 	var actionEntries, labelEntries []string
 
+	if cp.IsComposite() {
+		return cp.stringComposite()
+	}
+
 	entries := []string{}
 	entry := make(chan string)
 
@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/pspaces/gospace/container"
+)
+
+func TestFindLabel(t *testing.T) {
+	// Setup
+	var x int
+
+	salary := container.NewTemplate("salary", &x)
+	get := NewAction(fileTestGet, salary.Fields()...)
+
+	cp := NewComposable(
+		NewAggregation(container.NewLabel("salary-b"), NewAccessRule(*get, Allow)),
+		NewAggregation(container.NewLabel("salary-c"), NewAccessRule(*get, Allow)),
+		NewAggregation(container.NewLabel("salary-a"), NewAccessRule(*get, Allow)),
+	)
+
+	// Test that equally specific templates are chosen between by label, however the candidates are iterated.
+	for i := 0; i < 50; i++ {
+		if l := cp.Find(get); l == nil || l.ID() != "salary-a" {
+			t.Fatalf("Find() gave %v for equally specific templates, should be salary-a", l)
+		}
+	}
+
+	// Test that a more specific template is preferred regardless of its label.
+	ceo := container.NewTemplate("salary", 100000)
+	getCEO := NewAction(fileTestGet, ceo.Fields()...)
+	cp.Add(NewAggregation(container.NewLabel("salary-z"), NewAccessRule(*getCEO, Deny)))
+
+	if l := cp.Find(getCEO); l == nil || l.ID() != "salary-z" {
+		t.Errorf("Find() gave %v for an action matching a more specific template, should be salary-z", l)
+	}
+
+	if l := cp.Find(get); l == nil || l.ID() != "salary-a" {
+		t.Errorf("Find() gave %v for an action not matching the more specific template, should be salary-a", l)
+	}
+
+	// Test that templates with the same types but other values are found as well.
+	wage := container.NewTemplate("wage", &x)
+	getWage := NewAction(fileTestGet, wage.Fields()...)
+	cp.Add(NewAggregation(container.NewLabel("wage-get"), NewAccessRule(*getWage, Allow)))
+
+	if l := cp.Find(getWage); l == nil || l.ID() != "wage-get" {
+		t.Errorf("Find() gave %v for an action with the types of other templates, should be wage-get", l)
+	}
+}
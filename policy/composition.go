@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// Operator defines how the parts of a composite policy are combined.
+type Operator int
+
+// Operators for composing policies.
+const (
+	// Sequential applies the parts one after the other.
+	// An action is permitted if every part with a rule for it permits it, and the transformations of those rules are applied in order.
+	Sequential Operator = iota
+	// Override applies the first part with a rule for an action, and ignores the remaining parts.
+	Override
+	// Intersection permits an action if every part with a rule for it permits it, and applies the transformations of the first such rule.
+	Intersection
+)
+
+// String returns a print friendly representation of an operator op.
+func (op Operator) String() (s string) {
+	switch op {
+	case Sequential:
+		s = "sequential"
+	case Override:
+		s = "override"
+	case Intersection:
+		s = "intersection"
+	default:
+		s = "unknown"
+	}
+
+	return s
+}
+
+// Compose creates a composite policy cp combining the composable policies cps in order with operator op.
+// Conflicts between parts are resolved by their order: the earlier part takes precedence.
+// Nil policies are left out, and a composite policy of no parts has no rules.
+func Compose(op Operator, cps ...*Composable) (cp *Composable) {
+	parts := make([]*Composable, 0, len(cps))
+	for _, part := range cps {
+		if part != nil {
+			parts = append(parts, part)
+		}
+	}
+
+	cp = &Composable{ActionMap: new(sync.Map), LabelMap: new(sync.Map), Op: op, Parts: parts}
+
+	return cp
+}
+
+// IsComposite returns true if the composable policy cp has been created by Compose, and false otherwise.
+func (cp *Composable) IsComposite() (b bool) {
+	b = cp != nil && cp.Parts != nil
+	return b
+}
+
+// authorizeComposite finds the aggregation policy ap applying to action a in the composite policy cp,
+// and checks that it permits a to a caller holding the labels caller.
+func (cp *Composable) authorizeComposite(a *Action, caller container.Labels) (ap *Aggregation, err error) {
+	aps := []*Aggregation{}
+
+	for _, part := range cp.Parts {
+		pap, perr := part.Authorize(a, caller)
+
+		if pap == nil && perr == nil {
+			continue
+		}
+
+		if cp.Op == Override || perr != nil {
+			return pap, perr
+		}
+
+		aps = append(aps, pap)
+	}
+
+	switch {
+	case len(aps) == 0:
+		ap = nil
+	case len(aps) == 1 || cp.Op == Intersection:
+		ap = aps[0]
+	default:
+		ap = chainAggregations(aps)
+	}
+
+	return ap, err
+}
+
+// chainAggregations creates an aggregation policy ap which applies the transformations of the aggregation policies aps in order.
+// The chained policy is labelled and refers to the action of the first aggregation policy, and requires the labels and honours the exclusive labels of all of them.
+func chainAggregations(aps []*Aggregation) (ap *Aggregation) {
+	tmpls := []*Transformation{}
+	mtchs := []*Transformation{}
+	rslts := []*Transformation{}
+
+	req := container.NewLabels()
+	excl := container.NewLabels()
+
+	for _, p := range aps {
+		trs := p.AggRule.Transformations()
+
+		if tr := trs.Template(); tr != nil {
+			tmpls = append(tmpls, tr)
+		}
+
+		if tr := trs.Match(); tr != nil {
+			mtchs = append(mtchs, tr)
+		}
+
+		if tr := trs.Result(); tr != nil {
+			rslts = append(rslts, tr)
+		}
+
+		for _, l := range p.AggRule.Required() {
+			req.Add(l)
+		}
+
+		for _, l := range p.AggRule.Exclusive() {
+			excl.Add(l)
+		}
+	}
+
+	trs := Transformations{Tmpl: chainTransformations(tmpls), Mtch: chainTransformations(mtchs), Rslt: chainTransformations(rslts)}
+
+	rule := NewAggregationRule(aps[0].Action(), trs)
+	if len(req) > 0 {
+		rule.Req = req
+	}
+	if len(excl) > 0 {
+		rule.Excl = excl
+	}
+
+	chained := NewAggregation(aps[0].Label(), rule)
+	ap = &chained
+
+	return ap
+}
+
+// chainTransformations creates a transformation tr applying the transformations trs in order, each to the fields of the result of the previous one.
+// The chained transformation has no result if any of the transformations fails.
+func chainTransformations(trs []*Transformation) (tr Transformation) {
+	switch len(trs) {
+	case 0:
+		return tr
+	case 1:
+		return *trs[0]
+	}
+
+	chain := func(i interface{}) (res interface{}) {
+		fields := i.([]interface{})
+
+		for _, t := range trs {
+			val, err := t.Apply(fields...)
+
+			if err != nil {
+				return nil
+			}
+
+			switch v := val.(type) {
+			case container.Template:
+				fields = v.Fields()
+			case container.Intertuple:
+				fields = v.Fields()
+			default:
+				return nil
+			}
+
+			res = val
+		}
+
+		return res
+	}
+
+	tr = NewTransformation(chain)
+
+	return tr
+}
+
+// stringComposite returns a print friendly representation of a composite policy cp.
+func (cp *Composable) stringComposite() (s string) {
+	s = fmt.Sprintf("%s%v", cp.Op, cp.Parts)
+	return s
+}
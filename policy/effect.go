@@ -0,0 +1,26 @@
+package policy
+
+// Effect defines the effect a rule has on the action it applies to.
+type Effect int
+
+// Effects of rules.
+const (
+	// Allow permits an action, possibly transforming it.
+	Allow Effect = iota
+	// Deny refuses an action.
+	Deny
+)
+
+// String returns a print friendly representation of an effect e.
+func (e Effect) String() (s string) {
+	switch e {
+	case Allow:
+		s = "allow"
+	case Deny:
+		s = "deny"
+	default:
+		s = "unknown"
+	}
+
+	return s
+}
@@ -0,0 +1,281 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/deckarep/golang-set"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// Explanation describes how a composable policy decides an action, without performing the action.
+// Explanations only contain printable values, such that they can be sent between peers.
+type Explanation struct {
+	Action      string        // Action explained, e.g. Get("salary", int).
+	Signature   string        // Action signature under which aggregation policies are looked up, i.e. the operator and the parameter types.
+	Candidates  []Candidate   // Aggregation policies registered under the action signature, sorted by label.
+	Label       string        // Label of the aggregation policy applying to the action, or empty if none applies.
+	Effect      string        // Effect of the applying aggregation policy, or empty if none applies.
+	Required    []string      // Labels a caller must hold under the applying aggregation policy, sorted.
+	Permitted   bool          // Whether the action is permitted to the caller.
+	Reason      string        // Reason for refusing the action, or empty if it is permitted.
+	Template    []string      // Template transformations applied to the action, in order.
+	Match       []string      // Match transformations applied to the matched tuples, in order.
+	Result      []string      // Result transformations applied to the result, in order.
+	Composition string        // Operator combining the parts of a composite policy, or empty for other policies.
+	Parts       []Explanation // Explanations of the parts of a composite policy, in order of precedence.
+	Version     int           // Version of the policy explained, if it is governing a space.
+}
+
+// Candidate describes an aggregation policy considered for an action, and how specifically its template matches the action.
+type Candidate struct {
+	Label    string // Label of the aggregation policy.
+	Template string // Template of the action of the aggregation policy.
+	Matches  bool   // Whether the template matches the parameters of the action.
+	Pno      uint   // Number of fields matched exactly, i.e. by equal values or equal types.
+	Qno      uint   // Number of fields matched, including values matched by a type.
+	Chosen   bool   // Whether the aggregation policy applies to the action, i.e. it is the matching candidate with the highest Pno.
+}
+
+// Explain explains how the composable policy cp decides action a for a caller holding the labels caller.
+// Explain has no side effects, and the transformations listed are not applied.
+func (cp *Composable) Explain(a *Action, caller container.Labels) (e Explanation) {
+	e = Explanation{Action: describeAction(a), Signature: describeSignature(a), Candidates: []Candidate{}}
+
+	if cp.IsComposite() {
+		cp.explainComposite(a, caller, &e)
+		return e
+	}
+
+	if cp != nil {
+		e.Candidates = cp.candidates(a)
+	}
+
+	ap, err := cp.Authorize(a, caller)
+	explainDecision(ap, err, &e)
+
+	if ap != nil {
+		trs := ap.AggRule.Transformations()
+		e.Template = describeTransformations(trs.Template())
+		e.Match = describeTransformations(trs.Match())
+		e.Result = describeTransformations(trs.Result())
+	}
+
+	return e
+}
+
+// explainComposite explains how the composite policy cp decides action a for a caller holding the labels caller in explanation e.
+func (cp *Composable) explainComposite(a *Action, caller container.Labels, e *Explanation) {
+	e.Composition = cp.Op.String()
+
+	applying := []Explanation{}
+
+	for _, part := range cp.Parts {
+		pe := part.Explain(a, caller)
+		e.Parts = append(e.Parts, pe)
+
+		if pe.Label != "" {
+			applying = append(applying, pe)
+		}
+	}
+
+	ap, err := cp.Authorize(a, caller)
+	explainDecision(ap, err, e)
+
+	if ap == nil {
+		return
+	}
+
+	// A refusal or a single applying part is decided by one part, and all other operators use the transformations of the first applying part.
+	if err != nil || cp.Op != Sequential || len(applying) == 1 {
+		for _, pe := range applying {
+			if pe.Label == e.Label {
+				e.Template, e.Match, e.Result = pe.Template, pe.Match, pe.Result
+				break
+			}
+		}
+
+		return
+	}
+
+	for _, pe := range applying {
+		e.Template = append(e.Template, pe.Template...)
+		e.Match = append(e.Match, pe.Match...)
+		e.Result = append(e.Result, pe.Result...)
+	}
+}
+
+// explainDecision records the aggregation policy ap applying to an action and the outcome err of authorizing it in explanation e.
+func explainDecision(ap *Aggregation, err error, e *Explanation) {
+	e.Permitted = err == nil
+
+	if err != nil {
+		e.Reason = err.Error()
+	}
+
+	if ap != nil {
+		e.Label = ap.Lbl.ID()
+		e.Effect = ap.AggRule.Effect().String()
+
+		required := ap.AggRule.Required()
+		e.Required = (&required).Labelling()
+		sort.Strings(e.Required)
+	}
+}
+
+// candidates returns the aggregation policies of the composable policy cp registered under the signature of action a, sorted by label.
+func (cp *Composable) candidates(a *Action) (cs []Candidate) {
+	cs = []Candidate{}
+
+	iset, exists := cp.ActionMap.Load(a.Signature())
+	set, valid := iset.(*mapset.Set)
+
+	if !exists || !valid {
+		return cs
+	}
+
+	chosen := cp.Find(a)
+	atemp := container.NewTemplate(a.Parameters()...)
+
+	for elem := range (*set).Iterator().C {
+		ituple := elem.(container.Intertuple)
+		temp, et := ituple.GetFieldAt(0).(container.Template)
+		label, el := ituple.GetFieldAt(1).(container.Label)
+
+		if !et || !el {
+			continue
+		}
+
+		match, pno, qno := atemp.ExactMatch(&temp)
+
+		c := Candidate{Label: label.ID(), Template: temp.String(), Matches: match, Pno: pno, Qno: qno}
+		c.Chosen = chosen != nil && chosen.ID() == c.Label
+
+		cs = append(cs, c)
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Label < cs[j].Label
+	})
+
+	return cs
+}
+
+// operatorName returns the short name of the operator op of an action, e.g. Get for the method value of a space.
+func operatorName(op string) (name string) {
+	name = op
+
+	// Operators are named by their fully qualified function names, of which only the last part is shown.
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.TrimSuffix(name, "-fm")
+
+	return name
+}
+
+// describeAction returns a print friendly representation s of action a.
+func describeAction(a *Action) (s string) {
+	tp := container.NewTemplate(a.Parameters()...)
+	s = fmt.Sprintf("%s%v", operatorName(a.Operator()), tp)
+	return s
+}
+
+// describeSignature returns a print friendly representation s of the signature of action a.
+func describeSignature(a *Action) (s string) {
+	tp := container.NewTemplate(a.Parameters()...)
+
+	types := make([]string, tp.Length())
+	for i := range types {
+		switch f := tp.GetFieldAt(i).(type) {
+		case nil:
+			types[i] = "nil"
+		case container.TypeField:
+			types[i] = f.GetType().String()
+		default:
+			types[i] = reflect.TypeOf(f).String()
+		}
+	}
+
+	s = fmt.Sprintf("%s(%s)", operatorName(a.Operator()), strings.Join(types, ", "))
+
+	return s
+}
+
+// describeTransformations returns the print friendly representations names of the transformation tr, or none if tr is not set.
+func describeTransformations(tr *Transformation) (names []string) {
+	if tr == nil || tr.Function() == nil {
+		return nil
+	}
+
+	name := function.Name(tr.Function())
+
+	if params := tr.Parameters(); len(params) > 0 {
+		name = fmt.Sprintf("%s%v", name, params)
+	}
+
+	names = []string{name}
+
+	return names
+}
+
+// String returns a print friendly representation of an explanation e.
+func (e Explanation) String() (s string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "policy version %d\n", e.Version)
+	e.write(&b, "")
+	return b.String()
+}
+
+// write writes explanation e to b with every line indented by indent.
+func (e *Explanation) write(b *strings.Builder, indent string) {
+	if e.Composition != "" {
+		fmt.Fprintf(b, "%s%s composition of %d parts\n", indent, e.Composition, len(e.Parts))
+	}
+
+	fmt.Fprintf(b, "%saction: %s\n", indent, e.Action)
+	fmt.Fprintf(b, "%ssignature: %s\n", indent, e.Signature)
+
+	for _, c := range e.Candidates {
+		mark := " "
+		if c.Chosen {
+			mark = "*"
+		}
+
+		fmt.Fprintf(b, "%s%s candidate %s %s: matches %t, pno %d, qno %d\n", indent, mark, c.Label, c.Template, c.Matches, c.Pno, c.Qno)
+	}
+
+	if e.Label == "" {
+		fmt.Fprintf(b, "%sno aggregation policy applies\n", indent)
+	} else {
+		fmt.Fprintf(b, "%spolicy: %s (%s", indent, e.Label, e.Effect)
+		if len(e.Required) > 0 {
+			fmt.Fprintf(b, ", requires %s", strings.Join(e.Required, ", "))
+		}
+		fmt.Fprintf(b, ")\n")
+	}
+
+	for _, t := range []struct {
+		kind  string
+		names []string
+	}{{"template", e.Template}, {"match", e.Match}, {"result", e.Result}} {
+		if len(t.names) > 0 {
+			fmt.Fprintf(b, "%s%s transformations: %s\n", indent, t.kind, strings.Join(t.names, ", "))
+		}
+	}
+
+	if e.Permitted {
+		fmt.Fprintf(b, "%spermitted\n", indent)
+	} else {
+		fmt.Fprintf(b, "%srefused: %s\n", indent, e.Reason)
+	}
+
+	for i := range e.Parts {
+		fmt.Fprintf(b, "%spart %d:\n", indent, i+1)
+		e.Parts[i].write(b, indent+"  ")
+	}
+}
@@ -0,0 +1,586 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+	"gopkg.in/yaml.v3"
+)
+
+// FileVersion is the version of the policy file format.
+const FileVersion = 1
+
+// FileError is an error found in a policy file at a line and a column.
+type FileError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+// Error returns a print friendly representation of the file error err.
+func (err *FileError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Msg)
+}
+
+// FileErrors contains all errors found in a policy file, in the order they occur in the file.
+type FileErrors []*FileError
+
+// Error returns a print friendly representation of the file errors errs, one error per line.
+func (errs FileErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// policyFile is the structure of a policy file.
+//
+//	version: 1
+//	policies:
+//	  - label: salary-get
+//	    action: Get
+//	    template: [salary, {type: int}]
+//	    effect: allow
+//	    require: [hr]
+//	    exclusive: [tenant-a, tenant-b]
+//	    transformations:
+//	      result: func://example/redact
+//
+// Template fields are literal values, or mappings naming the type of a field.
+// Tuples labelled by different exclusive labels are not aggregated together.
+// Transformations are namespaces of registered functions, or mappings with a function and its parameters.
+type policyFile struct {
+	Version  int          `json:"version" yaml:"version"`
+	Policies []policyItem `json:"policies" yaml:"policies"`
+}
+
+// policyItem is a single aggregation policy of a policy file.
+type policyItem struct {
+	Label           string                 `json:"label" yaml:"label"`
+	Action          string                 `json:"action" yaml:"action"`
+	Template        []interface{}          `json:"template,omitempty" yaml:"template,omitempty"`
+	Effect          string                 `json:"effect,omitempty" yaml:"effect,omitempty"`
+	Require         []string               `json:"require,omitempty" yaml:"require,omitempty"`
+	Exclusive       []string               `json:"exclusive,omitempty" yaml:"exclusive,omitempty"`
+	Transformations *policyTransformations `json:"transformations,omitempty" yaml:"transformations,omitempty"`
+}
+
+// policyTransformations are the transformations of a policy of a policy file.
+type policyTransformations struct {
+	Template interface{} `json:"template,omitempty" yaml:"template,omitempty"`
+	Match    interface{} `json:"match,omitempty" yaml:"match,omitempty"`
+	Result   interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+}
+
+// policyFunction is a transformation with parameters of a policy file.
+type policyFunction struct {
+	Function string        `json:"function" yaml:"function"`
+	Params   []interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// policyType is a template field of a policy file naming a type.
+type policyType struct {
+	Type string `json:"type" yaml:"type"`
+}
+
+// LoadFile reads the policy file at path and loads it as described by Load.
+func LoadFile(path string, fr ...*function.Registry) (cp *Composable, err error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(data, fr...)
+}
+
+// Load creates a composable policy cp from the policy file data, written in either YAML or JSON.
+// Actions refer to operations registered with RegisterOperation, template fields to types registered with RegisterType,
+// and transformations to namespaces of functions in function registry fr, which defaults to the global registry.
+// Load returns FileErrors err listing every problem found in data, and a nil policy, if data is not a valid policy file.
+func Load(data []byte, fr ...*function.Registry) (cp *Composable, err error) {
+	var root yaml.Node
+
+	err = yaml.Unmarshal(data, &root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{fr: function.GlobalRegistry}
+	if len(fr) == 1 && fr[0] != nil {
+		l.fr = fr[0]
+	}
+
+	cp = l.file(&root)
+
+	if len(l.errs) > 0 {
+		sort.SliceStable(l.errs, func(i, j int) bool {
+			a, b := l.errs[i], l.errs[j]
+			return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+		})
+
+		return nil, l.errs
+	}
+
+	return cp, nil
+}
+
+// loader builds a composable policy from the nodes of a policy file, collecting the errors found on the way.
+type loader struct {
+	fr   *function.Registry
+	errs FileErrors
+}
+
+// fail records an error described by format and args at node n.
+func (l *loader) fail(n *yaml.Node, format string, args ...interface{}) {
+	l.errs = append(l.errs, &FileError{Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+// fields returns the values of mapping node n by key, recording errors for keys which are not among keys.
+func (l *loader) fields(n *yaml.Node, what string, keys ...string) (fs map[string]*yaml.Node) {
+	fs = make(map[string]*yaml.Node)
+
+	if n.Kind != yaml.MappingNode {
+		l.fail(n, "%s must be a mapping", what)
+		return fs
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		known := false
+		for _, key := range keys {
+			known = known || k.Value == key
+		}
+
+		if !known {
+			l.fail(k, "unknown field %q in %s", k.Value, what)
+		} else if _, dup := fs[k.Value]; dup {
+			l.fail(k, "duplicate field %q in %s", k.Value, what)
+		} else {
+			fs[k.Value] = v
+		}
+	}
+
+	return fs
+}
+
+// str returns the string value s of scalar node n, recording an error if n is not a string.
+func (l *loader) str(n *yaml.Node, what string) (s string, b bool) {
+	b = n.Kind == yaml.ScalarNode && n.Tag == "!!str" && n.Value != ""
+
+	if b {
+		s = n.Value
+	} else {
+		l.fail(n, "%s must be a non-empty string", what)
+	}
+
+	return s, b
+}
+
+// scalar returns the value v of scalar node n, recording an error if n is not a string, number or boolean.
+func (l *loader) scalar(n *yaml.Node, what string) (v interface{}, b bool) {
+	switch n.Tag {
+	case "!!str", "!!int", "!!float", "!!bool":
+		b = n.Decode(&v) == nil
+	}
+
+	if !b {
+		l.fail(n, "%s must be a string, number or boolean", what)
+	}
+
+	return v, b
+}
+
+// file builds a composable policy cp from the root node of a policy file.
+func (l *loader) file(root *yaml.Node) (cp *Composable) {
+	cp = NewComposable()
+
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		l.fail(root, "policy file is empty")
+		return cp
+	}
+
+	fs := l.fields(root.Content[0], "policy file", "version", "policies")
+
+	if n, ok := fs["version"]; !ok {
+		l.fail(root.Content[0], "missing field %q in policy file", "version")
+	} else if v, ok := l.scalar(n, "version"); ok && v != FileVersion {
+		l.fail(n, "unsupported version %s, should be %d", n.Value, FileVersion)
+	}
+
+	n, ok := fs["policies"]
+	if !ok {
+		return cp
+	}
+
+	if n.Kind != yaml.SequenceNode {
+		l.fail(n, "policies must be a sequence")
+		return cp
+	}
+
+	seen := make(map[string]bool)
+	for _, pn := range n.Content {
+		ap, ok := l.policy(pn)
+
+		if !ok {
+			continue
+		}
+
+		if id := ap.Lbl.ID(); seen[id] {
+			l.fail(pn, "duplicate policy label %q", id)
+		} else {
+			seen[id] = true
+			cp.Add(ap)
+		}
+	}
+
+	return cp
+}
+
+// policy builds an aggregation policy ap from node n, returning false if n contains errors.
+func (l *loader) policy(n *yaml.Node) (ap Aggregation, b bool) {
+	errs := len(l.errs)
+
+	fs := l.fields(n, "policy", "label", "action", "template", "effect", "require", "exclusive", "transformations")
+
+	var lbl, oper string
+	for _, key := range []string{"label", "action"} {
+		if _, ok := fs[key]; !ok && n.Kind == yaml.MappingNode {
+			l.fail(n, "missing field %q in policy", key)
+		}
+	}
+
+	if ln, ok := fs["label"]; ok {
+		lbl, _ = l.str(ln, "label")
+	}
+
+	var fun interface{}
+	if an, ok := fs["action"]; ok {
+		if oper, ok = l.str(an, "action"); ok {
+			if fun = LookupOperation(oper); fun == nil {
+				l.fail(an, "unknown action %q, should be one of %s", oper, strings.Join(Operations(), ", "))
+			}
+		}
+	}
+
+	params := []interface{}{}
+	if tn, ok := fs["template"]; ok {
+		params = l.template(tn)
+	}
+
+	eff := Allow
+	if en, ok := fs["effect"]; ok {
+		if s, ok := l.str(en, "effect"); ok {
+			switch s {
+			case Allow.String():
+				eff = Allow
+			case Deny.String():
+				eff = Deny
+			default:
+				l.fail(en, "unknown effect %q, should be %s or %s", s, Allow, Deny)
+			}
+		}
+	}
+
+	var req, excl []container.Label
+	if rn, ok := fs["require"]; ok {
+		req = l.labels(rn, "require", "required label")
+	}
+
+	if xn, ok := fs["exclusive"]; ok {
+		excl = l.labels(xn, "exclusive", "exclusive label")
+	}
+
+	var trs Transformations
+	if tn, ok := fs["transformations"]; ok {
+		trs = l.transformations(tn)
+	}
+
+	if len(l.errs) > errs {
+		return ap, false
+	}
+
+	a := NewAction(fun, params...)
+	rule := NewAggregationRule(*a, trs)
+	rule.Eff = eff
+	if len(req) > 0 {
+		rule.Req = container.NewLabels(req...)
+	}
+	if len(excl) > 0 {
+		rule.Excl = container.NewLabels(excl...)
+	}
+
+	ap = NewAggregation(container.NewLabel(lbl), rule)
+
+	return ap, true
+}
+
+// labels returns the labels lbls of the sequence at node n, which is the value of the field named key.
+func (l *loader) labels(n *yaml.Node, key string, what string) (lbls []container.Label) {
+	if n.Kind != yaml.SequenceNode {
+		l.fail(n, "%s must be a sequence of labels", key)
+		return lbls
+	}
+
+	for _, ln := range n.Content {
+		if id, ok := l.str(ln, what); ok {
+			lbls = append(lbls, container.NewLabel(id))
+		}
+	}
+
+	return lbls
+}
+
+// template returns the action parameters params of the template at node n.
+func (l *loader) template(n *yaml.Node) (params []interface{}) {
+	params = []interface{}{}
+
+	if n.Kind != yaml.SequenceNode {
+		l.fail(n, "template must be a sequence of fields")
+		return params
+	}
+
+	for _, fn := range n.Content {
+		if fn.Kind == yaml.MappingNode {
+			fs := l.fields(fn, "template field", "type")
+
+			tn, ok := fs["type"]
+			if !ok {
+				l.fail(fn, "missing field %q in template field", "type")
+				continue
+			}
+
+			if name, ok := l.str(tn, "type"); ok {
+				if t := LookupType(name); t != nil {
+					params = append(params, container.CreateTypeField(reflect.Zero(t).Interface()))
+				} else {
+					l.fail(tn, "unknown type %q", name)
+				}
+			}
+		} else if v, ok := l.scalar(fn, "template field"); ok {
+			params = append(params, v)
+		}
+	}
+
+	return params
+}
+
+// transformations returns the transformations trs at node n.
+func (l *loader) transformations(n *yaml.Node) (trs Transformations) {
+	fs := l.fields(n, "transformations", "template", "match", "result")
+
+	if tn, ok := fs["template"]; ok {
+		trs.Tmpl = l.transformation(tn)
+	}
+
+	if mn, ok := fs["match"]; ok {
+		trs.Mtch = l.transformation(mn)
+	}
+
+	if rn, ok := fs["result"]; ok {
+		trs.Rslt = l.transformation(rn)
+	}
+
+	return trs
+}
+
+// transformation returns the transformation tr at node n, which is either a namespace or a function with parameters.
+func (l *loader) transformation(n *yaml.Node) (tr Transformation) {
+	fn := n
+	var params []interface{}
+
+	if n.Kind == yaml.MappingNode {
+		fs := l.fields(n, "transformation", "function", "params")
+
+		var ok bool
+		if fn, ok = fs["function"]; !ok {
+			l.fail(n, "missing field %q in transformation", "function")
+			return tr
+		}
+
+		if pn, ok := fs["params"]; ok {
+			if pn.Kind != yaml.SequenceNode {
+				l.fail(pn, "params must be a sequence")
+			} else {
+				for _, vn := range pn.Content {
+					if v, ok := l.scalar(vn, "parameter"); ok {
+						params = append(params, v)
+					}
+				}
+			}
+		}
+	}
+
+	ns, ok := l.str(fn, "function")
+	if !ok {
+		return tr
+	}
+
+	var fun *function.Function
+	if l.fr != nil {
+		fun = l.fr.Decode(function.NewNamespace(ns))
+	}
+
+	if fun == nil {
+		l.fail(fn, "function %s is not registered", ns)
+		return tr
+	}
+
+	tr = NewTransformation(*fun, params...)
+
+	return tr
+}
+
+// Marshal returns the policy file data in YAML describing the composable policy cp, such that Load recreates cp.
+// Marshal returns an error err if cp uses an operation, type or function which policy files can not refer to.
+func Marshal(cp *Composable, fr ...*function.Registry) (data []byte, err error) {
+	pf, err := describe(cp, fr...)
+
+	if err == nil {
+		var buf bytes.Buffer
+
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+
+		err = enc.Encode(pf)
+
+		if err == nil {
+			err = enc.Close()
+		}
+
+		data = buf.Bytes()
+	}
+
+	return data, err
+}
+
+// MarshalJSON returns the policy file data in JSON describing the composable policy cp, such that Load recreates cp.
+// MarshalJSON returns an error err if cp uses an operation, type or function which policy files can not refer to.
+func MarshalJSON(cp *Composable, fr ...*function.Registry) (data []byte, err error) {
+	pf, err := describe(cp, fr...)
+
+	if err == nil {
+		data, err = json.MarshalIndent(pf, "", "  ")
+	}
+
+	return data, err
+}
+
+// describe returns the policy file pf describing the composable policy cp, with its policies sorted by label.
+func describe(cp *Composable, fr ...*function.Registry) (pf policyFile, err error) {
+	reg := function.GlobalRegistry
+	if len(fr) == 1 && fr[0] != nil {
+		reg = fr[0]
+	}
+
+	pf = policyFile{Version: FileVersion, Policies: []policyItem{}}
+
+	if cp.IsComposite() {
+		return pf, fmt.Errorf("composite %s policies can not be written to a policy file", cp.Op)
+	}
+
+	for _, ap := range cp.Aggregations() {
+		item, e := describePolicy(ap, reg)
+
+		if e != nil {
+			return pf, fmt.Errorf("policy %s: %s", ap.Lbl.ID(), e)
+		}
+
+		pf.Policies = append(pf.Policies, item)
+	}
+
+	return pf, err
+}
+
+// describePolicy returns the policy file entry item describing the aggregation policy ap.
+func describePolicy(ap Aggregation, fr *function.Registry) (item policyItem, err error) {
+	a := ap.Action()
+	rule := ap.AggRule
+
+	oper, ok := OperationName(a.Function())
+	if !ok {
+		return item, fmt.Errorf("action %s is not a registered operation", a.Operator())
+	}
+
+	item = policyItem{Label: ap.Lbl.ID(), Action: oper}
+
+	for _, p := range a.Parameters() {
+		switch v := p.(type) {
+		case container.TypeField:
+			if LookupType(v.String()) == nil {
+				return item, fmt.Errorf("type %s is not registered", v)
+			}
+			item.Template = append(item.Template, policyType{Type: v.String()})
+		case string, bool, int, float64:
+			item.Template = append(item.Template, v)
+		default:
+			return item, fmt.Errorf("template field %v of type %T can not be written to a policy file", p, p)
+		}
+	}
+
+	if rule.Effect() != Allow {
+		item.Effect = rule.Effect().String()
+	}
+
+	req := rule.Required()
+	item.Require = req.Labelling()
+	sort.Strings(item.Require)
+
+	excl := rule.Exclusive()
+	item.Exclusive = excl.Labelling()
+	sort.Strings(item.Exclusive)
+
+	trs := rule.Transformations()
+	pts := policyTransformations{}
+	for _, t := range []struct {
+		tr  *Transformation
+		dst *interface{}
+	}{{trs.Template(), &pts.Template}, {trs.Match(), &pts.Match}, {trs.Result(), &pts.Result}} {
+		if t.tr == nil {
+			continue
+		}
+
+		*t.dst, err = describeTransformation(t.tr, fr)
+
+		if err != nil {
+			return item, err
+		}
+	}
+
+	if pts.Template != nil || pts.Match != nil || pts.Result != nil {
+		item.Transformations = &pts
+	}
+
+	return item, err
+}
+
+// describeTransformation returns the policy file entry v describing the transformation tr.
+func describeTransformation(tr *Transformation, fr *function.Registry) (v interface{}, err error) {
+	var ns *function.Namespace
+	if fr != nil {
+		ns = fr.Encode(tr.Function())
+	}
+
+	if ns == nil {
+		return v, fmt.Errorf("function %s is not registered", function.Name(tr.Function()))
+	}
+
+	if len(tr.Parameters()) == 0 {
+		v = ns.String()
+	} else {
+		v = policyFunction{Function: ns.String(), Params: tr.Parameters()}
+	}
+
+	return v, err
+}
@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// fileTestGet stands in for an operation of a space.
+func fileTestGet(template ...interface{}) (container.Tuple, error) {
+	return container.NewTuple(), nil
+}
+
+// fileTestRedact stands in for a transformation.
+func fileTestRedact(i interface{}) container.Intertuple {
+	t := container.NewTuple()
+	return &t
+}
+
+func fileTestRegistry() (fr *function.Registry) {
+	RegisterOperation("Get", fileTestGet)
+
+	reg := function.NewRegistry()
+	reg.RegisterNamespace(fileTestRedact, function.NewNamespace("func://test/redact"))
+
+	return &reg
+}
+
+const fileTestPolicy = `version: 1
+policies:
+  - label: salary-get
+    action: Get
+    template: [salary, {type: int}]
+    require: [hr]
+    exclusive: [tenant-a, tenant-b]
+    transformations:
+      result: func://test/redact
+  - label: secret-get
+    action: Get
+    template: [secret, 42, true, 1.5]
+    effect: deny
+`
+
+func TestLoad(t *testing.T) {
+	// Setup
+	fr := fileTestRegistry()
+
+	cp, err := Load([]byte(fileTestPolicy), fr)
+	if err != nil {
+		t.Fatalf("Load() gave %v, should be nil", err)
+	}
+
+	var x int
+	salary := container.NewTemplate("salary", &x)
+
+	ap, err := cp.Authorize(NewAction(fileTestGet, salary.Fields()...), container.NewLabels(container.NewLabel("hr")))
+	if err != nil || ap == nil || ap.Lbl.ID() != "salary-get" {
+		t.Errorf("Authorize() gave %v and %v, should be policy salary-get", ap, err)
+	}
+
+	trs := ap.AggRule.Transformations()
+	if trs.Result() == nil || trs.Template() != nil {
+		t.Errorf("Load() gave transformations %v, should only have a result transformation", trs)
+	}
+
+	tenantA := container.NewLabels(container.NewLabel("tenant-a"), container.NewLabel("hr"))
+	tenantB := container.NewLabels(container.NewLabel("tenant-b"))
+	if !ap.AggRule.Compatible(tenantA, tenantA) || ap.AggRule.Compatible(tenantA, tenantB) {
+		t.Errorf("Compatible() should only permit aggregating tuples of one of the exclusive labels %v", ap.AggRule.Exclusive())
+	}
+
+	_, err = cp.Authorize(NewAction(fileTestGet, salary.Fields()...), container.NewLabels())
+	if err == nil {
+		t.Errorf("Authorize() gave nil for a caller without label hr, should be an error")
+	}
+
+	_, err = cp.Authorize(NewAction(fileTestGet, "secret", 42, true, 1.5), container.NewLabels())
+	if err == nil {
+		t.Errorf("Authorize() gave nil for a denied action, should be an error")
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	// Setup
+	fr := fileTestRegistry()
+
+	data := `{"version": 1, "policies": [{"label": "a", "action": "Get", "template": ["x", {"type": "string"}]}]}`
+
+	cp, err := Load([]byte(data), fr)
+	if err != nil || cp.Retrieve(container.NewLabel("a")) == nil {
+		t.Errorf("Load() gave %v and %v, should be a policy labelled a", cp, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	// Setup
+	fr := fileTestRegistry()
+
+	data := `version: 1
+policies:
+  - label: a
+    action: Steal
+  - label: b
+    action: Get
+    template: [{type: complex256}]
+    effect: maybe
+    colour: blue
+  - action: Get
+    transformations:
+      result: func://test/unknown
+`
+
+	_, err := Load([]byte(data), fr)
+
+	errs, ok := err.(FileErrors)
+	if !ok {
+		t.Fatalf("Load() gave %v, should be file errors", err)
+	}
+
+	expected := []struct {
+		line int
+		msg  string
+	}{
+		{4, "unknown action"},
+		{7, "unknown type"},
+		{8, "unknown effect"},
+		{9, "unknown field"},
+		{10, "missing field \"label\""},
+		{12, "not registered"},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Load() gave %d errors, should be %d:\n%v", len(errs), len(expected), err)
+	}
+
+	for i, e := range expected {
+		if errs[i].Line != e.line || !strings.Contains(errs[i].Msg, e.msg) {
+			t.Errorf("Load() gave error %v, should be at line %d containing %q", errs[i], e.line, e.msg)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	// Setup
+	fr := fileTestRegistry()
+
+	cp, err := Load([]byte(fileTestPolicy), fr)
+	if err != nil {
+		t.Fatalf("Load() gave %v, should be nil", err)
+	}
+
+	data, err := Marshal(cp, fr)
+	if err != nil {
+		t.Fatalf("Marshal() gave %v, should be nil", err)
+	}
+
+	rcp, err := Load(data, fr)
+	if err != nil {
+		t.Fatalf("Load() of marshalled policy gave %v, should be nil:\n%s", err, data)
+	}
+
+	rdata, _ := Marshal(rcp, fr)
+	if string(rdata) != string(data) {
+		t.Errorf("Marshal() gave\n%s\nafter round-tripping, should be\n%s", rdata, data)
+	}
+
+	jdata, err := MarshalJSON(cp, fr)
+	if err != nil {
+		t.Fatalf("MarshalJSON() gave %v, should be nil", err)
+	}
+
+	jcp, err := Load(jdata, fr)
+	if err != nil {
+		t.Fatalf("Load() of JSON policy gave %v, should be nil:\n%s", err, jdata)
+	}
+
+	if jrdata, _ := Marshal(jcp, fr); string(jrdata) != string(data) {
+		t.Errorf("Marshal() gave\n%s\nafter round-tripping through JSON, should be\n%s", jrdata, data)
+	}
+
+	unregistered := NewComposable(NewAggregation(container.NewLabel("x"), NewAccessRule(*NewAction(fileTestRedact), Allow)))
+	if _, err := Marshal(unregistered, fr); err == nil {
+		t.Errorf("Marshal() gave nil for an unregistered operation, should be an error")
+	}
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// operations contains the operations policy files can refer to by name.
+var operations = struct {
+	mu     *sync.RWMutex
+	byName map[string]interface{}
+}{mu: new(sync.RWMutex), byName: make(map[string]interface{})}
+
+// types contains the types policy files can refer to by name.
+var types = struct {
+	mu     *sync.RWMutex
+	byName map[string]reflect.Type
+}{mu: new(sync.RWMutex), byName: make(map[string]reflect.Type)}
+
+func init() {
+	for _, v := range []interface{}{
+		false, "",
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), []byte{}, []string{},
+		container.Labels{},
+	} {
+		RegisterType(v)
+	}
+}
+
+// RegisterOperation makes the operation fun available to policy files under name.
+// Spaces register their operations, such that policy files can refer to e.g. Get.
+func RegisterOperation(name string, fun interface{}) {
+	operations.mu.Lock()
+	defer operations.mu.Unlock()
+
+	operations.byName[name] = fun
+}
+
+// LookupOperation returns the operation fun registered under name, or nil if no such operation is registered.
+func LookupOperation(name string) (fun interface{}) {
+	operations.mu.RLock()
+	defer operations.mu.RUnlock()
+
+	fun = operations.byName[name]
+
+	return fun
+}
+
+// OperationName returns the name under which the operation fun is registered.
+// OperationName returns false if fun is not registered, and true otherwise.
+func OperationName(fun interface{}) (name string, b bool) {
+	operations.mu.RLock()
+	defer operations.mu.RUnlock()
+
+	fn := function.Name(fun)
+
+	for n, f := range operations.byName {
+		if function.Name(f) == fn {
+			return n, true
+		}
+	}
+
+	return name, b
+}
+
+// Operations returns the sorted names of all registered operations.
+func Operations() (names []string) {
+	operations.mu.RLock()
+	defer operations.mu.RUnlock()
+
+	names = make([]string, 0, len(operations.byName))
+	for n := range operations.byName {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// RegisterType makes the type of value v available to policy files under its Go name, e.g. int or []uint8.
+// The basic types of Go are registered by default.
+func RegisterType(v interface{}) {
+	types.mu.Lock()
+	defer types.mu.Unlock()
+
+	t := reflect.TypeOf(v)
+	types.byName[t.String()] = t
+}
+
+// LookupType returns the type t registered under name, or nil if no such type is registered.
+func LookupType(name string) (t reflect.Type) {
+	types.mu.RLock()
+	defer types.mu.RUnlock()
+
+	t = types.byName[name]
+
+	return t
+}
@@ -35,10 +35,11 @@ func NewTransformations(tr ...*Transformation) (trs *Transformations) {
 }
 
 // Template returns a transformation that can be applied to template entities.
+// Template returns nil if no such transformation is specified.
 func (trs *Transformations) Template() (trans *Transformation) {
 	trans = nil
 
-	if trs != nil {
+	if trs != nil && trs.Tmpl.Function() != nil {
 		trans = &trs.Tmpl
 	}
 
@@ -46,10 +47,11 @@ func (trs *Transformations) Template() (trans *Transformation) {
 }
 
 // Match returns an transformation that can be applied to matched entities.
+// Match returns nil if no such transformation is specified.
 func (trs *Transformations) Match() (match *Transformation) {
 	match = nil
 
-	if trs != nil {
+	if trs != nil && trs.Mtch.Function() != nil {
 		match = &trs.Mtch
 	}
 
@@ -57,10 +59,11 @@ func (trs *Transformations) Match() (match *Transformation) {
 }
 
 // Result returns an transformation that can be applied to result entities.
+// Result returns nil if no such transformation is specified.
 func (trs *Transformations) Result() (rslt *Transformation) {
 	rslt = nil
 
-	if trs != nil {
+	if trs != nil && trs.Rslt.Function() != nil {
 		rslt = &trs.Rslt
 	}
 
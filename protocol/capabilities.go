@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"sort"
+
+	"github.com/pspaces/gospace/function"
+)
+
+// Capabilities is the package exchanged by a client and a space when a connection is set up.
+// It contains the runtime version and the namespaces of all functions registered at a peer.
+type Capabilities struct {
+	Version    string
+	Namespaces []string
+}
+
+// CreateCapabilities will create the capabilities of a peer given its function registry fr.
+// A peer without a function registry has no functions registered.
+func CreateCapabilities(fr *function.Registry) Capabilities {
+	caps := Capabilities{Namespaces: []string{}}
+
+	if fr != nil {
+		caps.Version = fr.Version()
+
+		for _, ns := range fr.Namespaces() {
+			caps.Namespaces = append(caps.Namespaces, string(ns))
+		}
+	}
+
+	return caps
+}
+
+// GetVersion will return the runtime version of the capabilities.
+func (caps *Capabilities) GetVersion() string {
+	return caps.Version
+}
+
+// Supports will return true if the function with the external namespace ns is registered at the peer, and false otherwise.
+func (caps *Capabilities) Supports(ns string) bool {
+	i := sort.SearchStrings(caps.Namespaces, ns)
+	return i < len(caps.Namespaces) && caps.Namespaces[i] == ns
+}
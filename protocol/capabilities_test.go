@@ -0,0 +1,37 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/pspaces/gospace/function"
+)
+
+func capabilitiesTestFunction(x int) int {
+	return x
+}
+
+func TestCreateCapabilities(t *testing.T) {
+	// Setup
+	fr := function.NewRegistry()
+	ns := fr.Encode(capabilitiesTestFunction)
+
+	testCapabilities := CreateCapabilities(&fr)
+
+	if testCapabilities.GetVersion() != fr.Version() {
+		t.Errorf("GetVersion() gave %s, should be %s", testCapabilities.GetVersion(), fr.Version())
+	}
+
+	if !testCapabilities.Supports(ns.String()) {
+		t.Errorf("Supports(%s) gave false, should be true", ns)
+	}
+
+	if testCapabilities.Supports("func://golang:0.0/main.missing:()->()") {
+		t.Errorf("Supports() gave true for an unregistered function, should be false")
+	}
+
+	emptyCapabilities := CreateCapabilities(nil)
+
+	if emptyCapabilities.Supports(ns.String()) {
+		t.Errorf("Supports(%s) gave true without a registry, should be false", ns)
+	}
+}
@@ -1,11 +1,20 @@
 package protocol
 
+import (
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/tracing"
+)
+
 // Message is the package that is send across a connection.
 // It contains the type of message, denoted by operation and either a tuple or
 // template, depending on the type of operation.
+// It furthermore contains the labels held by the sender, which policies can
+// require of the sender, and the span context of the operation if it is traced.
 type Message struct {
 	Operation string
 	T         interface{}
+	Labels    container.Labels
+	Trace     tracing.SpanContext
 }
 
 // CreateMessage will create the message and return it with the opertaion type
@@ -25,3 +34,23 @@ func (message *Message) GetOperation() string {
 func (message *Message) GetBody() interface{} {
 	return message.T
 }
+
+// GetLabels will return the labels held by the sender of the message.
+func (message *Message) GetLabels() container.Labels {
+	return message.Labels
+}
+
+// SetLabels will set the labels held by the sender of the message.
+func (message *Message) SetLabels(ls container.Labels) {
+	message.Labels = ls
+}
+
+// GetTrace will return the span context of the operation of the message.
+func (message *Message) GetTrace() tracing.SpanContext {
+	return message.Trace
+}
+
+// SetTrace will set the span context of the operation of the message.
+func (message *Message) SetTrace(sc tracing.SpanContext) {
+	message.Trace = sc
+}
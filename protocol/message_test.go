@@ -3,6 +3,8 @@ package protocol
 import (
 	"reflect"
 	"testing"
+
+	"github.com/pspaces/gospace/tracing"
 )
 
 // Test to see if Message is creating correct.
@@ -13,7 +15,7 @@ func TestCreateMessage(t *testing.T) {
 	// Create Message manually.
 	actualOperation := GetRequest
 	actualT := []interface{}{"3", true, 4}
-	actualMessage := Message{actualOperation, actualT}
+	actualMessage := Message{actualOperation, actualT, nil, tracing.SpanContext{}}
 
 	// Test that the two templates are equal.
 	messagesEqual := reflect.DeepEqual(testMessage, actualMessage)
@@ -2,28 +2,51 @@ package protocol
 
 // Constants used for the messages.
 const (
-	PutRequest       = "PUT_REQUEST"
-	PutResponse      = "PUT_RESPONSE"
-	PutPRequest      = "PUTP_REQUEST"
-	PutPResponse     = "PUTP_RESPONSE"
-	GetRequest       = "GET_REQUEST"
-	GetResponse      = "GET_RESPONSE"
-	GetPRequest      = "GETP_REQUEST"
-	GetPResponse     = "GETP_RESPONSE"
-	GetAllRequest    = "GETALL_REQUEST"
-	GetAllResponse   = "GETALL_RESPONSE"
-	QueryRequest     = "QUERY_REQUEST"
-	QueryResponse    = "QUERY_RESPONSE"
-	QueryPRequest    = "QUERYP_REQUEST"
-	QueryPResponse   = "QUERYP_RESPONSE"
-	QueryAllRequest  = "QUERYALL_REQUEST"
-	QueryAllResponse = "QUERYALL_RESPONSE"
-	QueryAggRequest  = "QUERYAGG_REQUEST"
-	QueryAggResponse = "QUERYAGG_RESPONSE"
-	GetAggRequest    = "GETAGG_REQUEST"
-	GetAggResponse   = "GETAGG_RESPONSE"
-	PutAggRequest    = "PUTAGG_REQUEST"
-	PutAggResponse   = "PUTAGG_RESPONSE"
-	SizeRequest      = "SIZE_REQUEST"
-	SizeResponse     = "SIZE_RESPONSE"
+	PutRequest            = "PUT_REQUEST"
+	PutResponse           = "PUT_RESPONSE"
+	PutPRequest           = "PUTP_REQUEST"
+	PutPResponse          = "PUTP_RESPONSE"
+	GetRequest            = "GET_REQUEST"
+	GetResponse           = "GET_RESPONSE"
+	GetPRequest           = "GETP_REQUEST"
+	GetPResponse          = "GETP_RESPONSE"
+	GetAllRequest         = "GETALL_REQUEST"
+	GetAllResponse        = "GETALL_RESPONSE"
+	QueryRequest          = "QUERY_REQUEST"
+	QueryResponse         = "QUERY_RESPONSE"
+	QueryPRequest         = "QUERYP_REQUEST"
+	QueryPResponse        = "QUERYP_RESPONSE"
+	QueryAllRequest       = "QUERYALL_REQUEST"
+	QueryAllResponse      = "QUERYALL_RESPONSE"
+	QueryAggRequest       = "QUERYAGG_REQUEST"
+	QueryAggResponse      = "QUERYAGG_RESPONSE"
+	GetAggRequest         = "GETAGG_REQUEST"
+	GetAggResponse        = "GETAGG_RESPONSE"
+	PutAggRequest         = "PUTAGG_REQUEST"
+	PutAggResponse        = "PUTAGG_RESPONSE"
+	GetAggNRequest        = "GETAGGN_REQUEST"
+	GetAggNResponse       = "GETAGGN_RESPONSE"
+	QueryAggNRequest      = "QUERYAGGN_REQUEST"
+	QueryAggNResponse     = "QUERYAGGN_RESPONSE"
+	SizeRequest           = "SIZE_REQUEST"
+	SizeResponse          = "SIZE_RESPONSE"
+	EvalRequest           = "EVAL_REQUEST"
+	EvalResponse          = "EVAL_RESPONSE"
+	SetPolicyRequest      = "SETPOLICY_REQUEST"
+	SetPolicyResponse     = "SETPOLICY_RESPONSE"
+	UpdatePolicyRequest   = "UPDATEPOLICY_REQUEST"
+	UpdatePolicyResponse  = "UPDATEPOLICY_RESPONSE"
+	ExplainPolicyRequest  = "EXPLAINPOLICY_REQUEST"
+	ExplainPolicyResponse = "EXPLAINPOLICY_RESPONSE"
+
+	InspectTuplesRequest          = "INSPECTTUPLES_REQUEST"
+	InspectTuplesResponse         = "INSPECTTUPLES_RESPONSE"
+	InspectWaitingClientsRequest  = "INSPECTWAITINGCLIENTS_REQUEST"
+	InspectWaitingClientsResponse = "INSPECTWAITINGCLIENTS_RESPONSE"
+	InspectConnectionsRequest     = "INSPECTCONNECTIONS_REQUEST"
+	InspectConnectionsResponse    = "INSPECTCONNECTIONS_RESPONSE"
+	InspectPolicyRequest          = "INSPECTPOLICY_REQUEST"
+	InspectPolicyResponse         = "INSPECTPOLICY_RESPONSE"
+	InspectFunctionsRequest       = "INSPECTFUNCTIONS_REQUEST"
+	InspectFunctionsResponse      = "INSPECTFUNCTIONS_RESPONSE"
 )
@@ -4,16 +4,27 @@ import (
 	"net"
 	"strings"
 
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
 	"github.com/pspaces/gospace/function"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/tracing"
+	"github.com/pspaces/gospace/transport"
 )
 
 // PointToPoint contains information about the receiver, being a user specified
 // name, the IP address and the port number.
 type PointToPoint struct {
-	name    string             // Name of receiver.
-	address string             // IP address and port number of receiver separated by ":".
-	connc   chan *net.Conn     // Active connection channel.
-	funReg  *function.Registry // Function registry.
+	name    string              // Name of receiver.
+	address string              // IP address and port number of receiver separated by ":".
+	connc   chan *net.Conn      // Active connection channel.
+	funReg  *function.Registry  // Function registry.
+	labels  container.Labels    // Labels held by the sender.
+	id      auth.Identity       // Identity of the sender.
+	logger  logging.Logger      // Logger of the sender, or nil for the default logger.
+	tracer  *tracing.Tracer     // Tracer of the sender, or nil if operations are not traced.
+	trace   tracing.SpanContext // Span context of the operations of the sender, or empty if they start their own traces.
+	tr      transport.Transport // Transport connecting to the receiver, or nil for TCP and local connections.
 }
 
 // CreatePointToPoint will concatenate the ip and the port to a string to create
@@ -72,3 +83,127 @@ func (ptp *PointToPoint) GetName() string {
 func (ptp *PointToPoint) GetRegistry() (fr *function.Registry) {
 	return ptp.funReg
 }
+
+// GetLabels will return the labels held by the sender using ptp.
+func (ptp *PointToPoint) GetLabels() (ls container.Labels) {
+	if ptp != nil {
+		ls = ptp.labels
+	}
+
+	return ls
+}
+
+// SetLabels sets the labels held by the sender using ptp.
+func (ptp *PointToPoint) SetLabels(ls container.Labels) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).labels = ls
+	}
+
+	return b
+}
+
+// GetIdentity will return the identity of the sender using ptp.
+func (ptp *PointToPoint) GetIdentity() (id auth.Identity) {
+	if ptp != nil {
+		id = ptp.id
+	}
+
+	return id
+}
+
+// SetIdentity sets the identity of the sender using ptp.
+func (ptp *PointToPoint) SetIdentity(id auth.Identity) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).id = id
+	}
+
+	return b
+}
+
+// GetLogger will return the logger of the sender using ptp, or the default logger if it has none.
+func (ptp *PointToPoint) GetLogger() (l logging.Logger) {
+	if ptp != nil {
+		l = ptp.logger
+	}
+
+	if l == nil {
+		l = logging.Default()
+	}
+
+	return l
+}
+
+// SetLogger sets the logger of the sender using ptp, or makes it use the default logger if l is nil.
+func (ptp *PointToPoint) SetLogger(l logging.Logger) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).logger = l
+	}
+
+	return b
+}
+
+// GetTracer will return the tracer of the sender using ptp.
+func (ptp *PointToPoint) GetTracer() (t *tracing.Tracer) {
+	if ptp != nil {
+		t = ptp.tracer
+	}
+
+	return t
+}
+
+// SetTracer sets the tracer of the sender using ptp, or stops tracing if t is nil.
+func (ptp *PointToPoint) SetTracer(t *tracing.Tracer) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).tracer = t
+	}
+
+	return b
+}
+
+// GetTrace will return the span context which operations of the sender using ptp are part of.
+func (ptp *PointToPoint) GetTrace() (sc tracing.SpanContext) {
+	if ptp != nil {
+		sc = ptp.trace
+	}
+
+	return sc
+}
+
+// SetTrace makes the operations of the sender using ptp part of the span identified by span context sc.
+func (ptp *PointToPoint) SetTrace(sc tracing.SpanContext) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).trace = sc
+	}
+
+	return b
+}
+
+// GetTransport will return the transport connecting to the receiver, or nil if TCP and local connections are used.
+func (ptp *PointToPoint) GetTransport() (t transport.Transport) {
+	if ptp != nil {
+		t = ptp.tr
+	}
+
+	return t
+}
+
+// SetTransport makes connections to the receiver through transport t, or through TCP and local connections if t is nil.
+func (ptp *PointToPoint) SetTransport(t transport.Transport) (b bool) {
+	b = ptp != nil
+
+	if b {
+		(*ptp).tr = t
+	}
+
+	return b
+}
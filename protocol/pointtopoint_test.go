@@ -16,7 +16,7 @@ func TestCreatePointToPoint(t *testing.T) {
 	actualIP := "192.168.0.0"
 	actualPort := 8080
 	actualAddress := strings.Join([]string{actualIP, strconv.Itoa(actualPort)}, ":")
-	actualPointToPoint := &PointToPoint{actualName, actualAddress, nil, nil}
+	actualPointToPoint := &PointToPoint{name: actualName, address: actualAddress}
 
 	pointToPointsEqual := reflect.DeepEqual(testPointToPoint, actualPointToPoint)
 
@@ -75,5 +75,5 @@ func createTestPointToPoint() *PointToPoint {
 	testIP := "192.168.0.0"
 	testPort := "8080"
 
-	return CreatePointToPoint(testName, testIP, testPort, nil)
+	return CreatePointToPoint(testName, testIP, testPort, nil, nil)
 }
@@ -0,0 +1,31 @@
+package protocol
+
+import (
+	"errors"
+)
+
+// Status is the package a space sends in reply to a message, before responding to the operation itself.
+// It denotes whether the space accepted the operation, and the reason if it refused it.
+type Status struct {
+	Accepted bool
+	Reason   string
+}
+
+// CreateAcceptance will create the status of an accepted operation.
+func CreateAcceptance() Status {
+	return Status{Accepted: true}
+}
+
+// CreateRefusal will create the status of an operation refused for reason.
+func CreateRefusal(reason string) Status {
+	return Status{Accepted: false, Reason: reason}
+}
+
+// GetError will return an error describing why the operation was refused, and nil if it was accepted.
+func (status *Status) GetError() (err error) {
+	if !status.Accepted {
+		err = errors.New(status.Reason)
+	}
+
+	return err
+}
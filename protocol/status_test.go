@@ -0,0 +1,20 @@
+package protocol
+
+import (
+	"testing"
+)
+
+// Test to see if Status reports refusals as errors.
+func TestStatusGetError(t *testing.T) {
+	// Setup
+	testAcceptance := CreateAcceptance()
+	testRefusal := CreateRefusal("Action is denied")
+
+	if err := testAcceptance.GetError(); err != nil {
+		t.Errorf("GetError() on status: %+v gave %v, should be nil", testAcceptance, err)
+	}
+
+	if err := testRefusal.GetError(); err == nil || err.Error() != "Action is denied" {
+		t.Errorf("GetError() on status: %+v gave %v, should be %s", testRefusal, err, "Action is denied")
+	}
+}
@@ -13,6 +13,7 @@ type WaitingClient struct {
 	template     container.Template      // Template that the waiting client is using to search for a tuple.
 	responseChan chan<- *container.Tuple // Channel where the response can be send through.
 	operation    string                  // String that will denote the type of operation the client is trying to carry out.
+	count        int                     // Number of matching tuples the client is still waiting for.
 }
 
 // CreateWaitingClient will create the waiting client with the template that
@@ -27,7 +28,25 @@ func CreateWaitingClient(temp container.Template, tupleChan chan<- *container.Tu
 		o = QueryRequest
 	}
 
-	waitingClient := WaitingClient{template: temp, responseChan: tupleChan, operation: o}
+	waitingClient := WaitingClient{template: temp, responseChan: tupleChan, operation: o, count: 1}
+	return waitingClient
+}
+
+// CreateCountingClient will create a waiting client for an aggregation which
+// waits until count more tuples matching the template have been placed.
+// The remove value will be used to determine if the client performed a GetAggN
+// or QueryAggN operation.
+// Rather than a tuple, nil is send to the response channel once enough tuples
+// have arrived, after which the client is expected to retry the aggregation.
+func CreateCountingClient(temp container.Template, tupleChan chan<- *container.Tuple, remove bool, count int) WaitingClient {
+	var o string
+	if remove {
+		o = GetAggNRequest
+	} else {
+		o = QueryAggNRequest
+	}
+
+	waitingClient := WaitingClient{template: temp, responseChan: tupleChan, operation: o, count: count}
 	return waitingClient
 }
 
@@ -50,3 +69,23 @@ func (waitingClient *WaitingClient) GetResponseChan() chan<- *container.Tuple {
 func (waitingClient *WaitingClient) GetOperation() string {
 	return waitingClient.operation
 }
+
+// GetCount will return the number of matching tuples the waiting client is still waiting for.
+func (waitingClient *WaitingClient) GetCount() int {
+	return waitingClient.count
+}
+
+// IsCounting will return true if the waiting client is waiting for a number of tuples rather than a single tuple, and false otherwise.
+func (waitingClient *WaitingClient) IsCounting() bool {
+	return waitingClient.operation == GetAggNRequest || waitingClient.operation == QueryAggNRequest
+}
+
+// Decrement will count a matching tuple for the waiting client.
+// Decrement returns true if the waiting client is no longer waiting for any tuples, and false otherwise.
+func (waitingClient *WaitingClient) Decrement() bool {
+	if waitingClient.count > 0 {
+		waitingClient.count--
+	}
+
+	return waitingClient.count == 0
+}
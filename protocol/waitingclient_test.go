@@ -18,7 +18,7 @@ func TestCreateWaitingClientGet(t *testing.T) {
 	actualFields[0] = "Field 1"
 	actualChan := make(chan<- *Tuple)
 	actualOperation := GetRequest
-	actualWaitingClient := WaitingClient{NewTemplate(actualFields), actualChan, actualOperation}
+	actualWaitingClient := WaitingClient{NewTemplate(actualFields), actualChan, actualOperation, 1}
 
 	// Test that the two templates are equal.
 	waitingClientsEqual := true
@@ -108,6 +108,25 @@ func TestWaitingClientGetOperation(t *testing.T) {
 	}
 }
 
+// Test to see if a counting WaitingClient counts down the tuples it waits for.
+func TestCountingClientDecrement(t *testing.T) {
+	// Setup
+	testChan := make(chan<- *Tuple)
+	testWaitingClient := CreateCountingClient(NewTemplate("Field 1"), testChan, false, 2)
+
+	if !testWaitingClient.IsCounting() || testWaitingClient.GetOperation() != QueryAggNRequest {
+		t.Errorf("CreateCountingClient() gave %+v, should be a counting client performing %s", testWaitingClient, QueryAggNRequest)
+	}
+
+	if testWaitingClient.Decrement() {
+		t.Errorf("Decrement() on waitingClient: %+v gave true, should be false", testWaitingClient)
+	}
+
+	if !testWaitingClient.Decrement() || testWaitingClient.GetCount() != 0 {
+		t.Errorf("Decrement() on waitingClient: %+v gave false, should be true", testWaitingClient)
+	}
+}
+
 func createTestWaitingClient() WaitingClient {
 	testFields := make([]interface{}, 1)
 	testFields[0] = "Field 1"
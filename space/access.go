@@ -0,0 +1,197 @@
+package space
+
+import (
+	"fmt"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+	"github.com/pspaces/gospace/protocol"
+)
+
+// requestOperations maps the operations of messages to the names of the operations policies refer to.
+var requestOperations = make(map[string]string)
+
+func init() {
+	var spc Space
+
+	registerOperation(protocol.PutRequest, "Put", spc.Put)
+	registerOperation(protocol.PutPRequest, "PutP", spc.PutP)
+	registerOperation(protocol.PutAggRequest, "PutAgg", spc.PutAgg)
+	registerOperation(protocol.GetRequest, "Get", spc.Get)
+	registerOperation(protocol.GetPRequest, "GetP", spc.GetP)
+	registerOperation(protocol.GetAllRequest, "GetAll", spc.GetAll)
+	registerOperation(protocol.GetAggRequest, "GetAgg", spc.GetAgg)
+	registerOperation(protocol.GetAggNRequest, "GetAggN", spc.GetAggN)
+	registerOperation(protocol.QueryRequest, "Query", spc.Query)
+	registerOperation(protocol.QueryPRequest, "QueryP", spc.QueryP)
+	registerOperation(protocol.QueryAllRequest, "QueryAll", spc.QueryAll)
+	registerOperation(protocol.QueryAggRequest, "QueryAgg", spc.QueryAgg)
+	registerOperation(protocol.QueryAggNRequest, "QueryAggN", spc.QueryAggN)
+	registerOperation(protocol.SizeRequest, "Size", spc.Size)
+	registerOperation(protocol.EvalRequest, "Eval", spc.Eval)
+	registerOperation(protocol.SetPolicyRequest, "SetPolicy", spc.SetPolicy)
+	registerOperation(protocol.UpdatePolicyRequest, "UpdatePolicy", spc.UpdatePolicy)
+	registerOperation(protocol.ExplainPolicyRequest, "ExplainPolicy", spc.ExplainPolicy)
+}
+
+// registerOperation makes the method fun of spaces available to policy files under name,
+// and lets policies refer to messages with operation request by that method.
+func registerOperation(request string, name string, fun interface{}) {
+	requestOperations[request] = name
+	policy.RegisterOperation(name, fun)
+}
+
+// operationName returns the name of the operation of messages with operation operation, or operation itself if it is unknown.
+func operationName(operation string) (name string) {
+	name, known := requestOperations[operation]
+	if !known {
+		name = operation
+	}
+
+	return name
+}
+
+// operationAction returns the action a which policies use to refer to the operation with message body body.
+// operationAction returns nil if the operation is unknown.
+func operationAction(operation string, body interface{}) (a *policy.Action) {
+	name, known := requestOperations[operation]
+
+	if !known {
+		return nil
+	}
+
+	fun := policy.LookupOperation(name)
+
+	var params []interface{}
+	switch t := body.(type) {
+	case container.Tuple:
+		params = t.Fields()
+	case container.Template:
+		params = t.Fields()
+	}
+
+	// Explanations are permitted by the name of the operation explained, regardless of its template.
+	if operation == protocol.ExplainPolicyRequest && len(params) > 1 {
+		params = params[:1]
+	}
+
+	a = policy.NewAction(fun, params...)
+
+	return a
+}
+
+// adminOperations contains the operations of messages which are refused unless a policy permits them explicitly.
+var adminOperations = map[string]bool{
+	protocol.SetPolicyRequest:     true,
+	protocol.UpdatePolicyRequest:  true,
+	protocol.ExplainPolicyRequest: true,
+}
+
+// policyOperations contains the operations of messages changing the policy, which only administrators may perform.
+var policyOperations = map[string]bool{
+	protocol.SetPolicyRequest:    true,
+	protocol.UpdatePolicyRequest: true,
+}
+
+// isAdminOperation returns true if policies refer to an operation refused unless a policy permits it explicitly by name, and false otherwise.
+func isAdminOperation(name string) (b bool) {
+	for operation := range adminOperations {
+		if requestOperations[operation] == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errNotPermitted returns the error of an operation named name which is refused since no policy permits it explicitly.
+func errNotPermitted(name string) (err error) {
+	err = fmt.Errorf("No policy permits %s", name)
+	return err
+}
+
+// authorize finds the aggregation policy ap of tuple space ts which applies to the operation of message,
+// and checks that it permits the operation to the principal p which sent message.
+// authorize returns a nil policy if no aggregation policy applies, and an error err if the operation is refused.
+// The version of the policy used is returned as well.
+func (ts *TupleSpace) authorize(message *protocol.Message, p auth.Principal) (ap *policy.Aggregation, version int, err error) {
+	cp, version := ts.currentPolicy()
+
+	operation := message.GetOperation()
+
+	// Introspection is reserved to administrators, regardless of the policy.
+	if _, inspects := introspectionOperations[operation]; inspects {
+		err = ts.checkAdmin(p)
+		return ap, version, err
+	}
+
+	a := operationAction(operation, message.GetBody())
+
+	if cp != nil && a != nil {
+		ap, err = cp.Authorize(a, p.Labels)
+	}
+
+	if err == nil && ap == nil && adminOperations[operation] {
+		err = errNotPermitted(requestOperations[operation])
+	}
+
+	// Changing the policy is reserved to administrators, besides being permitted by the policy.
+	if err == nil && policyOperations[operation] {
+		err = ts.checkAdmin(p)
+	}
+
+	return ap, version, err
+}
+
+// transformTuple applies the transformation trans to tuple t and returns the transformed tuple tt.
+// transformTuple returns an empty tuple if the transformation fails, such that no untransformed fields are disclosed.
+// The transformed tuple never shares its fields with t, which may be handed to several waiting clients.
+func transformTuple(trans *policy.Transformation, t container.Tuple) (tt container.Tuple) {
+	if trans == nil {
+		return container.NewTuple(t.Fields()...)
+	}
+
+	val, err := trans.Apply(t.Fields()...)
+
+	if err == nil {
+		switch v := val.(type) {
+		case container.Intertuple:
+			tt = container.NewTuple(v.Fields()...)
+		case container.Template:
+			tt = container.NewTuple(v.Fields()...)
+		default:
+			tt = container.NewTuple()
+		}
+	} else {
+		tt = container.NewTuple()
+	}
+
+	return tt
+}
+
+// tupleTransform rewrites a tuple placed by an ordinary operation given an aggregation policy ap.
+func tupleTransform(ap *policy.Aggregation, t container.Tuple) (tt container.Tuple) {
+	var trans *policy.Transformation
+	if ap != nil {
+		trs := ap.AggRule.Transformations()
+		trans = trs.Template()
+	}
+
+	tt = transformTuple(trans, t)
+
+	return tt
+}
+
+// retrievalTransform rewrites a tuple retrieved by an ordinary operation given an aggregation policy ap.
+func retrievalTransform(ap *policy.Aggregation, t container.Tuple) (tt container.Tuple) {
+	var trans *policy.Transformation
+	if ap != nil {
+		trs := ap.AggRule.Transformations()
+		trans = trs.Result()
+	}
+
+	tt = transformTuple(trans, t)
+
+	return tt
+}
@@ -0,0 +1,158 @@
+package space
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+// accessTestRedact replaces the value of a tuple by its zero value.
+func accessTestRedact(i interface{}) (it container.Intertuple) {
+	tf := i.([]interface{})
+
+	t := container.NewTuple(tf[0], 0)
+	it = &t
+
+	return it
+}
+
+// accessTestRaise increases the value of a tuple by one.
+func accessTestRaise(i interface{}) (it container.Intertuple) {
+	tf := i.([]interface{})
+
+	t := container.NewTuple(tf[0], tf[1].(int)+1)
+	it = &t
+
+	return it
+}
+
+func TestAccessPolicy(t *testing.T) {
+	// Setup
+	var spc Space
+	var x int
+	var s string
+
+	hr := container.NewLabel("hr")
+
+	salary := container.NewTemplate("salary", &x)
+
+	get := policy.NewAction(spc.Get, salary.Fields()...)
+	query := policy.NewAction(spc.Query, salary.Fields()...)
+	put := policy.NewAction(spc.Put, "secret", "launch codes")
+
+	redact := policy.NewTransformation(accessTestRedact)
+	trs := policy.NewTransformations(nil, nil, &redact)
+
+	cp := policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("salary-get"), policy.NewAccessRule(*get, policy.Allow, hr)),
+		policy.NewAggregation(container.NewLabel("salary-query"), policy.NewAggregationRule(*query, *trs)),
+		policy.NewAggregation(container.NewLabel("secret-put"), policy.NewAccessRule(*put, policy.Deny)),
+	)
+
+	spc = NewSpace("tcp://localhost:31671/access", cp)
+
+	_, err := spc.Put("salary", 5000)
+	if err != nil {
+		t.Errorf("Put() gave %v, should be nil", err)
+	}
+
+	_, err = spc.Put("secret", "launch codes")
+	if err == nil {
+		t.Errorf("Put() gave nil for a denied tuple, should be an error")
+	}
+
+	tp, err := spc.Query("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 0)) {
+		t.Errorf("Query() gave %v and %v, should be %v", tp, err, container.NewTuple("salary", 0))
+	}
+
+	_, err = spc.Get("salary", &x)
+	if err == nil {
+		t.Errorf("Get() gave nil for a caller without label %s, should be an error", hr.ID())
+	}
+
+	hrspc := spc.WithLabels(container.NewLabels(hr))
+
+	tp, err = hrspc.Get("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 5000)) {
+		t.Errorf("Get() gave %v and %v for a caller with label %s, should be %v", tp, err, hr.ID(), container.NewTuple("salary", 5000))
+	}
+
+	// Operations without a policy are unaffected.
+	spc.Put("name", "alice")
+	tp, err = spc.Get("name", &s)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("name", "alice")) {
+		t.Errorf("Get() gave %v and %v, should be %v", tp, err, container.NewTuple("name", "alice"))
+	}
+
+	// Waiting clients receiving the same tuple have it transformed once each, rather than once per waiting client.
+	raise := policy.NewTransformation(accessTestRaise)
+	raised := policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("salary-raise"), policy.NewAggregationRule(*query, *policy.NewTransformations(nil, nil, &raise))),
+	)
+
+	rspc := NewSpace("tcp://localhost:31732/raise", raised)
+
+	results := make(chan container.Tuple)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var v int
+			tp, _ := rspc.Query("salary", &v)
+			results <- tp
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	rspc.Put("salary", 7000)
+
+	for i := 0; i < 2; i++ {
+		if tp := <-results; !reflect.DeepEqual(tp, container.NewTuple("salary", 7001)) {
+			t.Errorf("Query() gave %v to a waiting client, should be %v", tp, container.NewTuple("salary", 7001))
+		}
+	}
+}
+
+func TestPolicyFile(t *testing.T) {
+	// Setup
+	data := `version: 1
+policies:
+  - label: salary-get
+    action: Get
+    template:
+      - salary
+      - type: int
+    require:
+      - hr
+`
+
+	cp, err := policy.Load([]byte(data))
+	if err != nil {
+		t.Fatalf("Load() gave %v, should be nil", err)
+	}
+
+	spc := NewSpace("tcp://localhost:31672/access", cp)
+
+	var x int
+
+	spc.Put("salary", 5000)
+
+	_, err = spc.Get("salary", &x)
+	if err == nil {
+		t.Errorf("Get() gave nil for a caller without label hr, should be an error")
+	}
+
+	hrspc := spc.WithLabels(container.NewLabels(container.NewLabel("hr")))
+
+	tp, err := hrspc.Get("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 5000)) {
+		t.Errorf("Get() gave %v and %v for a caller with label hr, should be %v", tp, err, container.NewTuple("salary", 5000))
+	}
+
+	out, err := policy.Marshal(cp)
+	if err != nil || string(out) != data {
+		t.Errorf("Marshal() gave %v and\n%s\nshould be\n%s", err, out, data)
+	}
+}
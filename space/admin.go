@@ -0,0 +1,375 @@
+package space
+
+import (
+	"encoding/gob"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+	"github.com/pspaces/gospace/policy"
+	"github.com/pspaces/gospace/protocol"
+)
+
+// TuplePage is a page of the tuples stored in a space, as inspected by an administrator.
+type TuplePage struct {
+	Tuples []container.Tuple // Tuples of the page, in the order they were placed.
+	Offset int               // Index of the first tuple of the page among the matching tuples.
+	Total  int               // Number of tuples matching the template, only counted for pages not inspected after a cursor.
+	Next   TupleCursor       // Cursor after the last tuple of the page, from which the following page can be inspected.
+}
+
+// TupleCursor is a position among the tuples stored in a space, in the order they were placed.
+// Unlike offsets, cursors are not moved by tuples being retrieved, so that paging with them neither skips nor repeats tuples.
+// The zero cursor is the position before all tuples.
+type TupleCursor uint64
+
+// WaitingClientInfo describes an operation blocked until matching tuples are placed in a space.
+type WaitingClientInfo struct {
+	Operation string             // Name of the operation, e.g. Get.
+	Template  container.Template // Template of the operation.
+	Count     int                // Number of matching tuples the operation is still waiting for.
+	Age       time.Duration      // Time the operation has been waiting.
+}
+
+// ConnectionInfo describes a connection from a peer being handled by a space.
+type ConnectionInfo struct {
+	Remote    string        // Address of the peer.
+	Principal string        // Name of the principal of the peer, or empty if it has not been authenticated yet.
+	Operation string        // Name of the operation requested by the peer, or empty if it has not been received yet.
+	Age       time.Duration // Time since the connection was accepted.
+}
+
+// PolicyInfo describes the policy governing a space.
+type PolicyInfo struct {
+	Version  int    // Version of the policy.
+	Document string // Policy in the format of policy files, its parts in that format if it is composite, or empty if the space has no policy.
+}
+
+// introspectionOperations contains the operations of messages which are refused unless the peer is an authenticated administrator.
+var introspectionOperations = map[string]string{
+	protocol.InspectTuplesRequest:         "InspectTuples",
+	protocol.InspectWaitingClientsRequest: "InspectWaitingClients",
+	protocol.InspectConnectionsRequest:    "InspectConnections",
+	protocol.InspectPolicyRequest:         "InspectPolicy",
+	protocol.InspectFunctionsRequest:      "InspectFunctions",
+}
+
+func init() {
+	for request, name := range introspectionOperations {
+		requestOperations[request] = name
+	}
+
+	gob.Register(TupleCursor(0))
+}
+
+// newSnapshot returns a pointer v to an empty snapshot of the type taken by the introspection operation of messages with operation operation,
+// or nil if the operation is unknown.
+func newSnapshot(operation string) (v interface{}) {
+	switch operation {
+	case protocol.InspectTuplesRequest:
+		v = new(TuplePage)
+	case protocol.InspectWaitingClientsRequest:
+		v = new([]WaitingClientInfo)
+	case protocol.InspectConnectionsRequest:
+		v = new([]ConnectionInfo)
+	case protocol.InspectPolicyRequest:
+		v = new(PolicyInfo)
+	case protocol.InspectFunctionsRequest:
+		v = new([]string)
+	}
+
+	return v
+}
+
+// peer is a connection from a peer being handled by a tuple space.
+type peer struct {
+	remote    string
+	principal string
+	operation string
+	since     time.Time
+}
+
+// setAdmins makes the principals named names the administrators of tuple space ts, replacing any previous administrators.
+func (ts *TupleSpace) setAdmins(names ...string) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.admins = make(map[string]bool)
+	for _, name := range names {
+		g.admins[name] = true
+	}
+}
+
+// checkAdmin returns an error err unless principal p is an authenticated administrator of tuple space ts.
+func (ts *TupleSpace) checkAdmin(p auth.Principal) (err error) {
+	g := ts.guard
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if p.IsAnonymous() || !g.admins[p.Name] {
+		err = fmt.Errorf("principal %s is not an administrator of the space", p.Name)
+	}
+
+	return err
+}
+
+// trackConnection counts connection conn from a peer as being handled by tuple space ts.
+// trackConnection returns the peer pr describing the connection, and a function done which must be called once conn has been handled.
+func (ts *TupleSpace) trackConnection(conn net.Conn) (pr *peer, done func()) {
+	g := ts.guard
+
+	pr = &peer{remote: conn.RemoteAddr().String(), since: time.Now()}
+
+	g.mu.Lock()
+	g.peers[pr] = true
+	g.mu.Unlock()
+
+	done = func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		delete(g.peers, pr)
+	}
+
+	return pr, done
+}
+
+// describeConnection records that the peer pr is the principal named principal requesting the operation of messages with operation operation.
+func (ts *TupleSpace) describeConnection(pr *peer, principal string, operation string) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pr.principal = principal
+	pr.operation = operationName(operation)
+}
+
+// markWaiting records when the waiting client with response channel response started waiting, unless it is already waiting.
+// The caller must hold the lock on waitingClients[].
+func (ts *TupleSpace) markWaiting(response chan<- *container.Tuple) {
+	if _, waiting := ts.waitingSince[response]; !waiting {
+		ts.waitingSince[response] = time.Now()
+	}
+}
+
+// unmarkWaiting forgets when the waiting client with response channel response started waiting, once it has been served.
+func (ts *TupleSpace) unmarkWaiting(response chan<- *container.Tuple) {
+	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	delete(ts.waitingSince, response)
+}
+
+// inspectTuples returns the page of at most limit tuples of tuple space ts matching template temp, starting at the match with index offset
+// among the tuples placed after cursor after. All tuples are inspected if temp has no fields, and all matches from offset if limit is less than 1.
+// Only the tuples of the page are copied, and the matching tuples are only counted if after is the zero cursor.
+func (ts *TupleSpace) inspectTuples(offset int, after TupleCursor, limit int, temp container.Template) (page TuplePage) {
+	if offset < 0 {
+		offset = 0
+	}
+
+	page = TuplePage{Tuples: []container.Tuple{}, Offset: offset, Next: after}
+	counting := after == 0
+
+	ts.muTuples.RLock()
+
+	// Tuples are kept in the order they were placed, such that those placed after the cursor follow the first of them.
+	start := sort.Search(len(ts.seqs), func(i int) bool { return ts.seqs[i] > uint64(after) })
+
+	matches := 0
+	for i := start; i < len(ts.tuples); i++ {
+		full := limit > 0 && len(page.Tuples) >= limit
+		if full && !counting {
+			break
+		}
+
+		if temp.Length() > 0 && !ts.tuples[i].Match(temp) {
+			continue
+		}
+
+		if matches >= offset && !full {
+			fc := make([]interface{}, ts.tuples[i].Length())
+			copy(fc, ts.tuples[i].Fields())
+			page.Tuples = append(page.Tuples, container.NewTuple(fc...))
+			page.Next = TupleCursor(ts.seqs[i])
+		}
+
+		matches++
+	}
+
+	ts.muTuples.RUnlock()
+
+	if counting {
+		page.Total = matches
+	}
+
+	for i := range page.Tuples {
+		funcEncode(ts.funReg, &page.Tuples[i])
+	}
+
+	return page
+}
+
+// inspectWaitingClients returns the operations waiting for tuples in tuple space ts, the longest waiting first.
+func (ts *TupleSpace) inspectWaitingClients() (waiting []WaitingClientInfo) {
+	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	now := time.Now()
+
+	waiting = make([]WaitingClientInfo, 0, len(ts.waitingClients))
+	for _, wc := range ts.waitingClients {
+		tp := wc.GetTemplate()
+		funcEncode(ts.funReg, &tp)
+
+		var age time.Duration
+		if since, known := ts.waitingSince[wc.GetResponseChan()]; known {
+			age = now.Sub(since)
+		}
+
+		waiting = append(waiting, WaitingClientInfo{Operation: operationName(wc.GetOperation()), Template: tp, Count: wc.GetCount(), Age: age})
+	}
+
+	sort.SliceStable(waiting, func(i, j int) bool { return waiting[i].Age > waiting[j].Age })
+
+	return waiting
+}
+
+// inspectConnections returns the connections from peers being handled by tuple space ts, the oldest first.
+func (ts *TupleSpace) inspectConnections() (conns []ConnectionInfo) {
+	g := ts.guard
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	now := time.Now()
+
+	conns = make([]ConnectionInfo, 0, len(g.peers))
+	for pr := range g.peers {
+		conns = append(conns, ConnectionInfo{Remote: pr.remote, Principal: pr.principal, Operation: pr.operation, Age: now.Sub(pr.since)})
+	}
+
+	sort.Slice(conns, func(i, j int) bool { return conns[i].Age > conns[j].Age })
+
+	return conns
+}
+
+// inspectPolicy returns the policy governing tuple space ts, or an error err if it can not be described.
+func (ts *TupleSpace) inspectPolicy() (pi PolicyInfo, err error) {
+	cp, version := ts.currentPolicy()
+
+	pi.Version = version
+
+	if cp != nil {
+		pi.Document, err = policyDocument(cp, ts.funReg)
+	}
+
+	return pi, err
+}
+
+// policyDocument returns the document doc describing policy cp in the format of policy files, referring to functions through registry reg.
+// Composite policies can not be written to policy files, so their parts are written as consecutive documents, headed by how they are composed.
+func policyDocument(cp *policy.Composable, reg *function.Registry) (doc string, err error) {
+	if !cp.IsComposite() {
+		data, err := policy.Marshal(cp, reg)
+		return string(data), err
+	}
+
+	parts := make([]string, 0, len(cp.Parts))
+	for i, part := range cp.Parts {
+		pd, err := policyDocument(part, reg)
+		if err != nil {
+			return doc, err
+		}
+
+		parts = append(parts, fmt.Sprintf("# Part %d of %d\n%s", i+1, len(cp.Parts), pd))
+	}
+
+	doc = fmt.Sprintf("# Composite %s policy, the earlier parts taking precedence\n%s", cp.Op, strings.Join(parts, "---\n"))
+
+	return doc, nil
+}
+
+// inspectFunctions returns the namespaces of the functions registered at tuple space ts.
+func (ts *TupleSpace) inspectFunctions() (names []string) {
+	names = []string{}
+
+	if ts.funReg != nil {
+		for _, ns := range ts.funReg.Namespaces() {
+			names = append(names, string(ns))
+		}
+	}
+
+	return names
+}
+
+// inspect performs the introspection operation of messages with operation operation and body temp in tuple space ts.
+// inspect returns the snapshot v of the space, or an error err if it could not be taken.
+func (ts *TupleSpace) inspect(operation string, temp container.Template) (v interface{}, err error) {
+	switch operation {
+	case protocol.InspectTuplesRequest:
+		offset, limit := 0, 0
+		var after TupleCursor
+		fields := temp.Fields()
+
+		// Pages start at an offset or after a cursor, told apart by their type.
+		if len(fields) >= 2 {
+			switch start := fields[0].(type) {
+			case int:
+				offset = start
+			case TupleCursor:
+				after = start
+			}
+
+			limit, _ = fields[1].(int)
+			fields = fields[2:]
+		}
+
+		v = ts.inspectTuples(offset, after, limit, container.NewTemplate(fields...))
+	case protocol.InspectWaitingClientsRequest:
+		v = ts.inspectWaitingClients()
+	case protocol.InspectConnectionsRequest:
+		v = ts.inspectConnections()
+	case protocol.InspectPolicyRequest:
+		v, err = ts.inspectPolicy()
+	case protocol.InspectFunctionsRequest:
+		v = ts.inspectFunctions()
+	default:
+		err = fmt.Errorf("unknown introspection operation %s", operation)
+	}
+
+	return v, err
+}
+
+// handleInspect sends a snapshot of tuple space ts to the administrator at the other end of connection conn,
+// as requested by the introspection operation of messages with operation operation and body temp.
+func (ts *TupleSpace) handleInspect(conn net.Conn, operation string, temp container.Template) {
+	defer ts.handleRecover(ts.handleInspect, conn)
+
+	v, err := ts.inspect(operation, temp)
+
+	status := protocol.CreateAcceptance()
+	if err != nil {
+		status = protocol.CreateRefusal(err.Error())
+	}
+
+	enc := encoderOf(conn)
+	errEnc := enc.Encode(status)
+
+	if errEnc == nil && err == nil {
+		errEnc = encodeResponse(conn, v)
+	}
+
+	if errEnc != nil {
+		panic("Could not encode snapshot")
+	}
+}
@@ -0,0 +1,161 @@
+package space
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+func TestInspect(t *testing.T) {
+	// Setup
+	var x int
+	var s string
+
+	spc := NewSpace("tcp://localhost:31724/admin")
+
+	spc.Put("job", 1)
+	spc.Put("job", 2)
+	spc.Put("note", "x")
+
+	go spc.Get("result", &s)
+	time.Sleep(50 * time.Millisecond)
+
+	authn := auth.NewMemory()
+	authn.Add(auth.NewPrincipal("root"), "root-token")
+	authn.Add(auth.NewPrincipal("alice"), "alice-token")
+
+	spc.SetAuthenticator(authn)
+	if !spc.SetAdmins("root") {
+		t.Fatalf("SetAdmins() gave false at the host, should be true")
+	}
+
+	remote := NewRemoteSpace("tcp://localhost:31724/admin")
+	root := remote.WithIdentity(auth.NewTokenIdentity("root", "root-token"))
+	alice := remote.WithIdentity(auth.NewTokenIdentity("alice", "alice-token"))
+
+	// Test that only authenticated administrators can inspect the space.
+	if _, err := remote.InspectTuples(0, 0); err == nil {
+		t.Errorf("InspectTuples() gave nil without credentials, should be an error")
+	}
+
+	if _, err := alice.InspectFunctions(); err == nil {
+		t.Errorf("InspectFunctions() gave nil for a principal which is not an administrator, should be an error")
+	}
+
+	// Test paging and filtering of the tuples.
+	page, err := root.InspectTuples(0, 0)
+	if err != nil || page.Total != 3 || len(page.Tuples) != 3 {
+		t.Errorf("InspectTuples(0, 0) gave %v and error %v, should give all 3 tuples", page, err)
+	}
+
+	page, err = root.InspectTuples(1, 1, "job", &x)
+	expected := TuplePage{Tuples: []container.Tuple{container.NewTuple("job", 2)}, Offset: 1, Total: 2, Next: 2}
+	if err != nil || !reflect.DeepEqual(page, expected) {
+		t.Errorf("InspectTuples(1, 1, \"job\", &x) gave %v and error %v, should be %v", page, err, expected)
+	}
+
+	page, err = spc.InspectTuples(5, 1)
+	if err != nil || page.Total != 3 || len(page.Tuples) != 0 {
+		t.Errorf("InspectTuples(5, 1) at the host gave %v and error %v, should be an empty page of 3 tuples", page, err)
+	}
+
+	page, err = spc.InspectTuples(1, math.MaxInt)
+	if err != nil || page.Total != 3 || len(page.Tuples) != 2 {
+		t.Errorf("InspectTuples(1, math.MaxInt) at the host gave %v and error %v, should give the last 2 tuples", page, err)
+	}
+
+	// Test the blocked operation and its connection.
+	waiting, err := root.InspectWaitingClients()
+	if err != nil || len(waiting) != 1 || waiting[0].Operation != "Get" || waiting[0].Count != 1 || waiting[0].Age <= 0 {
+		t.Errorf("InspectWaitingClients() gave %v and error %v, should give the blocked Get", waiting, err)
+	}
+
+	result := container.NewTemplate("result", &s)
+	if err == nil && len(waiting) == 1 && !reflect.DeepEqual(waiting[0].Template.Fields(), result.Fields()) {
+		t.Errorf("InspectWaitingClients() gave template %v, should be the template of the blocked Get", waiting[0].Template)
+	}
+
+	// Connections of finished operations may still be closing, but the oldest is the blocked Get and the newest the inspection itself.
+	conns, err := root.InspectConnections()
+	if n := len(conns); err != nil || n < 2 || conns[0].Operation != "Get" || conns[n-1].Principal != "root" || conns[n-1].Operation != "InspectConnections" {
+		t.Errorf("InspectConnections() gave %v and error %v, should give the blocked Get and the inspection itself", conns, err)
+	}
+
+	pi, err := root.InspectPolicy()
+	if err != nil || pi.Version != 0 || pi.Document != "" {
+		t.Errorf("InspectPolicy() gave %v and error %v, should give no policy", pi, err)
+	}
+
+	if _, err := root.InspectFunctions(); err != nil {
+		t.Errorf("InspectFunctions() gave error %v, should be nil", err)
+	}
+
+	// Test that composite policies are described by their parts.
+	job := container.NewTemplate("job", &x)
+	query := policy.NewAction(spc.Query, job.Fields()...)
+
+	first := policy.NewComposable(policy.NewAggregation(container.NewLabel("first"), policy.NewAccessRule(*query, policy.Allow)))
+	second := policy.NewComposable(policy.NewAggregation(container.NewLabel("second"), policy.NewAccessRule(*query, policy.Deny)))
+
+	if _, err := spc.SetPolicy(policy.Compose(policy.Override, first, second)); err != nil {
+		t.Fatalf("SetPolicy() gave error %v at the host, should be nil", err)
+	}
+
+	pi, err = root.InspectPolicy()
+	if err != nil || !strings.Contains(pi.Document, "override") || !strings.Contains(pi.Document, "label: first") || !strings.Contains(pi.Document, "label: second") {
+		t.Errorf("InspectPolicy() gave %v and error %v for a composite policy, should describe both parts", pi, err)
+	}
+}
+
+func TestInspectTuplesAfter(t *testing.T) {
+	// Setup
+	var x int
+
+	spc := NewSpace("tcp://localhost:31736/admin")
+
+	for i := 0; i < 10; i++ {
+		spc.Put("n", i)
+	}
+
+	numbers := func(page TuplePage) (ns []int) {
+		for _, tp := range page.Tuples {
+			ns = append(ns, tp.GetFieldAt(1).(int))
+		}
+
+		return ns
+	}
+
+	page, err := spc.InspectTuplesAfter(0, 4, "n", &x)
+	if ns := numbers(page); err != nil || !reflect.DeepEqual(ns, []int{0, 1, 2, 3}) || page.Total != 10 {
+		t.Fatalf("InspectTuplesAfter(0, 4) gave %v of %d and error %v, should be 0 to 3 of 10", ns, page.Total, err)
+	}
+
+	// Test that retrieving tuples, including the last of the page, neither skips nor repeats tuples of the following pages.
+	spc.GetP("n", 1)
+	spc.GetP("n", 3)
+	spc.GetP("n", 8)
+
+	page, err = spc.InspectTuplesAfter(page.Next, 4, "n", &x)
+	if ns := numbers(page); err != nil || !reflect.DeepEqual(ns, []int{4, 5, 6, 7}) {
+		t.Errorf("InspectTuplesAfter() gave %v and error %v after retrieving tuples, should be 4 to 7", ns, err)
+	}
+
+	spc.Put("n", 10)
+
+	page, err = spc.InspectTuplesAfter(page.Next, 4, "n", &x)
+	if ns := numbers(page); err != nil || !reflect.DeepEqual(ns, []int{9, 10}) {
+		t.Errorf("InspectTuplesAfter() gave %v and error %v for the last page, should be 9 and the tuple placed meanwhile", ns, err)
+	}
+
+	// Test that tuples are kept in the order they were placed.
+	page, err = spc.InspectTuples(0, 0)
+	if ns := numbers(page); err != nil || !reflect.DeepEqual(ns, []int{0, 2, 4, 5, 6, 7, 9, 10}) {
+		t.Errorf("InspectTuples(0, 0) gave %v and error %v, should be the remaining tuples in the order they were placed", ns, err)
+	}
+}
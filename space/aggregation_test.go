@@ -0,0 +1,122 @@
+package space
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/aggregation"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/protocol"
+)
+
+func TestLibraryAggregation(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31661/aggregation")
+
+	spc.Put("temperature", 21)
+	spc.Put("temperature", 18)
+	spc.Put("temperature", 24)
+
+	var s string
+	var x int
+
+	tp, err := spc.QueryAgg(aggregation.Sum, &s, &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("temperature", 63)) {
+		t.Errorf("QueryAgg() with sum gave %v and %v, should be %v", tp, err, container.NewTuple("temperature", 63))
+	}
+
+	tp, err = spc.QueryAgg(aggregation.Max, &s, &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("temperature", 24)) {
+		t.Errorf("QueryAgg() with max gave %v and %v, should be %v", tp, err, container.NewTuple("temperature", 24))
+	}
+
+	spc.Put("humidity", 40)
+
+	tp, err = spc.QueryAgg(aggregation.NewGroupBy(0, aggregation.Count), &s, &x)
+	expected := container.NewTuple(container.NewTuple("temperature", 3), container.NewTuple("humidity", 1))
+	if err != nil || !reflect.DeepEqual(tp, expected) {
+		t.Errorf("QueryAgg() with group by gave %v and %v, should be %v", tp, err, expected)
+	}
+
+	tp, err = spc.GetAgg(aggregation.Count, &s, &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple(4)) {
+		t.Errorf("GetAgg() with count gave %v and %v, should be %v", tp, err, container.NewTuple(4))
+	}
+
+	if sz, _ := spc.Size(); sz != 0 {
+		t.Errorf("Size() gave %d after GetAgg(), should be %d", sz, 0)
+	}
+}
+
+func TestAggregationN(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31662/aggregation")
+
+	spc.Put("job", 1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		spc.Put("job", 2)
+		spc.Put("job", 3)
+	}()
+
+	var x int
+
+	tp, err := spc.QueryAggN(3, aggregation.Count, "job", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple(3)) {
+		t.Errorf("QueryAggN() gave %v and %v, should be %v", tp, err, container.NewTuple(3))
+	}
+
+	tp, err = spc.GetAggN(2, aggregation.Sum, "job", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("job", 3)) {
+		t.Errorf("GetAggN() gave %v and %v, should be %v", tp, err, container.NewTuple("job", 3))
+	}
+
+	if sz, _ := spc.Size(); sz != 1 {
+		t.Errorf("Size() gave %d after GetAggN(), should be %d", sz, 1)
+	}
+}
+
+func TestAggregationNHangup(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31739/aggregation")
+
+	spc.Put("job", 1)
+
+	var x int
+
+	ptp := *spc.p
+	tp := container.NewTemplate(2, aggregation.Sum, "job", &x)
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, _, err := establishConnection(ptp)
+	if err != nil {
+		t.Fatalf("establishConnection() gave %v, should be nil", err)
+	}
+
+	err = sendMessage(conn, ptp, protocol.GetAggNRequest, tp)
+	if err != nil {
+		t.Fatalf("sendMessage() gave %v, should be nil", err)
+	}
+
+	// Test
+	// The peer abandons the GetAggN() while it waits for a second tuple.
+	(*conn).Close()
+	time.Sleep(100 * time.Millisecond)
+
+	spc.Put("job", 2)
+	time.Sleep(100 * time.Millisecond)
+
+	if sz, _ := spc.Size(); sz != 2 {
+		t.Errorf("Size() gave %d after abandoning GetAggN(), should be %d", sz, 2)
+	}
+
+	spc.ts.muWaitingClients.Lock()
+	waiting := len(spc.ts.waitingClients)
+	spc.ts.muWaitingClients.Unlock()
+
+	if waiting != 0 {
+		t.Errorf("GetAggN() left %d waiting clients after the peer hung up, should leave none", waiting)
+	}
+}
@@ -0,0 +1,154 @@
+package space
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/tracing"
+)
+
+// guard contains the settings for authenticating, auditing, limiting, logging and tracing the peers of a tuple space.
+type guard struct {
+	mu     *sync.RWMutex      // Lock for the settings and the active operations.
+	authn  auth.Authenticator // Authenticator of peers, or nil if peers declare their own labels.
+	audit  *log.Logger        // Audit log of operations, or nil if operations are not audited.
+	logger logging.Logger     // Logger of the tuple space, or nil for the default logger.
+	tracer *tracing.Tracer    // Tracer of the operations of peers, or nil if they are not traced.
+	limits map[string]int     // Maximum number of concurrent operations per principal.
+	active map[string]int     // Number of concurrent operations per principal.
+	admins map[string]bool    // Names of the principals permitted to inspect the tuple space.
+	peers  map[*peer]bool     // Connections from peers being handled.
+}
+
+// newGuard creates a guard g which lets any peer perform any number of operations.
+func newGuard() (g *guard) {
+	g = &guard{mu: new(sync.RWMutex), limits: make(map[string]int), active: make(map[string]int), admins: make(map[string]bool), peers: make(map[*peer]bool)}
+	return g
+}
+
+// setAuthenticator sets the authenticator of the peers of tuple space ts.
+func (ts *TupleSpace) setAuthenticator(a auth.Authenticator) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.authn = a
+}
+
+// setAuditLog sets the audit log of tuple space ts.
+func (ts *TupleSpace) setAuditLog(l *log.Logger) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.audit = l
+}
+
+// setPrincipalLimit limits the number of concurrent operations of the principal named name in tuple space ts to n.
+// A limit n less than 1 removes the limit.
+func (ts *TupleSpace) setPrincipalLimit(name string, n int) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if n < 1 {
+		delete(g.limits, name)
+	} else {
+		g.limits[name] = n
+	}
+}
+
+// accept prepares connection conn as required by the authenticator of tuple space ts, e.g. by wrapping it in TLS.
+func (ts *TupleSpace) accept(conn net.Conn) (net.Conn, error) {
+	g := ts.guard
+
+	g.mu.RLock()
+	authn := g.authn
+	g.mu.RUnlock()
+
+	if authn == nil {
+		return conn, nil
+	}
+
+	return authn.Accept(conn)
+}
+
+// authenticate returns the principal p of the peer at the other end of connection conn presenting credentials creds.
+// If tuple space ts has no authenticator, the peer is anonymous and holds the labels lbls it declares itself.
+// Otherwise the declared labels are ignored, and p holds the labels given to it by the authenticator.
+func (ts *TupleSpace) authenticate(conn net.Conn, creds auth.Credentials, lbls container.Labels) (p auth.Principal, err error) {
+	g := ts.guard
+
+	g.mu.RLock()
+	authn := g.authn
+	g.mu.RUnlock()
+
+	if authn == nil {
+		p = auth.NewAnonymous(lbls)
+		return p, err
+	}
+
+	p, err = authn.Authenticate(conn, creds)
+
+	if err != nil {
+		p = auth.NewAnonymous(nil)
+	}
+
+	return p, err
+}
+
+// admit counts an operation of principal p in tuple space ts, unless p has reached its limit of concurrent operations.
+// admit returns a function release which must be called once the operation has finished.
+func (ts *TupleSpace) admit(p auth.Principal) (release func(), err error) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if n, limited := g.limits[p.Name]; limited && g.active[p.Name] >= n {
+		err = fmt.Errorf("principal %s has reached its limit of %d concurrent operations", p.Name, n)
+		return func() {}, err
+	}
+
+	g.active[p.Name]++
+
+	release = func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		g.active[p.Name]--
+		if g.active[p.Name] == 0 {
+			delete(g.active, p.Name)
+		}
+	}
+
+	return release, err
+}
+
+// auditOperation records the outcome err of the operation requested by principal p at the other end of connection conn,
+// which has been decided under the given version of the policy.
+func (ts *TupleSpace) auditOperation(conn net.Conn, p auth.Principal, operation string, version int, err error) {
+	g := ts.guard
+
+	g.mu.RLock()
+	audit := g.audit
+	g.mu.RUnlock()
+
+	if audit == nil {
+		return
+	}
+
+	if err == nil {
+		audit.Printf("%s from %s: %s accepted under policy version %d", p, conn.RemoteAddr(), operation, version)
+	} else {
+		audit.Printf("%s from %s: %s refused under policy version %d: %s", p, conn.RemoteAddr(), operation, version, err)
+	}
+}
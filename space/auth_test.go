@@ -0,0 +1,191 @@
+package space
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+	"github.com/pspaces/gospace/tracing"
+)
+
+func TestAuthentication(t *testing.T) {
+	// Setup
+	var spc Space
+	var x int
+
+	hr := container.NewLabel("hr")
+
+	salary := container.NewTemplate("salary", &x)
+	get := policy.NewAction(spc.Get, salary.Fields()...)
+
+	cp := policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("salary-get"), policy.NewAccessRule(*get, policy.Allow, hr)),
+	)
+
+	spc = NewSpace("tcp://localhost:31681/auth", cp)
+
+	authn := auth.NewMemory()
+	authn.Add(auth.NewPrincipal("alice", hr), "alice-token")
+	authn.Add(auth.NewPrincipal("bob"), "bob-token")
+
+	var buf bytes.Buffer
+	spc.SetAuditLog(log.New(&buf, "", 0))
+	spc.SetAuthenticator(authn)
+
+	_, err := spc.Put("salary", 5000)
+	if err == nil {
+		t.Errorf("Put() gave nil without credentials, should be an error")
+	}
+
+	alice := spc.WithIdentity(auth.NewTokenIdentity("alice", "alice-token"))
+	bob := spc.WithIdentity(auth.NewTokenIdentity("bob", "bob-token"))
+	mallory := spc.WithIdentity(auth.NewTokenIdentity("alice", "guess"))
+
+	_, err = mallory.Put("salary", 1)
+	if err == nil {
+		t.Errorf("Put() gave nil with a wrong token, should be an error")
+	}
+
+	_, err = bob.Put("salary", 5000)
+	if err != nil {
+		t.Errorf("Put() gave %v for an authenticated principal, should be nil", err)
+	}
+
+	// Declared labels are ignored once peers are authenticated.
+	bobhr := bob.WithLabels(container.NewLabels(hr))
+	_, err = bobhr.Get("salary", &x)
+	if err == nil {
+		t.Errorf("Get() gave nil for a principal without label %s, should be an error", hr.ID())
+	}
+
+	tp, err := alice.Get("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 5000)) {
+		t.Errorf("Get() gave %v and %v for a principal with label %s, should be %v", tp, err, hr.ID(), container.NewTuple("salary", 5000))
+	}
+
+	audit := buf.String()
+	if !strings.Contains(audit, "alice") || !strings.Contains(audit, "refused") {
+		t.Errorf("SetAuditLog() recorded %q, should record the principals and refusals", audit)
+	}
+}
+
+func TestPrincipalLimit(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31682/auth")
+
+	authn := auth.NewMemory()
+	authn.Add(auth.NewPrincipal("worker"), "worker-token")
+
+	spc.SetAuthenticator(authn)
+	spc.SetPrincipalLimit("worker", 1)
+
+	worker := spc.WithIdentity(auth.NewTokenIdentity("worker", "worker-token"))
+
+	var s string
+
+	done := make(chan bool)
+	go func() {
+		worker.Get("job", &s)
+		done <- true
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := worker.Size()
+	if err == nil {
+		t.Errorf("Size() gave nil for a principal at its limit, should be an error")
+	}
+
+	spc.SetPrincipalLimit("worker", 0)
+
+	_, err = worker.Put("job", "build")
+	if err != nil {
+		t.Errorf("Put() gave %v after removing the limit, should be nil", err)
+	}
+
+	<-done
+}
+
+// testCertificate creates a certificate named cn with organizational units ous, signed by parent with key signer.
+// testCertificate signs the certificate itself if parent is nil.
+func testCertificate(t *testing.T, cn string, ous []string, parent *x509.Certificate, signer *ecdsa.PrivateKey) (cert *x509.Certificate, key *ecdsa.PrivateKey, chain tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, OrganizationalUnit: ous},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{cn},
+	}
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent = tmpl
+		signer = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	return cert, key, chain
+}
+
+func TestTLSAuthentication(t *testing.T) {
+	// Setup
+	ca, caKey, _ := testCertificate(t, "ca", nil, nil, nil)
+	_, _, server := testCertificate(t, "space", nil, ca, caKey)
+	_, _, client := testCertificate(t, "carol", []string{"ops"}, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	spc := NewSpace("tcp://localhost:31737/tls")
+	spc.SetAuthenticator(auth.NewTLS(&tls.Config{Certificates: []tls.Certificate{server}, ClientCAs: pool}))
+
+	// Tracing wraps the connection, which must not hide its TLS state from the authenticator.
+	exp := tracing.NewInMemoryExporter()
+	if !spc.SetTracer(tracing.NewTracer(exp)) {
+		t.Fatalf("SetTracer() gave false, should be true")
+	}
+
+	carol := spc.WithIdentity(auth.NewTLSIdentity(&tls.Config{Certificates: []tls.Certificate{client}, RootCAs: pool, ServerName: "space"}))
+
+	// Test
+	_, err := carol.Put("shift", 1)
+	if err != nil {
+		t.Errorf("Put() gave %v for a principal presenting a certificate while tracing, should be nil", err)
+	}
+
+	if n := spc.ts.Size(); n != 1 {
+		t.Errorf("Put() stored %d tuples, should store 1", n)
+	}
+}
@@ -0,0 +1,53 @@
+package space
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pspaces/gospace/function"
+)
+
+func capabilitiesMissing(x int) int {
+	return x + 1
+}
+
+func TestMissingFunctionFails(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31651/capabilities")
+
+	// Let the space have its own registry without any functions.
+	fr := function.NewRegistry()
+	spc.ts.funReg = &fr
+
+	_, err := spc.Put("f", capabilitiesMissing)
+	if err == nil || !strings.Contains(err.Error(), "capabilitiesMissing") {
+		t.Errorf("Put() gave %v, should fail naming the missing function", err)
+	}
+
+	sz, _ := spc.Size()
+	if sz != 0 {
+		t.Errorf("Size() gave %d, should be %d", sz, 0)
+	}
+
+	fr.Register(capabilitiesMissing)
+
+	_, err = spc.Put("f", capabilitiesMissing)
+	if err != nil {
+		t.Errorf("Put() gave %v, should be nil once the function is registered", err)
+	}
+}
+
+func TestNamespaceStringIsNotDecoded(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31652/capabilities")
+
+	// Register the function and place its namespace as an ordinary string.
+	ns := function.GlobalRegistry.Encode(capabilitiesMissing).String()
+	spc.Put("name", ns)
+
+	var s string
+	tp, err := spc.Get("name", &s)
+	if err != nil || tp.GetFieldAt(1) != ns {
+		t.Errorf("Get() gave %v and %v, should be the string %s", tp, err, ns)
+	}
+}
@@ -0,0 +1,50 @@
+package space
+
+import (
+	"encoding/gob"
+	"net"
+)
+
+// codecConn is a connection carrying the encoder and decoder of the messages exchanged through it.
+// A decoder buffers what it reads ahead of the value it decodes, and an encoder only sends the types it has not sent before,
+// such that all messages exchanged through a connection must pass through the same encoder and decoder.
+type codecConn struct {
+	net.Conn
+	enc *gob.Encoder
+	dec *gob.Decoder
+}
+
+// newCodecConn creates a connection cc encoding and decoding the messages exchanged through connection conn.
+func newCodecConn(conn net.Conn) (cc *codecConn) {
+	cc = &codecConn{Conn: conn, enc: gob.NewEncoder(conn), dec: gob.NewDecoder(conn)}
+	return cc
+}
+
+// codecOf returns the connection cc carrying the encoder and decoder of connection conn, or nil if it carries none.
+func codecOf(conn net.Conn) (cc *codecConn) {
+	if tc, ok := conn.(*tracedConn); ok {
+		conn = tc.Conn
+	}
+
+	cc, _ = conn.(*codecConn)
+
+	return cc
+}
+
+// encoderOf returns the encoder enc of connection conn, or a new encoder if conn carries none.
+func encoderOf(conn net.Conn) (enc *gob.Encoder) {
+	if cc := codecOf(conn); cc != nil {
+		return cc.enc
+	}
+
+	return gob.NewEncoder(conn)
+}
+
+// decoderOf returns the decoder dec of connection conn, or a new decoder if conn carries none.
+func decoderOf(conn net.Conn) (dec *gob.Decoder) {
+	if cc := codecOf(conn); cc != nil {
+		return cc.dec
+	}
+
+	return gob.NewDecoder(conn)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/pspaces/gospace/container"
@@ -258,3 +259,130 @@ func BenchmarkRandomDisclosurePolicy(b *testing.B) {
 
 	return
 }
+
+// ComposeRaise raises the value of a tuple by 100.
+func ComposeRaise(i interface{}) (it container.Intertuple) {
+	tf := i.([]interface{})
+
+	t := container.NewTuple(tf[0], tf[1].(int)+100)
+	it = &t
+
+	return it
+}
+
+// ComposeDouble doubles the value of a tuple.
+func ComposeDouble(i interface{}) (it container.Intertuple) {
+	tf := i.([]interface{})
+
+	t := container.NewTuple(tf[0], tf[1].(int)*2)
+	it = &t
+
+	return it
+}
+
+// composeTestPolicies creates the parts of the composition tests.
+// The first part raises queried salaries and lets anyone get them, while the second part doubles queried salaries and only lets label hr get them.
+func composeTestPolicies() (first *policy.Composable, second *policy.Composable) {
+	var spc Space
+	var x int
+
+	salary := container.NewTemplate("salary", &x)
+	query := policy.NewAction(spc.Query, salary.Fields()...)
+	get := policy.NewAction(spc.Get, salary.Fields()...)
+
+	raise := policy.NewTransformation(ComposeRaise)
+	double := policy.NewTransformation(ComposeDouble)
+
+	first = policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("raise"), policy.NewAggregationRule(*query, *policy.NewTransformations(nil, nil, &raise))),
+		policy.NewAggregation(container.NewLabel("open"), policy.NewAccessRule(*get, policy.Allow)),
+	)
+
+	second = policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("double"), policy.NewAggregationRule(*query, *policy.NewTransformations(nil, nil, &double))),
+		policy.NewAggregation(container.NewLabel("restricted"), policy.NewAccessRule(*get, policy.Allow, container.NewLabel("hr"))),
+	)
+
+	return first, second
+}
+
+// composeTestOperator checks that a space governed by the parts of the composition tests composed with op
+// returns the salary queried and permits getting it to a caller without label hr as given by expected and permitted,
+// and that the policy is explained to apply the number of result transformations given by transformations.
+func composeTestOperator(t *testing.T, url string, op policy.Operator, expected int, permitted bool, transformations int) {
+	first, second := composeTestPolicies()
+
+	spc := NewSpace(url, policy.Compose(op, first, second))
+
+	var x int
+
+	spc.Put("salary", 5000)
+
+	tp, err := spc.Query("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", expected)) {
+		t.Errorf("Query() under %s composition gave %v and %v, should be %v", op, tp, err, container.NewTuple("salary", expected))
+	}
+
+	_, err = spc.Get("salary", &x)
+	if permitted != (err == nil) {
+		t.Errorf("Get() under %s composition gave %v, should be permitted: %t", op, err, permitted)
+	}
+
+	e, err := spc.ExplainPolicy("Query", "salary", &x)
+	if err != nil || e.Composition != op.String() || len(e.Parts) != 2 || len(e.Result) != transformations {
+		t.Errorf("ExplainPolicy() under %s composition gave %v and %v, should list %d result transformations", op, e, err, transformations)
+	}
+}
+
+func TestSequentialComposition(t *testing.T) {
+	composeTestOperator(t, "tcp://localhost:31701/compose", policy.Sequential, (5000+100)*2, false, 2)
+}
+
+func TestOverrideComposition(t *testing.T) {
+	composeTestOperator(t, "tcp://localhost:31702/compose", policy.Override, 5000+100, true, 1)
+}
+
+func TestIntersectionComposition(t *testing.T) {
+	composeTestOperator(t, "tcp://localhost:31703/compose", policy.Intersection, 5000+100, false, 1)
+}
+
+func TestMultiplePolicies(t *testing.T) {
+	// Setup
+	first, second := composeTestPolicies()
+
+	// Several policies given to a space are composed sequentially, with the first taking precedence.
+	spc := NewSpace("tcp://localhost:31704/compose", second, first)
+
+	var x int
+
+	spc.Put("salary", 5000)
+
+	tp, err := spc.Query("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 5000*2+100)) {
+		t.Errorf("Query() gave %v and %v, should be %v", tp, err, container.NewTuple("salary", 5000*2+100))
+	}
+
+	_, err = spc.UpdatePolicy(policy.NewComposable())
+	if err == nil {
+		t.Errorf("UpdatePolicy() gave nil for a composite policy, should be an error")
+	}
+
+	// Parts of a composite policy may themselves be composite.
+	nested := policy.Compose(policy.Override, policy.Compose(policy.Intersection, second, first), first)
+	v, err := spc.SetPolicy(nested)
+	if err != nil || v != 1 {
+		t.Errorf("SetPolicy() gave %d and %v, should be %d and nil", v, err, 1)
+	}
+
+	tp, err = spc.Query("salary", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("salary", 5000*2)) {
+		t.Errorf("Query() gave %v and %v, should be %v", tp, err, container.NewTuple("salary", 5000*2))
+	}
+
+	hrspc := spc.WithLabels(container.NewLabels(container.NewLabel("hr")))
+
+	_, err = hrspc.Get("salary", &x)
+	if err != nil {
+		t.Errorf("Get() gave %v for a caller with label hr, should be nil", err)
+	}
+}
@@ -0,0 +1,93 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// EvalError is the first field of the tuple placed in a space when an evaluation fails.
+// An error tuple has the form (EvalError, function, message) where function names the evaluated function.
+const EvalError = "gospace:eval:error"
+
+// errorType is the reflected type of the error interface.
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// evaluate calls function fun with arguments args and returns the resulting tuple t.
+// evaluate returns an error tuple if fun is not a function, can not be called with args, panics or returns a non-nil error as its last result.
+func evaluate(fun interface{}, args []interface{}) (t container.Tuple) {
+	name := fmt.Sprintf("%v", fun)
+
+	if fun == nil || !function.IsFunc(fun) {
+		return evalErrorTuple(name, errors.New("function is not registered at this space"))
+	}
+
+	name = function.Name(fun)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t = evalErrorTuple(name, fmt.Errorf("%v", r))
+		}
+	}()
+
+	fn := reflect.ValueOf(fun)
+	ft := fn.Type()
+
+	if (!ft.IsVariadic() && len(args) != ft.NumIn()) || (ft.IsVariadic() && len(args) < ft.NumIn()-1) {
+		return evalErrorTuple(name, fmt.Errorf("expected %d arguments but got %d", ft.NumIn(), len(args)))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var at reflect.Type
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			at = ft.In(ft.NumIn() - 1).Elem()
+		} else {
+			at = ft.In(i)
+		}
+
+		if arg == nil {
+			in[i] = reflect.Zero(at)
+		} else if reflect.TypeOf(arg).AssignableTo(at) {
+			in[i] = reflect.ValueOf(arg)
+		} else {
+			return evalErrorTuple(name, fmt.Errorf("argument %d has type %T but %s is expected", i, arg, at))
+		}
+	}
+
+	out := fn.Call(in)
+
+	// A trailing error result is reported instead of being placed in the tuple.
+	if len(out) > 0 && ft.Out(len(out)-1) == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return evalErrorTuple(name, err)
+		}
+
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 1 {
+		switch res := out[0].Interface().(type) {
+		case container.Tuple:
+			return res
+		case container.Intertuple:
+			return container.NewTuple(res.Fields()...)
+		}
+	}
+
+	fields := make([]interface{}, len(out))
+	for i := range out {
+		fields[i] = out[i].Interface()
+	}
+
+	return container.NewTuple(fields...)
+}
+
+// evalErrorTuple returns the error tuple t reporting that the evaluation of function name failed with error err.
+func evalErrorTuple(name string, err error) (t container.Tuple) {
+	t = container.NewTuple(EvalError, name, err.Error())
+	return t
+}
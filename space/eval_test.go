@@ -0,0 +1,63 @@
+package space
+
+import (
+	"errors"
+	"testing"
+)
+
+func evalSquare(x int) int {
+	return x * x
+}
+
+func evalDivide(x int, y int) (int, error) {
+	if y == 0 {
+		return 0, errors.New("division by zero")
+	}
+
+	return x / y, nil
+}
+
+func TestEval(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31641/eval")
+
+	_, err := spc.Eval(evalSquare, 7)
+	if err != nil {
+		t.Errorf("Eval() gave %v, should be nil", err)
+	}
+
+	var x int
+	tp, _ := spc.Get(&x)
+	if tp.GetFieldAt(0) != 49 {
+		t.Errorf("Get() gave %v, should be (%d)", tp, 49)
+	}
+
+	spc.Eval(evalDivide, 1, 0)
+
+	var name, msg string
+	tp, _ = spc.Get(EvalError, &name, &msg)
+	if tp.GetFieldAt(2) != "division by zero" {
+		t.Errorf("Get() gave %v, should be an error tuple reporting the division by zero", tp)
+	}
+
+	spc.Eval(evalSquare, "seven")
+
+	tp, _ = spc.Get(EvalError, &name, &msg)
+	if tp.Length() != 3 {
+		t.Errorf("Get() gave %v, should be an error tuple reporting the wrong argument", tp)
+	}
+}
+
+func TestEvalLocal(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31642/eval")
+
+	spc.EvalLocal(func(s string) (string, int) { return s, len(s) }, "gospace")
+
+	var s string
+	var n int
+	tp, _ := spc.Get(&s, &n)
+	if tp.GetFieldAt(0) != "gospace" || tp.GetFieldAt(1) != 7 {
+		t.Errorf("Get() gave %v, should be (%s, %d)", tp, "gospace", 7)
+	}
+}
@@ -0,0 +1,364 @@
+package space
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+)
+
+// ExportFormat names the format of exported spaces.
+const ExportFormat = "gospace/tuples"
+
+// ExportVersion is the version of the format of exported spaces.
+const ExportVersion = 1
+
+// exportPageSize is the number of tuples inspected at a time when a space is exported.
+const exportPageSize = 256
+
+// Exported spaces are written in JSON Lines: a header naming the format and its version, followed by one tuple per line.
+//
+//	{"format":"gospace/tuples","version":1}
+//	{"fields":[{"type":"string","value":"job"},{"type":"int","value":42},{"type":"float64","value":3.5}]}
+//	{"fields":[{"type":"labels","value":["hr"]},{"type":"string","value":"salary"},{"type":"func","value":"func://example/raise"}]}
+//
+// Every field is tagged with its type, which is one of:
+//   - nil, without a value.
+//   - bool and string, with their values.
+//   - int, int8, int16, int32, int64, uint, uint8, uint16, uint32 and uint64, with their values as numbers.
+//   - float32 and float64, with their values as numbers, or as the strings NaN, +Inf and -Inf.
+//   - tuple and list, with their fields tagged in the same way, for nested tuples and []interface{} values.
+//   - labels, with the identifiers of the labels, for container.Labels values such as the labels of labelled tuples.
+//   - func, with the namespace of the function, for functions and function references.
+//
+// Fields of other types can not be exported.
+
+// exportHeader is the first line of an exported space.
+type exportHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// exportRecord is a tuple of an exported space.
+type exportRecord struct {
+	Fields []exportField `json:"fields"`
+}
+
+// exportField is a field of a tuple of an exported space, tagged with its type.
+type exportField struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Export writes the tuples of space s matching template to w, in the format of exported spaces, and returns the number n of tuples written.
+// All tuples are written if no template is given. The tuples are read a page at a time, each after the last tuple read,
+// such that every tuple stored for the whole export is written once, while tuples placed or retrieved during it may be missed.
+// Exporting a remote space requires being an administrator of it.
+func (s *Space) Export(w io.Writer, template ...interface{}) (n int, e error) {
+	enc := json.NewEncoder(w)
+
+	e = enc.Encode(exportHeader{Format: ExportFormat, Version: ExportVersion})
+
+	var cursor TupleCursor
+
+	for e == nil {
+		var page TuplePage
+
+		page, e = s.InspectTuplesAfter(cursor, exportPageSize, template...)
+
+		for i := 0; e == nil && i < len(page.Tuples); i++ {
+			var rec exportRecord
+
+			rec.Fields, e = exportFields(page.Tuples[i].Flds)
+
+			if e == nil {
+				e = enc.Encode(rec)
+			}
+
+			if e == nil {
+				n++
+			}
+		}
+
+		if len(page.Tuples) < exportPageSize {
+			break
+		}
+
+		cursor = page.Next
+	}
+
+	return n, e
+}
+
+// Import places the tuples read from r, in the format of exported spaces, in space s, and returns the number n of tuples placed.
+// The tuples are read a line at a time, and are placed as by Put, such that the policies of the space apply to them,
+// and functions must be registered at the space under the namespaces they were exported with.
+// Import stops at the first line which can not be read or placed.
+func (s *Space) Import(r io.Reader) (n int, e error) {
+	br := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+
+		data = bytes.TrimSpace(data)
+
+		if line == 1 {
+			e = checkExportHeader(data)
+		} else if len(data) > 0 {
+			var fields []interface{}
+
+			fields, e = importRecord(data)
+
+			if e == nil {
+				_, e = s.Put(fields...)
+			}
+
+			if e == nil {
+				n++
+			}
+		}
+
+		if e != nil {
+			return n, fmt.Errorf("line %d: %w", line, e)
+		}
+
+		if err == io.EOF {
+			return n, nil
+		}
+	}
+}
+
+// checkExportHeader returns an error err unless data is the header of an exported space in a supported version.
+func checkExportHeader(data []byte) (err error) {
+	var hdr exportHeader
+
+	err = json.Unmarshal(data, &hdr)
+
+	if err == nil && hdr.Format != ExportFormat {
+		err = fmt.Errorf("format %q is not %q", hdr.Format, ExportFormat)
+	}
+
+	if err == nil && hdr.Version != ExportVersion {
+		err = fmt.Errorf("unsupported version %d, expected %d", hdr.Version, ExportVersion)
+	}
+
+	return err
+}
+
+// importRecord returns the fields of the exported tuple data.
+func importRecord(data []byte) (fields []interface{}, err error) {
+	var rec exportRecord
+
+	err = json.Unmarshal(data, &rec)
+
+	if err == nil && rec.Fields == nil {
+		err = fmt.Errorf("tuple has no fields")
+	}
+
+	if err == nil {
+		fields, err = importFields(rec.Fields)
+	}
+
+	return fields, err
+}
+
+// exportFields tags the fields of a tuple with their types.
+func exportFields(fields []interface{}) (efs []exportField, err error) {
+	efs = make([]exportField, len(fields))
+
+	for i := 0; err == nil && i < len(fields); i++ {
+		efs[i], err = exportValue(fields[i])
+	}
+
+	return efs, err
+}
+
+// exportValue tags the field with its type, or returns an error err if fields of its type can not be exported.
+func exportValue(field interface{}) (ef exportField, err error) {
+	var v interface{}
+
+	switch f := field.(type) {
+	case nil:
+		return exportField{Type: "nil"}, nil
+	case bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		ef.Type, v = reflect.TypeOf(f).String(), f
+	case float32:
+		ef.Type, v = "float32", exportFloat(float64(f), 32)
+	case float64:
+		ef.Type, v = "float64", exportFloat(f, 64)
+	case container.Tuple:
+		ef.Type = "tuple"
+		v, err = exportFields(f.Flds)
+	case []interface{}:
+		ef.Type = "list"
+		v, err = exportFields(f)
+	case container.Labels:
+		ids := f.Labelling()
+		sort.Strings(ids)
+		ef.Type, v = "labels", ids
+	case container.FuncRef:
+		ef.Type, v = "func", f.Namespace
+	default:
+		if !function.IsFunc(field) {
+			return ef, fmt.Errorf("fields of type %T can not be exported", field)
+		}
+
+		ef.Type, v = "func", container.RefOf(field).Namespace
+	}
+
+	if err == nil {
+		ef.Value, err = json.Marshal(v)
+	}
+
+	return ef, err
+}
+
+// exportFloat returns the floating point number f of size bits as a number, or as a string if JSON has no number for it.
+func exportFloat(f float64, bits int) (v interface{}) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, bits)
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bits))
+}
+
+// importFields returns the fields tagged with their types by efs.
+func importFields(efs []exportField) (fields []interface{}, err error) {
+	fields = make([]interface{}, len(efs))
+
+	for i := 0; err == nil && i < len(efs); i++ {
+		fields[i], err = importValue(efs[i])
+	}
+
+	return fields, err
+}
+
+// importValue returns the field tagged with its type by ef, or an error err if the type is unknown or the value invalid.
+func importValue(ef exportField) (field interface{}, err error) {
+	raw := ef.Value
+
+	switch ef.Type {
+	case "nil":
+	case "bool":
+		var b bool
+		err = json.Unmarshal(raw, &b)
+		field = b
+	case "string":
+		var str string
+		err = json.Unmarshal(raw, &str)
+		field = str
+	case "int", "int8", "int16", "int32", "int64":
+		var i int64
+		i, err = strconv.ParseInt(string(raw), 10, intBits(ef.Type, "int"))
+		field = convertNumber(i, ef.Type)
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		var u uint64
+		u, err = strconv.ParseUint(string(raw), 10, intBits(ef.Type, "uint"))
+		field = convertNumber(u, ef.Type)
+	case "float32", "float64":
+		text := string(raw)
+		if len(raw) > 0 && raw[0] == '"' {
+			err = json.Unmarshal(raw, &text)
+		}
+
+		var f float64
+		if err == nil {
+			f, err = strconv.ParseFloat(text, intBits(ef.Type, "float"))
+		}
+
+		field = convertNumber(f, ef.Type)
+	case "tuple", "list":
+		var efs []exportField
+		var fields []interface{}
+
+		err = json.Unmarshal(raw, &efs)
+		if err == nil {
+			fields, err = importFields(efs)
+		}
+
+		field = fields
+		if ef.Type == "tuple" {
+			field = container.NewTuple(fields...)
+		}
+	case "labels":
+		var ids []string
+		err = json.Unmarshal(raw, &ids)
+
+		lbls := container.NewLabels()
+		for _, id := range ids {
+			lbls.Add(container.NewLabel(id))
+		}
+
+		field = lbls
+	case "func":
+		var ns string
+		err = json.Unmarshal(raw, &ns)
+		field = container.NewFuncRef(ns)
+	default:
+		err = fmt.Errorf("unknown type %q", ef.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s field: %s", ef.Type, err)
+	}
+
+	return field, err
+}
+
+// intBits returns the size in bits of the number type named typ with prefix, which is 64 for int and uint.
+func intBits(typ string, prefix string) (bits int) {
+	bits, err := strconv.Atoi(typ[len(prefix):])
+	if err != nil {
+		bits = 64
+	}
+
+	return bits
+}
+
+// convertNumber converts the number v to the number type named typ.
+func convertNumber(v interface{}, typ string) (n interface{}) {
+	var t reflect.Type
+
+	switch typ {
+	case "int":
+		t = reflect.TypeOf(int(0))
+	case "int8":
+		t = reflect.TypeOf(int8(0))
+	case "int16":
+		t = reflect.TypeOf(int16(0))
+	case "int32":
+		t = reflect.TypeOf(int32(0))
+	case "int64":
+		t = reflect.TypeOf(int64(0))
+	case "uint":
+		t = reflect.TypeOf(uint(0))
+	case "uint8":
+		t = reflect.TypeOf(uint8(0))
+	case "uint16":
+		t = reflect.TypeOf(uint16(0))
+	case "uint32":
+		t = reflect.TypeOf(uint32(0))
+	case "uint64":
+		t = reflect.TypeOf(uint64(0))
+	case "float32":
+		t = reflect.TypeOf(float32(0))
+	default:
+		t = reflect.TypeOf(float64(0))
+	}
+
+	n = reflect.ValueOf(v).Convert(t).Interface()
+
+	return n
+}
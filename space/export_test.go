@@ -0,0 +1,107 @@
+package space
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pspaces/gospace/container"
+)
+
+func TestExportImport(t *testing.T) {
+	// Setup
+	var x int
+	var s string
+
+	src := NewSpace("tcp://localhost:31730/export")
+	dst := NewSpace("tcp://localhost:31731/import")
+
+	tuples := []container.Tuple{
+		container.NewTuple("job", 42, 3.5, 2.0),
+		container.NewTuple(nil, true, int8(-8), int16(16), int32(32), int64(math.MaxInt64), uint(1), uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64), float32(1.5)),
+		container.NewTuple("nested", container.NewTuple("inner", 1), []interface{}{"list", 2, []interface{}{}}),
+		container.NewTuple(container.NewLabels(container.NewLabel("hr")), "salary", math.Inf(-1)),
+	}
+
+	for _, tp := range tuples {
+		src.Put(tp.Flds...)
+	}
+
+	src.Put("square", evalSquare)
+
+	// Test that all tuples are exported in order, with the types of their fields.
+	var buf bytes.Buffer
+	n, err := src.Export(&buf)
+	if err != nil || n != 5 {
+		t.Fatalf("Export() gave %d and error %v, should export 5 tuples", n, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	header := `{"format":"gospace/tuples","version":1}`
+	first := `{"fields":[{"type":"string","value":"job"},{"type":"int","value":42},{"type":"float64","value":3.5},{"type":"float64","value":2}]}`
+	if len(lines) != 6 || lines[0] != header || lines[1] != first {
+		t.Errorf("Export() wrote %q, should start with the header %s and the tuple %s", buf.String(), header, first)
+	}
+
+	if !strings.HasPrefix(lines[5], `{"fields":[{"type":"string","value":"square"},{"type":"func","value":"func://`) {
+		t.Errorf("Export() wrote %s for a function, should write its namespace", lines[5])
+	}
+
+	// Test that importing the export places equal tuples.
+	exported := buf.String()
+
+	n, err = dst.Import(strings.NewReader(exported))
+	if err != nil || n != 5 {
+		t.Fatalf("Import() gave %d and error %v, should import 5 tuples", n, err)
+	}
+
+	placed, _ := src.InspectTuples(0, len(tuples))
+	page, _ := dst.InspectTuples(0, len(tuples))
+	if !reflect.DeepEqual(page, placed) {
+		t.Errorf("Import() placed %v, should be %v", page.Tuples, placed.Tuples)
+	}
+
+	buf.Reset()
+	if dst.Export(&buf); buf.String() != exported {
+		t.Errorf("Export() after Import() wrote %q, should be %q", buf.String(), exported)
+	}
+
+	// Test that exports are filtered by templates.
+	buf.Reset()
+	n, err = src.Export(&buf, "job", &x, 3.5, 2.0)
+	if err != nil || n != 1 || strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("Export() gave %d, error %v and %q for a template matching one tuple, should export it", n, err, buf.String())
+	}
+
+	buf.Reset()
+	n, err = src.Export(&buf, "job", &s, 3.5, 2.0)
+	if err != nil || n != 0 || buf.String() != header+"\n" {
+		t.Errorf("Export() gave %d, error %v and %q for a template matching no tuples, should only write the header", n, err, buf.String())
+	}
+
+	// Test that invalid imports are reported with their line.
+	invalid := map[string]string{
+		"":                               "line 1:",
+		`{"format":"other","version":1}`: "line 1: format",
+		`{"format":"gospace/tuples","version":2}`:                                       "line 1: unsupported version 2",
+		header + "\n\n" + `{"fields":[{"type":"int","value":"x"}]}`:                     "line 3: invalid int field",
+		header + "\n" + `{"fields":[{"type":"int8","value":128}]}`:                      "line 2: invalid int8 field",
+		header + "\n" + `{"fields":[{"type":"complex128","value":1}]}`:                  "line 2: invalid complex128 field: unknown type",
+		header + "\n" + `{"fields":[{"type":"func","value":"func://example/unknown"}]}`: "line 2:",
+		header + "\n" + `{"tuple":[]}`:                                                  "line 2: tuple has no fields",
+	}
+
+	for data, msg := range invalid {
+		if n, err := dst.Import(strings.NewReader(data)); err == nil || !strings.HasPrefix(err.Error(), msg) || n != 0 {
+			t.Errorf("Import(%q) gave %d and error %v, should fail with %q", data, n, err, msg)
+		}
+	}
+
+	// Test that fields without an exported type are reported.
+	src.Put("template", container.NewTemplate("x"))
+	if _, err = src.Export(&buf); err == nil {
+		t.Errorf("Export() gave nil for a tuple with a template, should be an error")
+	}
+}
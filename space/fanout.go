@@ -0,0 +1,75 @@
+package space
+
+import (
+	"errors"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/protocol"
+)
+
+// errNoSpaces is returned when an operation is fanned out to no spaces at all.
+var errNoSpaces = errors.New("operation performed on an empty collection of spaces")
+
+// queryResult contains the outcome of a blocking query performed on the i'th space.
+type queryResult struct {
+	i   int
+	t   container.Tuple
+	err error
+}
+
+// waitAny performs a blocking operation with template t on all spaces spcs at the same time.
+// waitAny parks a blocking query on every space and returns the first matching tuple tp and the index i of the space it came from.
+// If remove is true, waitAny removes exactly one tuple by performing a non-blocking retrieval on the space that answered first.
+// Should another client take that tuple first, waitAny parks a new query on that space and keeps waiting.
+// All pending queries are abandoned once waitAny returns, hence no tuple is delivered twice, and the spaces stop waiting for them.
+// Error e contains a structure adhering to the error interface if the operation fails on every space, and nil otherwise.
+func waitAny(spcs []*Space, remove bool, t ...interface{}) (tp container.Tuple, i int, e error) {
+	if len(spcs) == 0 {
+		return container.NewTuple(nil), -1, errNoSpaces
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	results := make(chan queryResult, len(spcs))
+
+	park := func(i int) {
+		go func() {
+			qt, err := getAndQueryUntil(*spcs[i].p, protocol.QueryRequest, done, t...)
+			results <- queryResult{i: i, t: qt, err: err}
+		}()
+	}
+
+	for i := range spcs {
+		park(i)
+	}
+
+	failed := 0
+
+	for {
+		res := <-results
+
+		// Spaces that fail are given up on, unless all of them fail.
+		if res.err != nil {
+			failed++
+
+			if failed == len(spcs) {
+				return container.NewTuple(nil), res.i, NewSpaceError(spcs[res.i], container.NewTemplate(t...), res.err)
+			}
+
+			continue
+		}
+
+		if !remove {
+			return res.t, res.i, nil
+		}
+
+		// Take exactly the tuple that was seen, or try again if someone else was faster.
+		gt, err := spcs[res.i].GetP(res.t.Fields()...)
+		if err == nil {
+			return gt, res.i, nil
+		}
+
+		park(res.i)
+	}
+}
@@ -0,0 +1,206 @@
+package space
+
+import (
+	"math/rand"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// Selection defines the order in which members of a space group are tried.
+type Selection int
+
+// Selection constants.
+const (
+	SelectOrdered Selection = iota
+	SelectRandom
+)
+
+// SpaceGroup is a structure for interacting with a collection of local or remote spaces as if they were one space.
+type SpaceGroup struct {
+	members []Space
+	sel     Selection
+}
+
+// NewSpaceGroup creates a space group sg over the spaces in members.
+// NewSpaceGroup uses the selection sel to decide in which order members are tried by non-blocking operations.
+func NewSpaceGroup(sel Selection, members ...Space) (sg SpaceGroup) {
+	sg = SpaceGroup{members: make([]Space, len(members)), sel: sel}
+	copy(sg.members, members)
+	return sg
+}
+
+// Members returns the spaces contained in the space group sg.
+func (sg *SpaceGroup) Members() (members []Space) {
+	members = make([]Space, len(sg.members))
+	copy(members, sg.members)
+	return members
+}
+
+// order returns references to the members of space group sg in the order given by its selection.
+func (sg *SpaceGroup) order() (spcs []*Space) {
+	spcs = make([]*Space, len(sg.members))
+
+	if sg.sel == SelectRandom {
+		for i, j := range rand.Perm(len(sg.members)) {
+			spcs[i] = &sg.members[j]
+		}
+	} else {
+		for i := range sg.members {
+			spcs[i] = &sg.members[i]
+		}
+	}
+
+	return spcs
+}
+
+// Size retrieves the total size of all members in space group sg at this instant.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) Size() (sz int, e error) {
+	if len(sg.members) == 0 {
+		return -1, errNoSpaces
+	}
+
+	for i := range sg.members {
+		msz, err := sg.members[i].Size()
+
+		if err != nil {
+			return -1, err
+		}
+
+		sz += msz
+	}
+
+	return sz, e
+}
+
+// Put performs a blocking placement of a tuple t into the first member of space group sg that accepts it.
+// Put returns the original tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) Put(t ...interface{}) (tp container.Tuple, e error) {
+	tp, e = container.NewTuple(nil), errNoSpaces
+
+	for _, spc := range sg.order() {
+		tp, e = spc.Put(t...)
+
+		if e == nil {
+			break
+		}
+	}
+
+	return tp, e
+}
+
+// PutP performs a non-blocking placement of a tuple t into the first member of space group sg that accepts it.
+// PutP returns the original tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) PutP(t ...interface{}) (tp container.Tuple, e error) {
+	tp, e = container.NewTuple(nil), errNoSpaces
+
+	for _, spc := range sg.order() {
+		tp, e = spc.PutP(t...)
+
+		if e == nil {
+			break
+		}
+	}
+
+	return tp, e
+}
+
+// Get performs a blocking retrieval for a tuple with template t from any member of space group sg.
+// Get waits on all members and removes exactly one tuple from the first member that can supply it.
+// Get returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails on every member, and nil otherwise.
+func (sg *SpaceGroup) Get(t ...interface{}) (tp container.Tuple, e error) {
+	tp, _, e = waitAny(sg.order(), true, t...)
+	return tp, e
+}
+
+// Query performs a blocking query for a tuple with template t from any member of space group sg.
+// Query waits on all members and returns the tuple from the first member that can supply it.
+// Query returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails on every member, and nil otherwise.
+func (sg *SpaceGroup) Query(t ...interface{}) (tp container.Tuple, e error) {
+	tp, _, e = waitAny(sg.order(), false, t...)
+	return tp, e
+}
+
+// GetP performs a non-blocking retrieval for a tuple with template t by trying the members of space group sg.
+// GetP returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) GetP(t ...interface{}) (tp container.Tuple, e error) {
+	tp, e = container.NewTuple(nil), errNoSpaces
+
+	for _, spc := range sg.order() {
+		tp, e = spc.GetP(t...)
+
+		if e == nil {
+			break
+		}
+	}
+
+	return tp, e
+}
+
+// QueryP performs a non-blocking query for a tuple with template t by trying the members of space group sg.
+// QueryP returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) QueryP(t ...interface{}) (tp container.Tuple, e error) {
+	tp, e = container.NewTuple(nil), errNoSpaces
+
+	for _, spc := range sg.order() {
+		tp, e = spc.QueryP(t...)
+
+		if e == nil {
+			break
+		}
+	}
+
+	return tp, e
+}
+
+// GetAll performs a non-blocking retrieval for all tuples with template t from all members of space group sg.
+// GetAll returns the merged tuples ts and the first error e that occured.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) GetAll(t ...interface{}) (ts []container.Tuple, e error) {
+	ts = []container.Tuple{}
+
+	if len(sg.members) == 0 {
+		return ts, errNoSpaces
+	}
+
+	for _, spc := range sg.order() {
+		mts, err := spc.GetAll(t...)
+
+		ts = append(ts, mts...)
+
+		if err != nil && e == nil {
+			e = err
+		}
+	}
+
+	return ts, e
+}
+
+// QueryAll performs a non-blocking query for all tuples with template t from all members of space group sg.
+// QueryAll returns the merged tuples ts and the first error e that occured.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (sg *SpaceGroup) QueryAll(t ...interface{}) (ts []container.Tuple, e error) {
+	ts = []container.Tuple{}
+
+	if len(sg.members) == 0 {
+		return ts, errNoSpaces
+	}
+
+	for _, spc := range sg.order() {
+		mts, err := spc.QueryAll(t...)
+
+		ts = append(ts, mts...)
+
+		if err != nil && e == nil {
+			e = err
+		}
+	}
+
+	return ts, e
+}
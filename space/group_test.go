@@ -0,0 +1,97 @@
+package space
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+func TestSpaceGroupGetOne(t *testing.T) {
+	// Setup
+	members := make([]Space, 0, 5)
+	for _, port := range []string{"31621", "31622", "31623", "31624", "31625"} {
+		members = append(members, NewSpace("tcp://localhost:"+port+"/workers"))
+	}
+	sg := NewSpaceGroup(SelectRandom, members...)
+
+	var job int
+	result := make(chan []interface{})
+	go func() {
+		tp, _ := sg.Get("job", &job)
+		result <- tp.Fields()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	members[3].Put("job", 42)
+	members[1].Put("job", 43)
+
+	fields := <-result
+	if fields[0] != "job" {
+		t.Errorf("Get() gave %v, should be a job", fields)
+	}
+
+	// Exactly one tuple must have been taken.
+	sz, err := sg.Size()
+	if err != nil || sz != 1 {
+		t.Errorf("Size() gave %d and %v, should be %d", sz, err, 1)
+	}
+
+	tuples, _ := sg.QueryAll("job", &job)
+	if len(tuples) != 1 || reflect.DeepEqual(tuples[0].Fields(), fields) {
+		t.Errorf("QueryAll() gave %v, should only contain the job that was not taken", tuples)
+	}
+
+	// The members stop waiting for the abandoned queries.
+	deadline := time.Now().Add(time.Second)
+	for _, member := range members {
+		waiting, err := member.InspectWaitingClients()
+		for err == nil && len(waiting) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			waiting, err = member.InspectWaitingClients()
+		}
+
+		if err != nil || len(waiting) != 0 {
+			t.Errorf("InspectWaitingClients() gave %v and %v after Get(), should be no waiting clients", waiting, err)
+		}
+	}
+}
+
+func TestSpaceGroupFailingMember(t *testing.T) {
+	// Setup
+	var spc Space
+	var job int
+
+	jobs := container.NewTemplate("job", &job)
+	query := policy.NewAction(spc.Query, jobs.Fields()...)
+
+	deny := policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("no-query"), policy.NewAccessRule(*query, policy.Deny)),
+	)
+
+	denied := NewSpace("tcp://localhost:31733/workers", deny)
+	healthy := NewSpace("tcp://localhost:31734/workers")
+	sg := NewSpaceGroup(SelectRandom, denied, healthy)
+
+	// Test that a member refusing the query does not fail the group while another can answer.
+	result := make(chan error)
+	go func() {
+		_, err := sg.Query("job", &job)
+		result <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	healthy.Put("job", 42)
+
+	if err := <-result; err != nil {
+		t.Errorf("Query() gave %v with a member refusing it, should be answered by the other member", err)
+	}
+
+	// Test that the group fails once every member has failed.
+	lone := NewSpaceGroup(SelectRandom, denied)
+	if _, err := lone.Query("job", &job); err == nil {
+		t.Errorf("Query() gave nil when every member refuses it, should be an error")
+	}
+}
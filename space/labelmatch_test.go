@@ -0,0 +1,68 @@
+package space
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+)
+
+func TestLabelMatch(t *testing.T) {
+	// Setup
+	var x int
+
+	srcA := container.NewLabel("src-a")
+	srcB := container.NewLabel("src-b")
+
+	spc := NewSpace("tcp://localhost:31713/labels")
+	rspc := NewRemoteSpace("tcp://localhost:31713/labels")
+
+	spc.Put(container.NewLabels(srcA), "reading", 1)
+	spc.Put(container.NewLabels(srcA, srcB), "reading", 2)
+	spc.Put(container.NewLabels(srcB), "reading", 3)
+
+	ts, err := rspc.QueryAll(container.AnyLabels(), "reading", &x)
+	if err != nil || len(ts) != 3 {
+		t.Errorf("QueryAll() gave %v and %v for any labels, should be %d tuples", ts, err, 3)
+	}
+
+	ts, err = rspc.QueryAll(container.HasLabels(srcA), "reading", &x)
+	if err != nil || len(ts) != 2 {
+		t.Errorf("QueryAll() gave %v and %v for label %s, should be %d tuples", ts, err, srcA.ID(), 2)
+	}
+
+	tp, err := rspc.Query(container.HasLabels(srcA).Lacks(srcB), "reading", &x)
+	expected := container.NewTuple(container.NewLabels(srcA), "reading", 1)
+	if err != nil || !reflect.DeepEqual(tp, expected) {
+		t.Errorf("Query() gave %v and %v, should be %v", tp, err, expected)
+	}
+
+	// Blocked operations are woken by labelled tuples matching their labels.
+	done := make(chan container.Tuple)
+	go func() {
+		tp, _ := rspc.Get(container.HasLabels(container.NewLabel("src-c")), "reading", &x)
+		done <- tp
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	spc.Put(container.NewLabels(container.NewLabel("src-c")), "reading", 4)
+
+	select {
+	case tp = <-done:
+		if tp.GetFieldAt(2) != 4 {
+			t.Errorf("Get() gave %v to a blocked client, should be the reading of src-c", tp)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Get() of a blocked client did not return after placing a matching tuple")
+	}
+
+	ts, err = rspc.GetAll(container.LacksLabels(srcB), "reading", &x)
+	if err != nil || len(ts) != 1 {
+		t.Errorf("GetAll() gave %v and %v without label %s, should be %d tuple", ts, err, srcB.ID(), 1)
+	}
+
+	if sz, _ := spc.Size(); sz != 2 {
+		t.Errorf("Size() gave %d, should be %d", sz, 2)
+	}
+}
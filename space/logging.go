@@ -0,0 +1,83 @@
+package space
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/function"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/protocol"
+)
+
+// setLogger sets the logger of tuple space ts, or makes it use the default logger if l is nil.
+func (ts *TupleSpace) setLogger(l logging.Logger) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger = l
+}
+
+// logger returns the logger l of tuple space ts, adding the name of the space to every message.
+func (ts *TupleSpace) logger() (l logging.Logger) {
+	g := ts.guard
+
+	g.mu.RLock()
+	l = g.logger
+	g.mu.RUnlock()
+
+	if l == nil {
+		l = logging.Default()
+	}
+
+	l = logging.With(l, logging.Space, ts.name)
+
+	return l
+}
+
+// peerFields returns the fields args logged about the peer at the other end of connection conn, followed by fields.
+func peerFields(conn net.Conn, fields ...interface{}) (args []interface{}) {
+	if conn != nil {
+		args = append(args, logging.Remote, conn.RemoteAddr().String())
+	}
+
+	args = append(args, fields...)
+
+	return args
+}
+
+// handleRecover logs any error the function caller of tuple space ts panicked with while handling the peer at the other end of connection conn.
+// conn is nil if caller is not handling a peer.
+func (ts *TupleSpace) handleRecover(caller interface{}, conn net.Conn) {
+	if err := recover(); err != nil {
+		ts.logger().Error("recovered from error", peerFields(conn, logging.Function, function.Name(caller), logging.Error, err)...)
+	}
+}
+
+// logOperation logs the error e of the operation with fields fields requested through the PointToPoint, if any.
+// Failures to reach or talk to the space are logged as warnings, and refusals by the space for debugging.
+func logOperation(ptp protocol.PointToPoint, operation string, fields []interface{}, e *error) {
+	if *e == nil {
+		return
+	}
+
+	level := ptp.GetLogger().Debug
+
+	var ne net.Error
+	if errors.As(*e, &ne) || errors.Is(*e, io.EOF) || errors.Is(*e, io.ErrUnexpectedEOF) {
+		level = ptp.GetLogger().Warn
+	}
+
+	args := []interface{}{logging.Space, ptp.GetName(), logging.Remote, ptp.GetAddress(), logging.Operation, operationName(operation)}
+
+	if fields != nil {
+		args = append(args, logging.Template, container.NewTemplate(fields...).String())
+	}
+
+	args = append(args, logging.Error, *e)
+
+	level("operation failed", args...)
+}
@@ -0,0 +1,103 @@
+package space
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/policy"
+)
+
+// logBuffer is a buffer which can be logged to concurrently.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (lb *logBuffer) Write(p []byte) (n int, err error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	return lb.buf.Write(p)
+}
+
+// records returns the JSON records logged to lb.
+func (lb *logBuffer) records(t *testing.T) (rs []map[string]interface{}) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimSpace(lb.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		r := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("json.Unmarshal(%q) gave error %s", line, err)
+		}
+
+		rs = append(rs, r)
+	}
+
+	return rs
+}
+
+func TestLogger(t *testing.T) {
+	// Setup
+	var spc Space
+	var x int
+
+	secret := container.NewTemplate("secret", &x)
+	put := policy.NewAction(spc.Put, secret.Fields()...)
+	deny := policy.NewAggregation(container.NewLabel("no-secrets"), policy.NewAccessRule(*put, policy.Deny))
+
+	spc = NewSpace("tcp://localhost:31722/logging", policy.NewComposable(deny))
+
+	// Operations of other spaces referring to the hosted space are logged to their own logger.
+	other := spc.WithLabels(nil)
+	other.SetLogger(logging.Discard)
+
+	lb := new(logBuffer)
+	if !spc.SetLogger(slog.New(slog.NewJSONHandler(lb, &slog.HandlerOptions{Level: slog.LevelDebug}))) {
+		t.Fatalf("SetLogger() gave false, should be true")
+	}
+
+	// Test
+	spc.Put("job", 1)
+	spc.Put("secret", 42)
+	other.Put("secret", 43)
+
+	var client, host int
+	for _, r := range lb.records(t) {
+		if r[logging.Space] != "logging" || r[logging.Operation] != "Put" || r["level"] != "DEBUG" {
+			t.Errorf("Logged %v, should be a debug message about Put at the space named logging", r)
+			continue
+		}
+
+		switch r["msg"] {
+		case "operation failed":
+			client++
+
+			if tp, _ := r[logging.Template].(string); !strings.Contains(tp, "42") {
+				t.Errorf("Logged template %v, should be the refused tuple", r[logging.Template])
+			}
+		case "operation refused":
+			host++
+
+			if _, exists := r[logging.Remote]; !exists {
+				t.Errorf("Logged %v without the address of the peer", r)
+			}
+		default:
+			t.Errorf("Logged %v, should only log the refused operation", r)
+		}
+	}
+
+	if client != 1 || host != 2 {
+		t.Errorf("Logged %d failures at the client and %d refusals at the host, should be 1 and 2", client, host)
+	}
+}
@@ -0,0 +1,150 @@
+package space
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pspaces/gospace/metrics"
+)
+
+// meter reports the measurements of a tuple space to a metrics sink.
+type meter struct {
+	mu   *sync.RWMutex // Lock for the sink.
+	sink metrics.Sink  // Sink of the measurements, or nil if the tuple space is not measured.
+}
+
+// newMeter creates a meter m which measures nothing until it is given a sink.
+func newMeter() (m *meter) {
+	m = &meter{mu: new(sync.RWMutex)}
+	return m
+}
+
+// current returns the sink of meter m, or nil if there is none.
+func (m *meter) current() (sink metrics.Sink) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sink
+}
+
+// count increases the counter name with labels lbls by delta, if meter m has a sink.
+func (m *meter) count(name string, delta float64, lbls ...metrics.Label) {
+	if sink := m.current(); sink != nil {
+		sink.Count(name, delta, lbls...)
+	}
+}
+
+// add changes the gauge name with labels lbls by delta, if meter m has a sink.
+func (m *meter) add(name string, delta float64, lbls ...metrics.Label) {
+	if sink := m.current(); sink != nil {
+		sink.Add(name, delta, lbls...)
+	}
+}
+
+// observe records value in the histogram name with labels lbls, if meter m has a sink.
+func (m *meter) observe(name string, value float64, lbls ...metrics.Label) {
+	if sink := m.current(); sink != nil {
+		sink.Observe(name, value, lbls...)
+	}
+}
+
+// setMetrics sets the sink of the measurements of tuple space ts.
+// The tuples and waiting clients already present are reported to the new sink, such that its gauges start out right.
+func (ts *TupleSpace) setMetrics(sink metrics.Sink) {
+	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	ts.muTuples.Lock()
+	defer ts.muTuples.Unlock()
+
+	m := ts.meter
+
+	m.mu.Lock()
+	old := m.sink
+	m.sink = sink
+	m.mu.Unlock()
+
+	for _, t := range ts.tuples {
+		if old != nil {
+			old.Add(metrics.Tuples, -1, arityLabel(t.Length()))
+		}
+
+		if sink != nil {
+			sink.Add(metrics.Tuples, 1, arityLabel(t.Length()))
+		}
+	}
+
+	if n := float64(len(ts.waitingClients)); n > 0 {
+		if old != nil {
+			old.Add(metrics.WaitingClients, -n)
+		}
+
+		if sink != nil {
+			sink.Add(metrics.WaitingClients, n)
+		}
+	}
+}
+
+// tuplesChanged reports that delta tuples of the given arity have been placed in, or removed from if negative, tuple space ts.
+func (ts *TupleSpace) tuplesChanged(arity int, delta int) {
+	ts.meter.add(metrics.Tuples, float64(delta), arityLabel(arity))
+}
+
+// arityLabel returns the label of measurements of tuples with the given arity.
+func arityLabel(arity int) (l metrics.Label) {
+	l = metrics.L("arity", strconv.Itoa(arity))
+	return l
+}
+
+// operationLabel returns the label of measurements of the operation of messages with operation operation.
+func operationLabel(operation string) (l metrics.Label) {
+	l = metrics.L("operation", operationName(operation))
+
+	return l
+}
+
+// meteredConn is a connection counting the bytes sent and received through it.
+type meteredConn struct {
+	net.Conn
+	in  int64
+	out int64
+}
+
+// Read reads from the connection mc, counting the bytes received.
+func (mc *meteredConn) Read(b []byte) (n int, err error) {
+	n, err = mc.Conn.Read(b)
+	atomic.AddInt64(&mc.in, int64(n))
+	return n, err
+}
+
+// Write writes to the connection mc, counting the bytes sent.
+func (mc *meteredConn) Write(b []byte) (n int, err error) {
+	n, err = mc.Conn.Write(b)
+	atomic.AddInt64(&mc.out, int64(n))
+	return n, err
+}
+
+// meterConnection counts connection conn as active in tuple space ts, and counts the bytes sent and received through it.
+// meterConnection returns the metered connection mc, and a function done which must be called once conn has been handled.
+func (ts *TupleSpace) meterConnection(conn net.Conn) (mc net.Conn, done func()) {
+	// The connection is reported to the sink present when it is accepted, even if the sink is replaced meanwhile.
+	sink := ts.meter.current()
+
+	if sink == nil {
+		return conn, func() {}
+	}
+
+	sink.Add(metrics.ActiveConnections, 1)
+
+	metered := &meteredConn{Conn: conn}
+
+	done = func() {
+		sink.Add(metrics.ActiveConnections, -1)
+		sink.Count(metrics.ReceivedBytes, float64(atomic.LoadInt64(&metered.in)))
+		sink.Count(metrics.SentBytes, float64(atomic.LoadInt64(&metered.out)))
+	}
+
+	return metered, done
+}
@@ -0,0 +1,88 @@
+package space
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/metrics"
+	"github.com/pspaces/gospace/policy"
+)
+
+func TestMetrics(t *testing.T) {
+	// Setup
+	var spc Space
+	var s string
+	var x int
+
+	secret := container.NewTemplate("secret", &x)
+	put := policy.NewAction(spc.Put, secret.Fields()...)
+	deny := policy.NewAggregation(container.NewLabel("no-secrets"), policy.NewAccessRule(*put, policy.Deny))
+
+	spc = NewSpace("tcp://localhost:31721/metrics", policy.NewComposable(deny))
+
+	spc.Put("job", 1)
+
+	reg := metrics.NewRegistry()
+	if !spc.SetMetrics(reg) {
+		t.Fatalf("SetMetrics() gave false at the host, should be true")
+	}
+
+	spc.Put("job", 2)
+	spc.Put("job", 3, "urgent")
+	spc.Put("secret", 42)
+	spc.Get("job", &x)
+
+	blocked := make(chan bool)
+	go func() {
+		spc.Get("result", &s)
+		blocked <- true
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	checks := []struct {
+		name     string
+		lbls     []metrics.Label
+		expected float64
+	}{
+		{metrics.Tuples, []metrics.Label{metrics.L("arity", "2")}, 1},
+		{metrics.Tuples, []metrics.Label{metrics.L("arity", "3")}, 1},
+		{metrics.WaitingClients, nil, 1},
+		{metrics.ActiveConnections, nil, 1},
+		{metrics.Operations, []metrics.Label{metrics.L("operation", "Put"), metrics.L("outcome", metrics.Accepted)}, 2},
+		{metrics.Operations, []metrics.Label{metrics.L("operation", "Put"), metrics.L("outcome", metrics.Refused)}, 1},
+		{metrics.Operations, []metrics.Label{metrics.L("operation", "Get"), metrics.L("outcome", metrics.Accepted)}, 2},
+	}
+
+	for _, c := range checks {
+		if v, _ := reg.Value(c.name, c.lbls...); v != c.expected {
+			t.Errorf("Value(%s, %v) gave %v, should be %v", c.name, c.lbls, v, c.expected)
+		}
+	}
+
+	spc.Put("result", "done")
+	<-blocked
+
+	spc.QueryAgg(ProvenanceSum, "job", &x)
+
+	if v, _ := reg.Value(metrics.WaitingClients); v != 0 {
+		t.Errorf("Value(%s) gave %v after waking the waiting client, should be %v", metrics.WaitingClients, v, 0)
+	}
+
+	if _, ok := reg.Value(metrics.AggregationDuration); !ok {
+		t.Errorf("Value(%s) gave nothing after aggregating, should be measured", metrics.AggregationDuration)
+	}
+
+	if v, _ := reg.Value(metrics.ReceivedBytes); v <= 0 {
+		t.Errorf("Value(%s) gave %v, should be positive", metrics.ReceivedBytes, v)
+	}
+
+	if v, _ := reg.Value(metrics.SentBytes); v <= 0 {
+		t.Errorf("Value(%s) gave %v, should be positive", metrics.SentBytes, v)
+	}
+
+	if _, ok := reg.Value(metrics.OperationDuration, metrics.L("operation", "Get")); !ok {
+		t.Errorf("Value(%s) gave nothing for Get, should be measured", metrics.OperationDuration)
+	}
+}
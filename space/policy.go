@@ -0,0 +1,194 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+	"github.com/pspaces/gospace/protocol"
+)
+
+// errCompositePolicy is returned when a composite policy is patched.
+var errCompositePolicy = errors.New("a composite policy can not be patched, set a new policy instead")
+
+// currentPolicy returns the composable policy cp of tuple space ts and its version.
+func (ts *TupleSpace) currentPolicy() (cp *policy.Composable, version int) {
+	ts.muPolicy.RLock()
+	defer ts.muPolicy.RUnlock()
+
+	return ts.pol, ts.polVersion
+}
+
+// setPolicy replaces the composable policy of tuple space ts by cp, and returns the version of the new policy.
+// Operations already in progress, including blocked ones, keep the policy they were authorized under.
+func (ts *TupleSpace) setPolicy(cp *policy.Composable) (version int) {
+	ts.muPolicy.Lock()
+	defer ts.muPolicy.Unlock()
+
+	ts.pol = cp
+	ts.polVersion++
+
+	return ts.polVersion
+}
+
+// updatePolicy patches the composable policy of tuple space ts, and returns the version of the patched policy.
+// The aggregation policies of cp replace those with the same label or are added, and those labelled by remove are removed.
+// The patched policy is a new composable policy, such that operations in progress are unaffected.
+// updatePolicy returns an error err if the policy of ts is composite, since it is ambiguous which part to patch.
+func (ts *TupleSpace) updatePolicy(cp *policy.Composable, remove ...container.Label) (version int, err error) {
+	ts.muPolicy.Lock()
+	defer ts.muPolicy.Unlock()
+
+	if ts.pol.IsComposite() {
+		return -1, errCompositePolicy
+	}
+
+	removed := make(map[string]bool)
+	for _, l := range remove {
+		removed[l.ID()] = true
+	}
+
+	for _, ap := range cp.Aggregations() {
+		removed[ap.Lbl.ID()] = true
+	}
+
+	ncp := policy.NewComposable()
+
+	for _, ap := range ts.pol.Aggregations() {
+		if !removed[ap.Lbl.ID()] {
+			ncp.Add(ap)
+		}
+	}
+
+	for _, ap := range cp.Aggregations() {
+		ncp.Add(ap)
+	}
+
+	ts.pol = ncp
+	ts.polVersion++
+
+	return ts.polVersion, err
+}
+
+// handleSetPolicy replaces the policy of the space by the policy file contained in template temp.
+func (ts *TupleSpace) handleSetPolicy(conn net.Conn, temp container.Template) {
+	defer ts.handleRecover(ts.handleSetPolicy, conn)
+
+	version := -1
+
+	data, _ := temp.GetFieldAt(0).(string)
+
+	cp, err := policy.Load([]byte(data), ts.funReg)
+
+	if err == nil {
+		version = ts.setPolicy(cp)
+	}
+
+	ts.replyPolicy(conn, version, err)
+}
+
+// handleUpdatePolicy patches the policy of the space with the policy file and the labels to remove contained in template temp.
+func (ts *TupleSpace) handleUpdatePolicy(conn net.Conn, temp container.Template) {
+	defer ts.handleRecover(ts.handleUpdatePolicy, conn)
+
+	version := -1
+
+	data, _ := temp.GetFieldAt(0).(string)
+
+	var ids []string
+	if temp.Length() > 1 {
+		ids, _ = temp.GetFieldAt(1).([]string)
+	}
+
+	remove := make([]container.Label, len(ids))
+	for i, id := range ids {
+		remove[i] = container.NewLabel(id)
+	}
+
+	cp, err := policy.Load([]byte(data), ts.funReg)
+
+	if err == nil {
+		version, err = ts.updatePolicy(cp, remove...)
+	}
+
+	ts.replyPolicy(conn, version, err)
+}
+
+// replyPolicy tells the peer at the other end of connection conn whether a policy change succeeded, and the resulting version.
+func (ts *TupleSpace) replyPolicy(conn net.Conn, version int, err error) {
+	status := protocol.CreateAcceptance()
+	if err != nil {
+		status = protocol.CreateRefusal(err.Error())
+	}
+
+	enc := encoderOf(conn)
+	errEnc := enc.Encode(status)
+
+	if errEnc == nil {
+		errEnc = encodeResponse(conn, version)
+	}
+
+	if errEnc != nil {
+		panic("Could not encode policy version")
+	}
+}
+
+// explainPolicy explains how the policy of tuple space ts decides the operation named operation on template temp
+// for a caller holding the labels caller, without performing the operation.
+// explainPolicy returns an error err if the operation is unknown.
+func (ts *TupleSpace) explainPolicy(operation string, temp container.Template, caller container.Labels) (e policy.Explanation, err error) {
+	fun := policy.LookupOperation(operation)
+
+	if fun == nil {
+		return e, fmt.Errorf("unknown operation %s", operation)
+	}
+
+	a := policy.NewAction(fun, temp.Fields()...)
+
+	cp, version := ts.currentPolicy()
+
+	e = cp.Explain(a, caller)
+	e.Version = version
+
+	// Administrative operations are refused unless a policy permits them.
+	if e.Permitted && e.Label == "" && isAdminOperation(operation) {
+		e.Permitted = false
+		e.Reason = errNotPermitted(operation).Error()
+	}
+
+	return e, err
+}
+
+// handleExplainPolicy explains the policy of the space for the operation and template contained in template temp,
+// as it applies to a caller holding the labels caller.
+func (ts *TupleSpace) handleExplainPolicy(conn net.Conn, temp container.Template, caller container.Labels) {
+	defer ts.handleRecover(ts.handleExplainPolicy, conn)
+
+	fields := temp.Fields()
+
+	var e policy.Explanation
+	err := errors.New("missing operation to explain")
+
+	if len(fields) > 0 {
+		operation, _ := fields[0].(string)
+		e, err = ts.explainPolicy(operation, container.NewTemplate(fields[1:]...), caller)
+	}
+
+	status := protocol.CreateAcceptance()
+	if err != nil {
+		status = protocol.CreateRefusal(err.Error())
+	}
+
+	enc := encoderOf(conn)
+	errEnc := enc.Encode(status)
+
+	if errEnc == nil {
+		errEnc = encodeResponse(conn, e)
+	}
+
+	if errEnc != nil {
+		panic("Could not encode policy explanation")
+	}
+}
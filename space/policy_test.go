@@ -0,0 +1,207 @@
+package space
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/auth"
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+func TestSetPolicy(t *testing.T) {
+	// Setup
+	var spc Space
+	var x int
+	var s string
+	var ids []string
+
+	hr := container.NewLabel("hr")
+	admin := container.NewLabel("admin")
+
+	salary := container.NewTemplate("salary", &x)
+	setTemplate := container.NewTemplate(&s)
+	updateTemplate := container.NewTemplate(&s, &ids)
+
+	get := policy.NewAction(spc.Get, salary.Fields()...)
+	set := policy.NewAction(spc.SetPolicy, setTemplate.Fields()...)
+	update := policy.NewAction(spc.UpdatePolicy, updateTemplate.Fields()...)
+
+	salaryGet := policy.NewAggregation(container.NewLabel("salary-get"), policy.NewAccessRule(*get, policy.Allow, hr))
+	adminSet := policy.NewAggregation(container.NewLabel("admin-set"), policy.NewAccessRule(*set, policy.Allow, admin))
+	adminUpdate := policy.NewAggregation(container.NewLabel("admin-update"), policy.NewAccessRule(*update, policy.Allow, admin))
+
+	spc = NewSpace("tcp://localhost:31691/policy", policy.NewComposable(salaryGet))
+
+	authn := auth.NewMemory()
+	authn.Add(auth.NewPrincipal("carol"), "carol-token")
+	authn.Add(auth.NewPrincipal("dave", admin), "dave-token")
+	authn.Add(auth.NewPrincipal("root", admin), "root-token")
+
+	spc.SetAuthenticator(authn)
+	spc.SetAdmins("root")
+
+	remote := NewRemoteSpace("tcp://localhost:31691/policy")
+	rspc := remote.WithIdentity(auth.NewTokenIdentity("carol", "carol-token"))
+
+	rspc.Put("salary", 5000)
+
+	_, err := rspc.Get("salary", &x)
+	if err == nil || !strings.Contains(err.Error(), "policy version 0") {
+		t.Errorf("Get() gave %v, should be an error mentioning policy version 0", err)
+	}
+
+	_, err = rspc.SetPolicy(policy.NewComposable())
+	if err == nil {
+		t.Errorf("SetPolicy() gave nil without a policy permitting it, should be an error")
+	}
+
+	// A client blocked under the old policy is not disrupted by changing it.
+	blocked := make(chan container.Tuple)
+	go func() {
+		tp, _ := rspc.Get("job", &s)
+		blocked <- tp
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	v, err := spc.SetPolicy(policy.NewComposable(adminSet, adminUpdate))
+	if err != nil || v != 1 {
+		t.Errorf("SetPolicy() gave %d and %v at the host, should be %d and nil", v, err, 1)
+	}
+
+	rspc.Put("job", "build")
+
+	select {
+	case tp := <-blocked:
+		if !reflect.DeepEqual(tp, container.NewTuple("job", "build")) {
+			t.Errorf("Get() gave %v to a blocked client, should be %v", tp, container.NewTuple("job", "build"))
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Get() of a blocked client did not return after changing the policy")
+	}
+
+	_, err = rspc.Get("salary", &x)
+	if err != nil {
+		t.Errorf("Get() gave %v after removing the policy on salaries, should be nil", err)
+	}
+
+	rspc.Put("salary", 6000)
+
+	_, err = rspc.UpdatePolicy(policy.NewComposable(salaryGet))
+	if err == nil {
+		t.Errorf("UpdatePolicy() gave nil for a caller without label %s, should be an error", admin.ID())
+	}
+
+	// Holding the label permitted to change the policy does not suffice without being an administrator.
+	dspc := remote.WithIdentity(auth.NewTokenIdentity("dave", "dave-token"))
+
+	_, err = dspc.UpdatePolicy(policy.NewComposable(salaryGet), adminSet.Lbl)
+	if err == nil || !strings.Contains(err.Error(), "not an administrator") {
+		t.Errorf("UpdatePolicy() gave %v for a principal with label %s who is no administrator, should be an error", err, admin.ID())
+	}
+
+	aspc := remote.WithIdentity(auth.NewTokenIdentity("root", "root-token"))
+
+	v, err = aspc.UpdatePolicy(policy.NewComposable(salaryGet), adminSet.Lbl)
+	if err != nil || v != 2 {
+		t.Errorf("UpdatePolicy() gave %d and %v, should be %d and nil", v, err, 2)
+	}
+
+	_, err = rspc.Get("salary", &x)
+	if err == nil || !strings.Contains(err.Error(), "policy version 2") {
+		t.Errorf("Get() gave %v after adding the policy on salaries, should be an error mentioning policy version 2", err)
+	}
+
+	_, err = aspc.SetPolicy(policy.NewComposable())
+	if err == nil {
+		t.Errorf("SetPolicy() gave nil after removing the policy permitting it, should be an error")
+	}
+}
+
+func TestExplainPolicy(t *testing.T) {
+	// Setup
+	var spc Space
+	var x int
+
+	admin := container.NewLabel("admin")
+
+	salaries := container.NewTemplate("salary", &x)
+	ceo := container.NewTemplate("salary", "ceo")
+
+	raise := policy.NewTransformation(ComposeRaise)
+
+	query := policy.NewAction(spc.Query, salaries.Fields()...)
+	queryCEO := policy.NewAction(spc.Query, ceo.Fields()...)
+	explain := policy.NewAction(spc.ExplainPolicy, "Query")
+
+	salaryQuery := policy.NewAggregation(container.NewLabel("salary-query"), policy.NewAggregationRule(*query, *policy.NewTransformations(nil, nil, &raise)))
+	ceoQuery := policy.NewAggregation(container.NewLabel("ceo-query"), policy.NewAccessRule(*queryCEO, policy.Deny))
+	adminExplain := policy.NewAggregation(container.NewLabel("admin-explain"), policy.NewAccessRule(*explain, policy.Allow, admin))
+
+	spc = NewSpace("tcp://localhost:31692/explain", policy.NewComposable(salaryQuery, ceoQuery, adminExplain))
+	rspc := NewRemoteSpace("tcp://localhost:31692/explain")
+
+	spc.Put("salary", 5000)
+
+	e, err := spc.ExplainPolicy("Query", "salary", &x)
+	if err != nil {
+		t.Fatalf("ExplainPolicy() gave %v, should be nil", err)
+	}
+
+	if e.Label != "salary-query" || !e.Permitted || len(e.Result) != 1 || !strings.HasSuffix(e.Result[0], "ComposeRaise") {
+		t.Errorf("ExplainPolicy() gave %v, should permit Query by policy salary-query with result transformation ComposeRaise", e)
+	}
+
+	if len(e.Candidates) != 1 || !e.Candidates[0].Chosen || e.Candidates[0].Pno != 2 {
+		t.Errorf("ExplainPolicy() gave candidates %v, should be salary-query with pno %d", e.Candidates, 2)
+	}
+
+	e, err = spc.ExplainPolicy("Query", "salary", "ceo")
+	if err != nil || e.Label != "ceo-query" || e.Permitted || e.Effect != "deny" {
+		t.Errorf("ExplainPolicy() gave %v and %v, should refuse Query by policy ceo-query", e, err)
+	}
+
+	if len(e.Candidates) != 1 || e.Candidates[0].Label != "ceo-query" {
+		t.Errorf("ExplainPolicy() gave candidates %v, should be ceo-query", e.Candidates)
+	}
+
+	e, err = spc.ExplainPolicy("SetPolicy", "")
+	if err != nil || e.Permitted {
+		t.Errorf("ExplainPolicy() gave %v and %v for SetPolicy without a policy permitting it, should refuse it", e, err)
+	}
+
+	_, err = spc.ExplainPolicy("Frobnicate")
+	if err == nil {
+		t.Errorf("ExplainPolicy() gave nil for an unknown operation, should be an error")
+	}
+
+	// Remote spaces only explain their policy to callers permitted to do so.
+	_, err = rspc.ExplainPolicy("Query", "salary", &x)
+	if err == nil {
+		t.Errorf("ExplainPolicy() gave nil for a caller without label %s, should be an error", admin.ID())
+	}
+
+	aspc := rspc.WithLabels(container.NewLabels(admin))
+
+	e, err = aspc.ExplainPolicy("Query", "salary", &x)
+	if err != nil || e.Label != "salary-query" || !e.Permitted {
+		t.Errorf("ExplainPolicy() gave %v and %v, should permit Query by policy salary-query", e, err)
+	}
+
+	_, err = aspc.ExplainPolicy("Get", "salary", &x)
+	if err == nil {
+		t.Errorf("ExplainPolicy() gave nil for Get, should be an error since only explaining Query is permitted")
+	}
+
+	// Explaining touches no tuples.
+	if n, _ := spc.Size(); n != 1 {
+		t.Errorf("Size() gave %d after explaining, should be %d", n, 1)
+	}
+
+	if !strings.Contains(e.String(), "* candidate salary-query") {
+		t.Errorf("String() gave %q, should mark the chosen candidate salary-query", e.String())
+	}
+}
@@ -0,0 +1,104 @@
+package space
+
+import (
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+// setProvenance turns provenance tracking of tuple space ts on or off.
+func (ts *TupleSpace) setProvenance(b bool) {
+	ts.muPolicy.Lock()
+	defer ts.muPolicy.Unlock()
+
+	ts.provenance = b
+}
+
+// tracksProvenance returns true if aggregates of tuple space ts carry the labels of the tuples they are derived from, and false otherwise.
+func (ts *TupleSpace) tracksProvenance() (b bool) {
+	ts.muPolicy.RLock()
+	defer ts.muPolicy.RUnlock()
+
+	return ts.provenance
+}
+
+// transformRetrieved rewrites a tuple t retrieved by an ordinary operation given an aggregation policy ap, as retrievalTransform does.
+// If tuple space ts tracks provenance, the rewritten tuple is labelled with the labels of t, even if the policy relabels or drops them.
+func (ts *TupleSpace) transformRetrieved(ap *policy.Aggregation, t container.Tuple) (tt container.Tuple) {
+	tt = retrievalTransform(ap, t)
+
+	if lbls := tupleLabels(t); ap != nil && lbls != nil && ts.tracksProvenance() {
+		tt = transformToTuple(withProvenance(&tt, []container.Labels{lbls}))
+	}
+
+	return tt
+}
+
+// tupleLabels returns the labels lbls of tuple t if it is labelled, i.e. if its first field is a label set, and nil otherwise.
+func tupleLabels(t container.Tuple) (lbls container.Labels) {
+	if t.Length() > 0 {
+		lbls, _ = t.GetFieldAt(0).(container.Labels)
+	}
+
+	return lbls
+}
+
+// inputLabels returns the label sets of the tuples at the indices consumed of tuples, leaving out unlabelled tuples.
+func inputLabels(tuples []container.Tuple, consumed []int) (inputs []container.Labels) {
+	inputs = make([]container.Labels, 0, len(consumed))
+
+	for _, i := range consumed {
+		if lbls := tupleLabels(tuples[i]); lbls != nil {
+			inputs = append(inputs, lbls)
+		}
+	}
+
+	return inputs
+}
+
+// withProvenance labels the aggregate result with the union of its own labels and the label sets inputs of the tuples it is derived from.
+// withProvenance returns result unchanged if none of the tuples it is derived from are labelled.
+func withProvenance(result container.Intertuple, inputs []container.Labels) (pt container.Intertuple) {
+	union := container.NewLabels()
+	for _, lbls := range inputs {
+		for _, l := range lbls {
+			union.Add(l)
+		}
+	}
+
+	if result == nil || len(union) == 0 {
+		return result
+	}
+
+	var fields []interface{}
+
+	switch rt := result.(type) {
+	case *container.LabelledTuple:
+		for _, l := range rt.Labels() {
+			union.Add(l)
+		}
+
+		t := rt.Tuple()
+		fields = t.Fields()
+	case *container.Tuple:
+		fields = rt.Fields()
+
+		if lbls := tupleLabels(*rt); lbls != nil {
+			for _, l := range lbls {
+				union.Add(l)
+			}
+
+			fields = fields[1:]
+		}
+	default:
+		return result
+	}
+
+	lf := make([]interface{}, len(fields)+1)
+	lf[0] = union
+	copy(lf[1:], fields)
+
+	lt := container.NewLabelledTuple(lf...)
+	pt = &lt
+
+	return pt
+}
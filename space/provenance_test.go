@@ -0,0 +1,178 @@
+package space
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/policy"
+)
+
+// ProvenanceSum sums the readings of tuples, dropping their labels.
+func ProvenanceSum(ts ...container.Intertuple) container.Intertuple {
+	sum := 0
+	for _, t := range ts {
+		sum += t.GetFieldAt(t.Length() - 1).(int)
+	}
+
+	tuple := container.NewTuple("reading", sum)
+
+	return &tuple
+}
+
+func TestProvenance(t *testing.T) {
+	// Setup
+	var lbls container.Labels
+	var x int
+
+	srcA := container.NewLabel("src-a")
+	srcB := container.NewLabel("src-b")
+
+	spc := NewSpace("tcp://localhost:31711/provenance")
+
+	spc.Put(container.NewLabels(srcA), "reading", 1)
+	spc.Put(container.NewLabels(srcB), "reading", 2)
+
+	tp, err := spc.QueryAgg(ProvenanceSum, &lbls, "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("reading", 3)) {
+		t.Errorf("QueryAgg() gave %v and %v, should be %v", tp, err, container.NewTuple("reading", 3))
+	}
+
+	if !spc.SetProvenance(true) {
+		t.Fatalf("SetProvenance() gave false at the host, should be true")
+	}
+
+	expected := container.NewTuple(container.NewLabels(srcA, srcB), "reading", 3)
+
+	tp, err = spc.QueryAgg(ProvenanceSum, &lbls, "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, expected) {
+		t.Errorf("QueryAgg() gave %v and %v with provenance, should be %v", tp, err, expected)
+	}
+
+	// Aggregates placed in the space keep their provenance, and can be told apart by it.
+	spc.PutAgg(ProvenanceSum, &lbls, "reading", &x)
+
+	tp, err = spc.GetP(container.NewLabels(srcA, srcB), "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, expected) {
+		t.Errorf("GetP() gave %v and %v for the placed aggregate, should be %v", tp, err, expected)
+	}
+
+	rspc := NewRemoteSpace("tcp://localhost:31711/provenance")
+	if rspc.SetProvenance(true) {
+		t.Errorf("SetProvenance() gave true for a remote space, should be false")
+	}
+}
+
+func TestExclusiveLabels(t *testing.T) {
+	// Setup
+	var spc Space
+	var lbls container.Labels
+	var x, k int
+
+	tenantA := container.NewLabels(container.NewLabel("tenant-a"))
+	tenantB := container.NewLabels(container.NewLabel("tenant-b"))
+
+	readings := container.NewTemplate(ProvenanceSum, &lbls, "reading", &x)
+	getAgg := policy.NewAction(spc.GetAgg, readings.Fields()...)
+
+	counted := container.NewTemplate(&k, ProvenanceSum, &lbls, "reading", &x)
+	queryAggN := policy.NewAction(spc.QueryAggN, counted.Fields()...)
+
+	excl := container.NewLabels(container.NewLabel("tenant-a"), container.NewLabel("tenant-b"))
+
+	rule := policy.NewAccessRule(*getAgg, policy.Allow)
+	rule.Excl = excl
+
+	ruleN := policy.NewAccessRule(*queryAggN, policy.Allow)
+	ruleN.Excl = excl
+
+	spc = NewSpace("tcp://localhost:31712/provenance", policy.NewComposable(
+		policy.NewAggregation(container.NewLabel("tenants"), rule),
+		policy.NewAggregation(container.NewLabel("tenants-n"), ruleN),
+	))
+
+	spc.Put(tenantA, "reading", 1)
+	spc.Put(tenantA, "reading", 2)
+
+	tp, err := spc.GetAgg(ProvenanceSum, &lbls, "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("reading", 3)) {
+		t.Errorf("GetAgg() gave %v and %v for tuples of one tenant, should be %v", tp, err, container.NewTuple("reading", 3))
+	}
+
+	spc.Put(tenantA, "reading", 1)
+	spc.Put(tenantB, "reading", 2)
+
+	tp, err = spc.GetAgg(ProvenanceSum, &lbls, "reading", &x)
+	if err == nil || !strings.Contains(err.Error(), "tenants") {
+		t.Errorf("GetAgg() gave %v and %v for tuples of different tenants, should be refused by policy tenants", tp, err)
+	}
+
+	if sz, _ := spc.Size(); sz != 2 {
+		t.Errorf("Size() gave %d after a refused GetAgg(), should be %d", sz, 2)
+	}
+
+	// Test that waiting clients are refused once the tuples they wait for mix tenants.
+	refused := make(chan error)
+	go func() {
+		_, err := spc.QueryAggN(3, ProvenanceSum, &lbls, "reading", &x)
+		refused <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	spc.Put(tenantA, "reading", 3)
+
+	select {
+	case err = <-refused:
+		if err == nil || !strings.Contains(err.Error(), "tenants-n") {
+			t.Errorf("QueryAggN() gave %v to a waiting client for tuples of different tenants, should be refused by policy tenants-n", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("QueryAggN() of a waiting client did not return after placing a tuple of another tenant")
+	}
+}
+
+// provenanceTestUnlabel drops the labels of a tuple.
+func provenanceTestUnlabel(i interface{}) (it container.Intertuple) {
+	tf := i.([]interface{})
+
+	t := container.NewTuple(tf[1:]...)
+	it = &t
+
+	return it
+}
+
+func TestRetrievalProvenance(t *testing.T) {
+	// Setup
+	var spc Space
+	var lbls container.Labels
+	var x int
+
+	srcA := container.NewLabels(container.NewLabel("src-a"))
+
+	readings := container.NewTemplate(&lbls, "reading", &x)
+	query := policy.NewAction(spc.Query, readings.Fields()...)
+
+	unlabel := policy.NewTransformation(provenanceTestUnlabel)
+	rule := policy.NewAggregationRule(*query, *policy.NewTransformations(nil, nil, &unlabel))
+
+	spc = NewSpace("tcp://localhost:31735/provenance", policy.NewComposable(policy.NewAggregation(container.NewLabel("unlabel"), rule)))
+
+	spc.Put(srcA, "reading", 1)
+
+	tp, err := spc.Query(&lbls, "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, container.NewTuple("reading", 1)) {
+		t.Errorf("Query() gave %v and %v, should be %v", tp, err, container.NewTuple("reading", 1))
+	}
+
+	// Test that tuples transformed on retrieval keep their labels with provenance.
+	spc.SetProvenance(true)
+
+	expected := container.NewTuple(srcA, "reading", 1)
+
+	tp, err = spc.Query(&lbls, "reading", &x)
+	if err != nil || !reflect.DeepEqual(tp, expected) {
+		t.Errorf("Query() gave %v and %v with provenance, should be %v", tp, err, expected)
+	}
+}
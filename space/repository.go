@@ -0,0 +1,168 @@
+package space
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pspaces/gospace/discovery"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/policy"
+)
+
+// Repository is a structure for hosting a collection of named spaces.
+// A repository can advertise the spaces it hosts such that clients can connect to them by their logical names.
+type Repository struct {
+	mu          *sync.RWMutex
+	spaces      map[string]Space
+	urls        map[string]string
+	advertisers []discovery.Advertiser
+}
+
+// NewRepository creates an empty repository r.
+func NewRepository() (r *Repository) {
+	r = &Repository{
+		mu:     new(sync.RWMutex),
+		spaces: make(map[string]Space),
+		urls:   make(map[string]string),
+	}
+
+	return r
+}
+
+// Add hosts a new space with the specified URL under the name name in repository r.
+// Add announces the space to all advertisers attached to r, logging the errors of those failing to the logger of the space.
+// Add returns the space spc and true if it has been added, and false if the name is already taken.
+func (r *Repository) Add(name string, url string, cp ...*policy.Composable) (spc Space, b bool) {
+	r.mu.Lock()
+
+	_, exists := r.spaces[name]
+	b = !exists
+
+	if b {
+		spc = NewSpace(url, cp...)
+		r.spaces[name] = spc
+		r.urls[name] = url
+	} else {
+		spc = r.spaces[name]
+	}
+
+	advertisers := make([]discovery.Advertiser, len(r.advertisers))
+	copy(advertisers, r.advertisers)
+
+	r.mu.Unlock()
+
+	if b {
+		for _, a := range advertisers {
+			err := a.Advertise(discovery.NewEntry(name, url))
+			logAdvertiser(spc, name, "could not advertise space", err)
+		}
+	}
+
+	return spc, b
+}
+
+// Space returns the space spc hosted under the name name in repository r.
+// Space returns true if a space is hosted under that name, and false otherwise.
+func (r *Repository) Space(name string) (spc Space, b bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	spc, b = r.spaces[name]
+
+	return spc, b
+}
+
+// URL returns the URL of the space hosted under the name name in repository r.
+func (r *Repository) URL(name string) (url string, b bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	url, b = r.urls[name]
+
+	return url, b
+}
+
+// Remove forgets the space hosted under the name name in repository r, and withdraws it from all advertisers attached to r.
+// The errors of advertisers failing to withdraw the space are logged to the logger of the space.
+// The space itself keeps serving the clients connected to it by its URL.
+// Remove returns true if a space has been removed, and false otherwise.
+func (r *Repository) Remove(name string) (b bool) {
+	r.mu.Lock()
+
+	spc, b := r.spaces[name]
+
+	delete(r.spaces, name)
+	delete(r.urls, name)
+
+	advertisers := make([]discovery.Advertiser, len(r.advertisers))
+	copy(advertisers, r.advertisers)
+
+	r.mu.Unlock()
+
+	if b {
+		for _, a := range advertisers {
+			err := a.Withdraw(name)
+			logAdvertiser(spc, name, "could not withdraw space", err)
+		}
+	}
+
+	return b
+}
+
+// Names returns the sorted names of all spaces hosted by repository r.
+func (r *Repository) Names() (names []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names = make([]string, 0, len(r.spaces))
+	for name := range r.spaces {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Entries returns discovery entries for all spaces hosted by repository r.
+func (r *Repository) Entries() (entries []discovery.Entry) {
+	names := r.Names()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries = make([]discovery.Entry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, discovery.NewEntry(name, r.urls[name]))
+	}
+
+	return entries
+}
+
+// Advertise announces all spaces hosted by repository r through advertiser a.
+// Spaces added to r later on are announced through a as well, and spaces removed from r are withdrawn.
+func (r *Repository) Advertise(a discovery.Advertiser) (err error) {
+	r.mu.Lock()
+	r.advertisers = append(r.advertisers, a)
+	r.mu.Unlock()
+
+	err = a.Advertise(r.Entries()...)
+
+	return err
+}
+
+// logAdvertiser logs message msg with the error err of an advertiser announcing or withdrawing the space spc hosted under the name name, if any.
+func logAdvertiser(spc Space, name string, msg string, err error) {
+	if err == nil {
+		return
+	}
+
+	var l logging.Logger
+	if spc.ts != nil {
+		l = spc.ts.logger()
+	} else {
+		l = logging.With(logging.Default(), logging.Space, name)
+	}
+
+	l.Warn(msg, logging.Error, err)
+}
@@ -0,0 +1,95 @@
+package space
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/discovery"
+	"github.com/pspaces/gospace/logging"
+)
+
+func TestRepositoryDiscovery(t *testing.T) {
+	// Setup
+	lg := discovery.NewLocalGroup()
+	advertiser := discovery.NewMulticast(lg.Join())
+	resolver := discovery.NewMulticast(lg.Join())
+	defer advertiser.Close()
+	defer resolver.Close()
+
+	discovery.Reset()
+	defer discovery.Reset()
+	discovery.Use(resolver)
+
+	repo := NewRepository()
+	repo.Advertise(advertiser)
+	spc, _ := repo.Add("orders", "tcp://localhost:31631/orders")
+
+	rspc := NewRemoteSpace("discover:///orders")
+	rspc.Put("order", 1)
+
+	var i int
+	tp, err := spc.GetP("order", &i)
+	if err != nil || tp.GetFieldAt(1) != 1 {
+		t.Errorf("GetP() gave %v and %v, should be the tuple put through the logical name", tp, err)
+	}
+
+	if _, err := discovery.Resolve("discover:///invoices", 50*time.Millisecond); err == nil {
+		t.Errorf("Resolve() found a space that is not advertised")
+	}
+
+	// Removed spaces are withdrawn from the advertisers.
+	if !repo.Remove("orders") {
+		t.Errorf("Remove() gave false for a hosted space, should be true")
+	}
+
+	err = nil
+	for deadline := time.Now().Add(time.Second); err == nil && time.Now().Before(deadline); {
+		_, err = discovery.Resolve("discover:///orders", 10*time.Millisecond)
+	}
+
+	if err == nil {
+		t.Errorf("Resolve() found a space after it was removed")
+	}
+}
+
+// downAdvertiser is an advertiser failing to announce or withdraw anything.
+type downAdvertiser struct{}
+
+func (downAdvertiser) Advertise(entries ...discovery.Entry) error {
+	return errors.New("advertiser is down")
+}
+
+func (downAdvertiser) Withdraw(names ...string) error {
+	return errors.New("advertiser is down")
+}
+
+func TestRepositoryAdvertiserErrors(t *testing.T) {
+	// Setup
+	lb := &logBuffer{}
+	logging.SetDefault(slog.New(slog.NewJSONHandler(lb, nil)))
+	defer logging.SetDefault(nil)
+
+	repo := NewRepository()
+
+	err := repo.Advertise(downAdvertiser{})
+	if err == nil {
+		t.Errorf("Advertise() gave nil for a failing advertiser, should be an error")
+	}
+
+	// Test
+	repo.Add("invoices", "tcp://localhost:31740/invoices")
+	repo.Remove("invoices")
+
+	var msgs []string
+	for _, r := range lb.records(t) {
+		if r[logging.Error] == "advertiser is down" {
+			msgs = append(msgs, r["msg"].(string))
+		}
+	}
+
+	if len(msgs) != 2 || msgs[0] != "could not advertise space" || msgs[1] != "could not withdraw space" {
+		t.Errorf("Add() and Remove() logged %v, should log the errors of the advertiser", msgs)
+	}
+}
@@ -0,0 +1,284 @@
+package space
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/pspaces/gospace/container"
+)
+
+// ShardedSpace is a structure for interacting with a space partitioned over several spaces.
+// Tuples are placed in one shard given a hash of the key fields.
+// Templates with concrete key fields are routed to a single shard, while templates with formal key fields are fanned out to all shards.
+type ShardedSpace struct {
+	shards []Space
+	keys   []int
+}
+
+// NewShardedSpace creates a sharded space ss partitioned over the spaces in shards.
+// NewShardedSpace partitions by the field indices given by keys, and by the first field if no keys are given.
+func NewShardedSpace(shards []Space, keys ...int) (ss ShardedSpace) {
+	if len(keys) == 0 {
+		keys = []int{0}
+	}
+
+	ss = ShardedSpace{shards: make([]Space, len(shards)), keys: make([]int, len(keys))}
+	copy(ss.shards, shards)
+	copy(ss.keys, keys)
+
+	return ss
+}
+
+// Shards returns the spaces the sharded space ss is partitioned over.
+func (ss *ShardedSpace) Shards() (shards []Space) {
+	shards = make([]Space, len(ss.shards))
+	copy(shards, ss.shards)
+	return shards
+}
+
+// Keys returns the field indices used to partition the sharded space ss.
+func (ss *ShardedSpace) Keys() (keys []int) {
+	keys = make([]int, len(ss.keys))
+	copy(keys, ss.keys)
+	return keys
+}
+
+// shard returns the index i of the shard responsible for the fields flds.
+// shard returns false if one of the key fields is a formal field, and true otherwise.
+func (ss *ShardedSpace) shard(flds []interface{}) (i int, concrete bool) {
+	h := fnv.New32a()
+
+	concrete = true
+
+	for _, k := range ss.keys {
+		var field interface{}
+
+		if k >= 0 && k < len(flds) {
+			field = flds[k]
+		}
+
+		if isFormal(field) {
+			concrete = false
+			break
+		}
+
+		fmt.Fprintf(h, "%T:%v;", field, field)
+	}
+
+	if concrete && len(ss.shards) > 0 {
+		i = int(h.Sum32() % uint32(len(ss.shards)))
+	} else {
+		i = -1
+	}
+
+	return i, concrete
+}
+
+// isFormal returns true if a template field is a formal field, and false otherwise.
+func isFormal(field interface{}) (b bool) {
+	if field != nil {
+		b = reflect.TypeOf(field) == reflect.TypeOf(container.TypeField{}) || reflect.TypeOf(field).Kind() == reflect.Ptr
+	}
+
+	return b
+}
+
+// members returns references to all shards in the sharded space ss.
+func (ss *ShardedSpace) members() (spcs []*Space) {
+	spcs = make([]*Space, len(ss.shards))
+
+	for i := range ss.shards {
+		spcs[i] = &ss.shards[i]
+	}
+
+	return spcs
+}
+
+// Size retrieves the total size of all shards in sharded space ss at this instant.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) Size() (sz int, e error) {
+	if len(ss.shards) == 0 {
+		return -1, errNoSpaces
+	}
+
+	for i := range ss.shards {
+		ssz, err := ss.shards[i].Size()
+
+		if err != nil {
+			return -1, err
+		}
+
+		sz += ssz
+	}
+
+	return sz, e
+}
+
+// Put performs a blocking placement of a tuple t into the shard responsible for t.
+// Put returns the original tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) Put(t ...interface{}) (tp container.Tuple, e error) {
+	i, _ := ss.shard(t)
+
+	if i < 0 {
+		return container.NewTuple(nil), errNoSpaces
+	}
+
+	return ss.shards[i].Put(t...)
+}
+
+// PutP performs a non-blocking placement of a tuple t into the shard responsible for t.
+// PutP returns the original tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) PutP(t ...interface{}) (tp container.Tuple, e error) {
+	i, _ := ss.shard(t)
+
+	if i < 0 {
+		return container.NewTuple(nil), errNoSpaces
+	}
+
+	return ss.shards[i].PutP(t...)
+}
+
+// Get performs a blocking retrieval for a tuple from sharded space ss with template t.
+// Get waits on all shards if the key fields of t are formal and removes the tuple from whichever shard can supply it first.
+// Get returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) Get(t ...interface{}) (tp container.Tuple, e error) {
+	i, concrete := ss.shard(t)
+
+	if concrete {
+		if i < 0 {
+			return container.NewTuple(nil), errNoSpaces
+		}
+
+		return ss.shards[i].Get(t...)
+	}
+
+	tp, _, e = waitAny(ss.members(), true, t...)
+
+	return tp, e
+}
+
+// Query performs a blocking query for a tuple from sharded space ss with template t.
+// Query waits on all shards if the key fields of t are formal and returns the tuple from whichever shard can supply it first.
+// Query returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) Query(t ...interface{}) (tp container.Tuple, e error) {
+	i, concrete := ss.shard(t)
+
+	if concrete {
+		if i < 0 {
+			return container.NewTuple(nil), errNoSpaces
+		}
+
+		return ss.shards[i].Query(t...)
+	}
+
+	tp, _, e = waitAny(ss.members(), false, t...)
+
+	return tp, e
+}
+
+// GetP performs a non-blocking retrieval for a tuple from sharded space ss with template t.
+// GetP tries the shards in order if the key fields of t are formal.
+// GetP returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) GetP(t ...interface{}) (tp container.Tuple, e error) {
+	return ss.findP(true, t...)
+}
+
+// QueryP performs a non-blocking query for a tuple from sharded space ss with template t.
+// QueryP tries the shards in order if the key fields of t are formal.
+// QueryP returns the matched tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) QueryP(t ...interface{}) (tp container.Tuple, e error) {
+	return ss.findP(false, t...)
+}
+
+// findP performs a non-blocking retrieval or query on the shards responsible for template t.
+func (ss *ShardedSpace) findP(remove bool, t ...interface{}) (tp container.Tuple, e error) {
+	i, concrete := ss.shard(t)
+
+	var candidates []*Space
+	if concrete {
+		if i >= 0 {
+			candidates = []*Space{&ss.shards[i]}
+		}
+	} else {
+		candidates = ss.members()
+	}
+
+	tp, e = container.NewTuple(nil), errNoSpaces
+
+	for _, spc := range candidates {
+		if remove {
+			tp, e = spc.GetP(t...)
+		} else {
+			tp, e = spc.QueryP(t...)
+		}
+
+		if e == nil {
+			break
+		}
+	}
+
+	return tp, e
+}
+
+// GetAll performs a non-blocking retrieval for all tuples from sharded space ss with template t.
+// GetAll merges the tuples from all shards if the key fields of t are formal.
+// GetAll returns the matching tuples ts and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) GetAll(t ...interface{}) (ts []container.Tuple, e error) {
+	return ss.findAll(true, t...)
+}
+
+// QueryAll performs a non-blocking query for all tuples from sharded space ss with template t.
+// QueryAll merges the tuples from all shards if the key fields of t are formal.
+// QueryAll returns the matching tuples ts and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (ss *ShardedSpace) QueryAll(t ...interface{}) (ts []container.Tuple, e error) {
+	return ss.findAll(false, t...)
+}
+
+// findAll performs a non-blocking retrieval or query for all tuples on the shards responsible for template t.
+// findAll returns the tuples collected so far together with the first error that occured.
+func (ss *ShardedSpace) findAll(remove bool, t ...interface{}) (ts []container.Tuple, e error) {
+	i, concrete := ss.shard(t)
+
+	var candidates []*Space
+	if concrete {
+		if i >= 0 {
+			candidates = []*Space{&ss.shards[i]}
+		}
+	} else {
+		candidates = ss.members()
+	}
+
+	ts = []container.Tuple{}
+
+	if len(candidates) == 0 {
+		return ts, errNoSpaces
+	}
+
+	for _, spc := range candidates {
+		var sts []container.Tuple
+		var err error
+
+		if remove {
+			sts, err = spc.GetAll(t...)
+		} else {
+			sts, err = spc.QueryAll(t...)
+		}
+
+		ts = append(ts, sts...)
+
+		if err != nil && e == nil {
+			e = err
+		}
+	}
+
+	return ts, e
+}
@@ -0,0 +1,81 @@
+package space
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func createTestShardedSpace(name string, ports ...string) ShardedSpace {
+	shards := make([]Space, len(ports))
+	for i, port := range ports {
+		shards[i] = NewSpace("tcp://localhost:" + port + "/" + name)
+	}
+
+	return NewShardedSpace(shards)
+}
+
+func TestShardedSpaceConcreteKey(t *testing.T) {
+	// Setup
+	ss := createTestShardedSpace("sharded-concrete", "31601", "31602", "31603")
+
+	for i := 0; i < 12; i++ {
+		ss.Put(i, "job")
+	}
+
+	// Every tuple with the same key must be placed in the same shard.
+	i, _ := ss.shard([]interface{}{7})
+	tuples, err := ss.shards[i].QueryAll(7, "job")
+	if err != nil || len(tuples) != 1 {
+		t.Errorf("QueryAll() on shard %d gave %v and %v, should contain the tuple (7, \"job\")", i, tuples, err)
+	}
+
+	sz, err := ss.Size()
+	if err != nil || sz != 12 {
+		t.Errorf("Size() gave %d and %v, should be %d", sz, err, 12)
+	}
+
+	tp, err := ss.GetP(7, "job")
+	if err != nil || !reflect.DeepEqual(tp.Fields(), []interface{}{7, "job"}) {
+		t.Errorf("GetP() gave %v and %v, should be %v", tp, err, []interface{}{7, "job"})
+	}
+}
+
+func TestShardedSpaceFormalKey(t *testing.T) {
+	// Setup
+	ss := createTestShardedSpace("sharded-formal", "31611", "31612", "31613")
+
+	for i := 0; i < 6; i++ {
+		ss.Put(i, "job")
+	}
+
+	var i int
+	var s string
+	tuples, err := ss.GetAll(&i, &s)
+	if err != nil || len(tuples) != 6 {
+		t.Errorf("GetAll() gave %v and %v, should contain %d tuples", tuples, err, 6)
+	}
+
+	// A blocking retrieval must be satisfied by whichever shard receives a tuple first.
+	result := make(chan []interface{}, 2)
+	for k := 0; k < 2; k++ {
+		go func() {
+			tp, _ := ss.Get(&i, "task")
+			result <- tp.Fields()
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	ss.Put(4, "task")
+	ss.Put(5, "task")
+
+	first, second := <-result, <-result
+	if reflect.DeepEqual(first, second) {
+		t.Errorf("Get() delivered %v twice", first)
+	}
+
+	sz, _ := ss.Size()
+	if sz != 0 {
+		t.Errorf("Size() gave %d, should be %d", sz, 0)
+	}
+}
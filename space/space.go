@@ -1,12 +1,20 @@
 package space
 
 import (
+	"fmt"
+	"log"
 	"reflect"
 
 	"github.com/google/uuid"
+	"github.com/pspaces/gospace/auth"
 	"github.com/pspaces/gospace/container"
+	"github.com/pspaces/gospace/discovery"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/metrics"
 	"github.com/pspaces/gospace/policy"
 	"github.com/pspaces/gospace/protocol"
+	"github.com/pspaces/gospace/tracing"
+	"github.com/pspaces/gospace/transport"
 )
 
 // Interspace defines the internal space interface.
@@ -61,6 +69,7 @@ type Space struct {
 }
 
 // NewSpace creates an empty space s with the specified URL.
+// The space is governed by the composable policies cp, which are composed sequentially if there are several.
 func NewSpace(url string, cp ...*policy.Composable) (s Space) {
 	id := uuid.New()
 	sid, err := id.MarshalText()
@@ -77,10 +86,15 @@ func NewSpace(url string, cp ...*policy.Composable) (s Space) {
 }
 
 // NewRemoteSpace connects to a remote space rs with the specified URL.
+// NewRemoteSpace accepts logical names such as discover:///space, which are resolved through the discovery package.
 func NewRemoteSpace(url string) (rs Space) {
 	id := uuid.New()
 	sid, err := id.MarshalText()
 
+	if err == nil {
+		url, err = discovery.Resolve(url)
+	}
+
 	if err == nil {
 		p, ts := NewRemoteSpaceAlt(url)
 		rs = Space{string(sid), ts, p}
@@ -89,6 +103,424 @@ func NewRemoteSpace(url string) (rs Space) {
 	return rs
 }
 
+// WithLabels returns a space ls referring to the same space as s, whose operations are performed by a caller holding the labels lbls.
+// Policies of the space may require labels of the caller in order to permit an operation.
+// The labels are only trusted by a space without an authenticator, and hence restrict nothing a caller could not declare itself.
+func (s *Space) WithLabels(lbls container.Labels) (ls Space) {
+	if s != nil {
+		ls = *s
+
+		if s.p != nil {
+			p := *s.p
+			p.SetLabels(lbls)
+			ls.p = &p
+		}
+	}
+
+	return ls
+}
+
+// WithIdentity returns a space is referring to the same space as s, whose operations are performed by the principal proving identity id.
+// Spaces with an authenticator grant the caller the labels of the authenticated principal, rather than any labels it declares.
+func (s *Space) WithIdentity(id auth.Identity) (is Space) {
+	if s != nil {
+		is = *s
+
+		if s.p != nil {
+			p := *s.p
+			p.SetIdentity(id)
+			is.p = &p
+		}
+	}
+
+	return is
+}
+
+// WithTrace returns a space ts referring to the same space as s, whose operations are traced as part of the span identified by span context sc.
+// Spaces hosting a traced space continue the trace when handling the operations, even if the operations are not traced by s itself.
+func (s *Space) WithTrace(sc tracing.SpanContext) (ts Space) {
+	if s != nil {
+		ts = *s
+
+		if s.p != nil {
+			p := *s.p
+			p.SetTrace(sc)
+			ts.p = &p
+		}
+	}
+
+	return ts
+}
+
+// WithTransport returns a space cs referring to the same space as s, whose operations connect to it through transport t.
+// Tests use it to connect from a host of an in-memory network, such that partitions between hosts apply to the operations.
+func (s *Space) WithTransport(t transport.Transport) (cs Space) {
+	if s != nil {
+		cs = *s
+
+		if s.p != nil {
+			p := *s.p
+			p.SetTransport(t)
+			cs.p = &p
+		}
+	}
+
+	return cs
+}
+
+// SetAuthenticator makes the space hosted by s authenticate every peer with authenticator a, or stop authenticating peers if a is nil.
+// Once set, operations by peers failing authentication are refused, and policies are evaluated against the labels of the authenticated principal.
+// SetAuthenticator returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetAuthenticator(a auth.Authenticator) (b bool) {
+	b = s != nil && s.ts != nil
+
+	if b {
+		s.ts.setAuthenticator(a)
+	}
+
+	return b
+}
+
+// SetAuditLog makes the space hosted by s record the principal and outcome of every operation in logger l, or stop recording if l is nil.
+// SetAuditLog returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetAuditLog(l *log.Logger) (b bool) {
+	b = s != nil && s.ts != nil
+
+	if b {
+		s.ts.setAuditLog(l)
+	}
+
+	return b
+}
+
+// SetMetrics makes the space hosted by s report its operations, tuples, waiting clients, connections and traffic to sink,
+// e.g. a metrics.Registry serving them to Prometheus, or stop reporting them if sink is nil.
+// SetMetrics returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetMetrics(sink metrics.Sink) (b bool) {
+	b = s != nil && s.ts != nil
+
+	if b {
+		s.ts.setMetrics(sink)
+	}
+
+	return b
+}
+
+// SetLogger makes space s log to l, e.g. a *slog.Logger, or to the default logger of the logging package if l is nil.
+// Failed operations of s are logged with the name and address of the space, the operation and its template.
+// If s is hosting the space, its failures in handling peers are logged as well.
+// SetLogger returns false if s is not connected to a space, and true otherwise.
+func (s *Space) SetLogger(l logging.Logger) (b bool) {
+	b = s != nil && s.p != nil
+
+	if b {
+		s.p.SetLogger(l)
+	}
+
+	if b && s.ts != nil {
+		s.ts.setLogger(l)
+	}
+
+	return b
+}
+
+// SetTracer makes space s trace its operations with tracer t, or stop tracing them if t is nil.
+// Operations are traced as client spans with spans for establishing the connection and sending the message, and the span context is sent to the space.
+// If s is hosting the space, handling the operations of peers is traced as server spans with spans for waiting for tuples and encoding the response.
+// SetTracer returns false if s is not connected to a space, and true otherwise.
+func (s *Space) SetTracer(t *tracing.Tracer) (b bool) {
+	b = s != nil && s.p != nil
+
+	if b {
+		s.p.SetTracer(t)
+	}
+
+	if b && s.ts != nil {
+		s.ts.setTracer(t)
+	}
+
+	return b
+}
+
+// SetAdmins makes the principals named names the administrators of the space hosted by s, replacing any previous administrators.
+// Only administrators may inspect the tuples, waiting clients, connections, policy and functions of the space remotely, or change its policy,
+// and only once authenticated, such that the credentials of the administrators guard the introspection of the space.
+// SetAdmins returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetAdmins(names ...string) (b bool) {
+	b = s != nil && s.ts != nil
+
+	if b {
+		s.ts.setAdmins(names...)
+	}
+
+	return b
+}
+
+// SetPrincipalLimit limits the number of operations the principal named name may have in progress at the space hosted by s to n.
+// Unauthenticated peers share the limit of the principal named auth.Anonymous. A limit n less than 1 removes the limit.
+// SetPrincipalLimit returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetPrincipalLimit(name string, n int) (b bool) {
+	b = s != nil && s.ts != nil
+
+	if b {
+		s.ts.setPrincipalLimit(name, n)
+	}
+
+	return b
+}
+
+// SetProvenance makes the space hosted by s label every aggregate with the union of the labels of the tuples it is derived from, or stop doing so if b is false.
+// Aggregates derived from labelled tuples are then labelled as well, such that consumers can tell their sources apart by label.
+// Labelled tuples transformed by a policy when they are retrieved likewise keep their labels.
+// SetProvenance returns false if s is not hosting the space, and true otherwise.
+func (s *Space) SetProvenance(b bool) (ok bool) {
+	ok = s != nil && s.ts != nil
+
+	if ok {
+		s.ts.setProvenance(b)
+	}
+
+	return ok
+}
+
+// SetPolicy replaces the composable policy of space s by cp, e.g. with one loaded by policy.LoadFile.
+// The policy is swapped atomically, and operations in progress, including blocked ones, are not disrupted.
+// A remote space refuses the change unless the caller is an authenticated administrator, and its current policy has a rule permitting the SetPolicy action to the caller.
+// SetPolicy returns the version v of the new policy, which refusals and audit logs of the space mention.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) SetPolicy(cp *policy.Composable) (v int, e error) {
+	var result int
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawSetPolicy(cp)
+		result = rawres.(int)
+		status = rawerr
+	} else {
+		result = -1
+	}
+
+	e = NewSpaceError(s, -1, status)
+
+	if e == nil {
+		v = result
+	} else {
+		v = -1
+	}
+
+	return v, e
+}
+
+// RawSetPolicy replaces the composable policy of space s by cp without any error checking.
+// RawSetPolicy returns the implementation result v and error state e.
+func (s *Space) RawSetPolicy(cp *policy.Composable) (v interface{}, e interface{}) {
+	var err error
+
+	if s.ts != nil {
+		v = s.ts.setPolicy(cp)
+	} else {
+		v, err = setPolicyOperation(*s.p, cp)
+	}
+
+	e = rawState(err == nil, err)
+
+	return v, e
+}
+
+// UpdatePolicy patches the composable policy of space s, replacing the aggregation policies with the labels of those in cp,
+// adding the other aggregation policies of cp, and removing the aggregation policies labelled by remove.
+// The patch is applied atomically as described by SetPolicy, and requires an administrator permitted the UpdatePolicy action at a remote space.
+// UpdatePolicy returns the version v of the patched policy.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) UpdatePolicy(cp *policy.Composable, remove ...container.Label) (v int, e error) {
+	var result int
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawUpdatePolicy(cp, remove...)
+		result = rawres.(int)
+		status = rawerr
+	} else {
+		result = -1
+	}
+
+	e = NewSpaceError(s, -1, status)
+
+	if e == nil {
+		v = result
+	} else {
+		v = -1
+	}
+
+	return v, e
+}
+
+// RawUpdatePolicy patches the composable policy of space s without any error checking.
+// RawUpdatePolicy returns the implementation result v and error state e.
+func (s *Space) RawUpdatePolicy(cp *policy.Composable, remove ...container.Label) (v interface{}, e interface{}) {
+	var err error
+
+	if s.ts != nil {
+		v, err = s.ts.updatePolicy(cp, remove...)
+	} else {
+		v, err = updatePolicyOperation(*s.p, cp, remove...)
+	}
+
+	e = rawState(err == nil, err)
+
+	return v, e
+}
+
+// ExplainPolicy explains how the composable policy of space s decides the operation named operation, e.g. Get, on the template given by template,
+// for the caller of s. The explanation lists the aggregation policies considered and their specificity, the one applying, and the transformations it would apply.
+// No tuples are touched. A remote space refuses to explain unless a rule of its policy permits the ExplainPolicy action with the name of the operation to the caller.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) ExplainPolicy(operation string, template ...interface{}) (x policy.Explanation, e error) {
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawExplainPolicy(operation, template...)
+		x, _ = rawres.(policy.Explanation)
+		status = rawerr
+	}
+
+	e = NewSpaceError(s, operation, status)
+
+	return x, e
+}
+
+// RawExplainPolicy explains the composable policy of space s for an operation without any error checking.
+// RawExplainPolicy returns the implementation result v and error state e.
+func (s *Space) RawExplainPolicy(operation string, template ...interface{}) (v interface{}, e interface{}) {
+	var err error
+
+	if s.ts != nil {
+		var lbls container.Labels
+		if s.p != nil {
+			lbls = s.p.GetLabels()
+		}
+
+		v, err = s.ts.explainPolicy(operation, container.NewTemplate(template...), lbls)
+	} else {
+		v, err = explainPolicyOperation(*s.p, operation, template...)
+	}
+
+	e = rawState(err == nil, err)
+
+	return v, e
+}
+
+// InspectTuples returns the page of at most limit tuples stored in space s matching template, starting at the match with index offset.
+// All tuples are inspected if template is empty, and all matches from offset if limit is less than 1. No tuples are touched.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectTuples(offset int, limit int, template ...interface{}) (page TuplePage, e error) {
+	fields := make([]interface{}, len(template)+2)
+	fields[0] = offset
+	fields[1] = limit
+	copy(fields[2:], template)
+
+	e = s.inspect(protocol.InspectTuplesRequest, fields, &page)
+
+	return page, e
+}
+
+// InspectTuplesAfter returns the page of at most limit tuples stored in space s matching template, placed after cursor.
+// The page tells the cursor of its last tuple, from which the following page is inspected, such that tuples are neither skipped nor repeated
+// by paging through a space while tuples are retrieved from it. The matching tuples are only counted if cursor is the zero cursor.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectTuplesAfter(cursor TupleCursor, limit int, template ...interface{}) (page TuplePage, e error) {
+	fields := make([]interface{}, len(template)+2)
+	fields[0] = cursor
+	fields[1] = limit
+	copy(fields[2:], template)
+
+	e = s.inspect(protocol.InspectTuplesRequest, fields, &page)
+
+	return page, e
+}
+
+// InspectWaitingClients returns the operations waiting for tuples in space s, the longest waiting first.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectWaitingClients() (waiting []WaitingClientInfo, e error) {
+	e = s.inspect(protocol.InspectWaitingClientsRequest, nil, &waiting)
+	return waiting, e
+}
+
+// InspectConnections returns the connections from peers being handled by space s, the oldest first.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectConnections() (conns []ConnectionInfo, e error) {
+	e = s.inspect(protocol.InspectConnectionsRequest, nil, &conns)
+	return conns, e
+}
+
+// InspectPolicy returns the version of the policy governing space s, and the policy in the format of policy files, or its parts in that format if it is composite.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectPolicy() (pi PolicyInfo, e error) {
+	e = s.inspect(protocol.InspectPolicyRequest, nil, &pi)
+	return pi, e
+}
+
+// InspectFunctions returns the namespaces of the functions registered at space s.
+// A remote space refuses to be inspected unless the caller has been authenticated as one of its administrators.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) InspectFunctions() (names []string, e error) {
+	e = s.inspect(protocol.InspectFunctionsRequest, nil, &names)
+	return names, e
+}
+
+// inspect takes the snapshot of space s requested by the introspection operation of messages with operation operation and template fields,
+// and stores it in v, which must point to a value of the type of the snapshot.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) inspect(operation string, fields []interface{}, v interface{}) (e error) {
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawInspect(operation, fields...)
+
+		if rawres != nil {
+			reflect.ValueOf(v).Elem().Set(reflect.ValueOf(rawres))
+		}
+
+		status = rawerr
+	}
+
+	e = NewSpaceError(s, operationName(operation), status)
+
+	return e
+}
+
+// RawInspect takes a snapshot of space s for the introspection operation of messages with operation operation and template fields without any error checking.
+// RawInspect returns the implementation result v and error state e.
+func (s *Space) RawInspect(operation string, fields ...interface{}) (v interface{}, e interface{}) {
+	var err error
+
+	if s.ts != nil {
+		v, err = s.ts.inspect(operation, container.NewTemplate(fields...))
+	} else {
+		snapshot := newSnapshot(operation)
+
+		if snapshot != nil {
+			err = inspectOperation(*s.p, operation, fields, snapshot)
+			v = reflect.ValueOf(snapshot).Elem().Interface()
+		} else {
+			err = fmt.Errorf("unknown introspection operation %s", operation)
+		}
+	}
+
+	if err != nil {
+		v = nil
+	}
+
+	e = rawState(err == nil, err)
+
+	return v, e
+}
+
 // ID returns the identifier for space s.
 // Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
 func (s *Space) ID() (id string, e error) {
@@ -130,7 +562,8 @@ func (s *Space) Size() (sz int, e error) {
 // RawSize retrieves the size of space s at this instant without any error checking.
 // RawSize returns the implementation result sz and error state e.
 func (s *Space) RawSize() (sz interface{}, e interface{}) {
-	sz, e = Size(*s.p)
+	sz, err := sizeOperation(*s.p)
+	e = rawState(err == nil, err)
 	return sz, e
 }
 
@@ -163,7 +596,8 @@ func (s *Space) Put(t ...interface{}) (tp container.Tuple, e error) {
 // RawPut performs a blocking placement of a tuple t into space s without any error checking.
 // RawPut returns the implementation result tp and error state e.
 func (s *Space) RawPut(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = Put(*s.p, t...)
+	tp, err := putOperation(*s.p, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -196,7 +630,8 @@ func (s *Space) Get(t ...interface{}) (tp container.Tuple, e error) {
 // RawGet performs a blocking retrieval a tuple from space s with template t and without any error checking.
 // RawGet returns the implementation result tp and error state e.
 func (s *Space) RawGet(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = Get(*s.p, t...)
+	tp, err := getAndQueryUntil(*s.p, protocol.GetRequest, nil, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -229,7 +664,8 @@ func (s *Space) Query(t ...interface{}) (tp container.Tuple, e error) {
 // RawQuery performs a blocking query for a tuple from space s with template t and without any error checking.
 // RawQuery returns the implementation result tp and error state e.
 func (s *Space) RawQuery(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = Query(*s.p, t...)
+	tp, err := getAndQueryUntil(*s.p, protocol.QueryRequest, nil, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -262,7 +698,8 @@ func (s *Space) PutP(t ...interface{}) (tp container.Tuple, e error) {
 // RawPutP performs a non-blocking placement of a tuple t into space s without any error checking.
 // RawPutP returns the implementation result tp and error state e.
 func (s *Space) RawPutP(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = PutP(*s.p, t...)
+	tp, err := putPOperation(*s.p, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -295,7 +732,8 @@ func (s *Space) GetP(t ...interface{}) (tp container.Tuple, e error) {
 // RawGetP performs a non-blocking retrieval a tuple from space s with template t and without any error checking.
 // RawGetP returns the implementation result tp and error state e.
 func (s *Space) RawGetP(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e, _ = GetP(*s.p, t...)
+	tp, found, err := getPAndQueryP(*s.p, protocol.GetPRequest, t...)
+	e = rawState(found, err)
 	return tp, e
 }
 
@@ -328,7 +766,8 @@ func (s *Space) QueryP(t ...interface{}) (tp container.Tuple, e error) {
 // RawQueryP performs a blocking query for a tuple from space s with template t and without any error checking.
 // RawQueryP returns the implementation result tp and error state e.
 func (s *Space) RawQueryP(t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e, _ = QueryP(*s.p, t...)
+	tp, found, err := getPAndQueryP(*s.p, protocol.QueryPRequest, t...)
+	e = rawState(found, err)
 	return tp, e
 }
 
@@ -361,7 +800,8 @@ func (s *Space) GetAll(t ...interface{}) (ts []container.Tuple, e error) {
 // RawGetAll performs a non-blocking retrieval for all tuples from space s with template t and without any error checking.
 // RawGetAll returns the implementation result ts and error state e.
 func (s *Space) RawGetAll(t ...interface{}) (ts interface{}, e interface{}) {
-	ts, e = GetAll(*s.p, t...)
+	ts, err := getAllAndQueryAll(*s.p, protocol.GetAllRequest, t...)
+	e = rawState(true, err)
 	return ts, e
 }
 
@@ -394,7 +834,8 @@ func (s *Space) QueryAll(t ...interface{}) (ts []container.Tuple, e error) {
 // RawQueryAll performs a non-blocking query for all tuples from space s with template t and without any error checking.
 // RawQueryAll returns the implementation result ts and error state e.
 func (s *Space) RawQueryAll(t ...interface{}) (ts interface{}, e interface{}) {
-	ts, e = QueryAll(*s.p, t...)
+	ts, err := getAllAndQueryAll(*s.p, protocol.QueryAllRequest, t...)
+	e = rawState(true, err)
 	return ts, e
 }
 
@@ -430,7 +871,8 @@ func (s *Space) PutAgg(f interface{}, t ...interface{}) (tp container.Tuple, e e
 // RawPutAgg uses an aggregation function f to aggregate a pair of tuples into one.
 // RawPutAgg returns the implementation result tp and error state e.
 func (s *Space) RawPutAgg(f interface{}, t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = PutAgg(*s.p, f, t...)
+	tp, err := aggOperation(*s.p, protocol.PutAggRequest, f, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -465,7 +907,8 @@ func (s *Space) GetAgg(f interface{}, t ...interface{}) (tp container.Tuple, e e
 // RawGetAgg uses an aggregation function f to aggregate a pair of tuples into one.
 // RawGetAgg returns the implementation result tp and error state e.
 func (s *Space) RawGetAgg(f interface{}, t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = GetAgg(*s.p, f, t...)
+	tp, err := aggOperation(*s.p, protocol.GetAggRequest, f, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
@@ -500,10 +943,161 @@ func (s *Space) QueryAgg(f interface{}, t ...interface{}) (tp container.Tuple, e
 // RawQueryAgg uses an aggregation function f to aggregate a pair of tuples into one.
 // RawQueryAgg returns the implementation result tp and error state e.
 func (s *Space) RawQueryAgg(f interface{}, t ...interface{}) (tp interface{}, e interface{}) {
-	tp, e = QueryAgg(*s.p, f, t...)
+	tp, err := aggOperation(*s.p, protocol.QueryAggRequest, f, t...)
+	e = rawState(true, err)
 	return tp, e
 }
 
+// GetAggN performs a blocking aggregation retrieval on n tuples from space s that match template t.
+// GetAggN waits until at least n tuples match t, and then atomically removes exactly n of them and aggregates them with an aggregation function f.
+// GetAggN returns an aggregate tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) GetAggN(n int, f interface{}, t ...interface{}) (tp container.Tuple, e error) {
+	var result container.Tuple
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawGetAggN(n, f, t...)
+		result = rawres.(container.Tuple)
+		status = rawerr
+	} else {
+		result = container.NewTuple(nil)
+	}
+
+	e = NewSpaceError(s, container.NewTemplate(t...), status)
+
+	if e == nil {
+		tp = result
+	} else {
+		tp = container.NewTuple(nil)
+	}
+
+	return tp, e
+}
+
+// RawGetAggN performs a blocking aggregation retrieval on n tuples from space that match template t and without any error checking.
+// RawGetAggN uses an aggregation function f to aggregate the tuples.
+// RawGetAggN returns the implementation result tp and error state e.
+func (s *Space) RawGetAggN(n int, f interface{}, t ...interface{}) (tp interface{}, e interface{}) {
+	tp, err := aggNOperation(*s.p, protocol.GetAggNRequest, n, f, t...)
+	e = rawState(true, err)
+	return tp, e
+}
+
+// QueryAggN performs a blocking aggregation query on n tuples from space s that match template t.
+// QueryAggN waits until at least n tuples match t, and then atomically aggregates exactly n of them with an aggregation function f.
+// QueryAggN returns an aggregate tuple tp and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) QueryAggN(n int, f interface{}, t ...interface{}) (tp container.Tuple, e error) {
+	var result container.Tuple
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawQueryAggN(n, f, t...)
+		result = rawres.(container.Tuple)
+		status = rawerr
+	} else {
+		result = container.NewTuple(nil)
+	}
+
+	e = NewSpaceError(s, container.NewTemplate(t...), status)
+
+	if e == nil {
+		tp = result
+	} else {
+		tp = container.NewTuple(nil)
+	}
+
+	return tp, e
+}
+
+// RawQueryAggN performs a blocking aggregation query on n tuples from space that match template t and without any error checking.
+// RawQueryAggN uses an aggregation function f to aggregate the tuples.
+// RawQueryAggN returns the implementation result tp and error state e.
+func (s *Space) RawQueryAggN(n int, f interface{}, t ...interface{}) (tp interface{}, e interface{}) {
+	tp, err := aggNOperation(*s.p, protocol.QueryAggNRequest, n, f, t...)
+	e = rawState(true, err)
+	return tp, e
+}
+
+// Eval performs a non-blocking evaluation of function f with arguments args at the host of space s.
+// Eval requires that f is registered in the function registry at the host of s.
+// Once f returns, its result is placed into s as a tuple; a returned tuple is placed as is, and otherwise the results of f become the fields of the tuple.
+// If the evaluation fails, an error tuple with EvalError as its first field is placed into s instead.
+// Eval returns a tuple tp containing f and its arguments, and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) Eval(f interface{}, args ...interface{}) (tp container.Tuple, e error) {
+	var result container.Tuple
+	var status interface{}
+
+	if s != nil {
+		rawres, rawerr := (*s).RawEval(f, args...)
+		result = rawres.(container.Tuple)
+		status = rawerr
+	} else {
+		result = container.NewTuple(nil)
+	}
+
+	fields := make([]interface{}, len(args)+1)
+	fields[0] = f
+	copy(fields[1:], args)
+
+	e = NewSpaceError(s, container.NewTuple(fields...), status)
+
+	if e == nil {
+		tp = result
+	} else {
+		tp = container.NewTuple(nil)
+	}
+
+	return tp, e
+}
+
+// RawEval performs a non-blocking evaluation of function f with arguments args at the host of space s and without any error checking.
+// RawEval returns the implementation result tp and error state e.
+func (s *Space) RawEval(f interface{}, args ...interface{}) (tp interface{}, e interface{}) {
+	tp, err := evalOperation(*s.p, f, args...)
+	e = rawState(true, err)
+	return tp, e
+}
+
+// EvalLocal performs a non-blocking evaluation of function f with arguments args in the calling process.
+// Once f returns, its result or an error tuple is placed into space s in the same way as for Eval.
+// EvalLocal returns a tuple tp containing f and its arguments, and an error e.
+// Error e contains a structure adhering to the error interface if the operation fails, and nil if no error occured.
+func (s *Space) EvalLocal(f interface{}, args ...interface{}) (tp container.Tuple, e error) {
+	fields := make([]interface{}, len(args)+1)
+	fields[0] = f
+	copy(fields[1:], args)
+
+	e = NewSpaceError(s, container.NewTuple(fields...), s != nil)
+
+	if e != nil {
+		return container.NewTuple(nil), e
+	}
+
+	go func(args []interface{}) {
+		res := evaluate(f, args)
+		s.Put(res.Fields()...)
+	}(fields[1:])
+
+	tp = container.NewTuple(fields...)
+
+	return tp, e
+}
+
+// rawState returns the error state e of an operation given its outcome b and the error err that occured.
+// The error state is the error itself if one occured, and the outcome otherwise.
+func rawState(b bool, err error) (e interface{}) {
+	if err != nil {
+		e = err
+	} else {
+		e = b
+	}
+
+	return e
+}
+
 // InterpretError returns an error message msg given a return state by an operation.
 // The state is given by the implementation and this method maps from the state to sane
 // error messages. This is an internal method and may change without notice.
@@ -523,7 +1117,9 @@ func (s *Space) InterpretError(state interface{}) (msg string) {
 	}
 
 	if s != nil {
-		if state != nil {
+		if err, ok := state.(error); ok {
+			msg = fmt.Sprintf("%s: %s", errMsg[InterOperationFailed], err)
+		} else if state != nil {
 			status := state.(bool)
 
 			if status {
@@ -548,7 +1144,7 @@ func (s *Space) InterpretOperation(state interface{}) (status bool) {
 	status = false
 
 	if s != nil && state != nil {
-		status = state.(bool)
+		status, _ = state.(bool)
 	}
 
 	return status
@@ -0,0 +1,100 @@
+package space
+
+import (
+	"net"
+
+	"github.com/pspaces/gospace/protocol"
+	"github.com/pspaces/gospace/tracing"
+)
+
+// setTracer sets the tracer of tuple space ts, or stops tracing if t is nil.
+func (ts *TupleSpace) setTracer(t *tracing.Tracer) {
+	g := ts.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.tracer = t
+}
+
+// tracer returns the tracer t of tuple space ts, or nil if it is not traced.
+func (ts *TupleSpace) tracer() (t *tracing.Tracer) {
+	g := ts.guard
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.tracer
+}
+
+// traceOperation starts the client span of the operation requested through the PointToPoint ptp,
+// and makes it the parent of the spans of ptp, including those of the space handling the operation.
+// traceOperation returns a function end which must be called with the error of the operation once it has finished.
+func traceOperation(ptp *protocol.PointToPoint, operation string) (end func(e *error)) {
+	span := ptp.GetTracer().Start(ptp.GetTrace(), operationName(operation), tracing.Client)
+
+	if span == nil {
+		return func(e *error) {}
+	}
+
+	span.SetAttribute("space", ptp.GetName())
+	span.SetAttribute("remote", ptp.GetAddress())
+
+	ptp.SetTrace(span.SpanContext())
+
+	end = func(e *error) {
+		span.SetError(*e)
+		span.End()
+	}
+
+	return end
+}
+
+// startSpan starts the internal span s named name as part of the operation requested through the PointToPoint ptp, or returns nil if it is not traced.
+func startSpan(ptp protocol.PointToPoint, name string) (s *tracing.Span) {
+	if sc := ptp.GetTrace(); sc.IsValid() {
+		s = ptp.GetTracer().Start(sc, name, tracing.Internal)
+	}
+
+	return s
+}
+
+// tracedConn is a connection to a peer whose operation is traced by a server span.
+type tracedConn struct {
+	net.Conn
+	span *tracing.Span
+}
+
+// traceConnection returns connection conn to a peer as part of span s, or conn itself if s is nil.
+func traceConnection(conn net.Conn, s *tracing.Span) (tc net.Conn) {
+	if s == nil {
+		return conn
+	}
+
+	tc = &tracedConn{Conn: conn, span: s}
+
+	return tc
+}
+
+// spanOf returns the span s tracing the operation of the peer at the other end of connection conn, or nil if it is not traced.
+func spanOf(conn net.Conn) (s *tracing.Span) {
+	if tc, ok := conn.(*tracedConn); ok {
+		s = tc.span
+	}
+
+	return s
+}
+
+// encodeResponse encodes the response v to the operation of the peer at the other end of connection conn.
+// encodeResponse returns an error err if the response could not be encoded.
+func encodeResponse(conn net.Conn, v interface{}) (err error) {
+	span := spanOf(conn).Child("encodeResponse")
+	defer span.End()
+
+	enc := encoderOf(conn)
+	err = enc.Encode(v)
+
+	span.SetError(err)
+
+	return err
+}
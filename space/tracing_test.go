@@ -0,0 +1,100 @@
+package space
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/tracing"
+)
+
+// awaitSpans waits until exporter exp has at least n spans of the trace identified by id, and returns them.
+func awaitSpans(exp *tracing.InMemoryExporter, id tracing.TraceID, n int) (spans []*tracing.Span) {
+	for i := 0; i < 100 && len(spans) < n; i++ {
+		time.Sleep(10 * time.Millisecond)
+		spans = exp.Trace(id)
+	}
+
+	return spans
+}
+
+// findSpan returns the span named name of the given kind among spans, or nil if there is none.
+func findSpan(spans []*tracing.Span, name string, kind tracing.Kind) (s *tracing.Span) {
+	for _, span := range spans {
+		if span.Name == name && span.Kind == kind {
+			return span
+		}
+	}
+
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	// Setup
+	var x int
+
+	spc := NewSpace("tcp://localhost:31723/tracing")
+
+	exp := tracing.NewInMemoryExporter()
+	if !spc.SetTracer(tracing.NewTracer(exp)) {
+		t.Fatalf("SetTracer() gave false, should be true")
+	}
+
+	parent, _ := tracing.ParseSpanContext("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	traced := spc.WithTrace(parent)
+
+	// Test
+	done := make(chan bool)
+	go func() {
+		traced.Get("result", &x)
+		done <- true
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	spc.Put("result", 42)
+	<-done
+
+	spans := awaitSpans(exp, parent.TraceID, 6)
+
+	client := findSpan(spans, "Get", tracing.Client)
+	server := findSpan(spans, "Get", tracing.Server)
+
+	if client == nil || server == nil {
+		t.Fatalf("Traced %d spans of the Get operation, should trace a client and a server span", len(spans))
+	}
+
+	if client.Parent != parent.SpanID {
+		t.Errorf("Client span has parent %s, should be %s", client.Parent, parent.SpanID)
+	}
+
+	if server.Parent != client.Context.SpanID {
+		t.Errorf("Server span has parent %s, should be the client span %s", server.Parent, client.Context.SpanID)
+	}
+
+	children := []struct {
+		name   string
+		parent *tracing.Span
+	}{
+		{"establishConnection", client},
+		{"sendMessage", client},
+		{"waitingClient", server},
+		{"encodeResponse", server},
+	}
+
+	for _, c := range children {
+		s := findSpan(spans, c.name, tracing.Internal)
+
+		if s == nil {
+			t.Errorf("No span named %s, should be traced", c.name)
+		} else if s.Parent != c.parent.Context.SpanID {
+			t.Errorf("Span %s has parent %s, should be the %s span %s", c.name, s.Parent, c.parent.Kind, c.parent.Context.SpanID)
+		}
+	}
+
+	if wait := findSpan(spans, "waitingClient", tracing.Internal); wait != nil && wait.Duration() < 50*time.Millisecond {
+		t.Errorf("Span waitingClient lasted %s, should last until the tuple was placed", wait.Duration())
+	}
+
+	if put := findSpan(exp.Spans(), "Put", tracing.Client); put == nil || put.Context.TraceID == parent.TraceID {
+		t.Errorf("Put was traced as %v, should start its own trace", put)
+	}
+}
@@ -0,0 +1,154 @@
+package space
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pspaces/gospace/transport"
+)
+
+func TestMemoryTransport(t *testing.T) {
+	// Setup
+	var s string
+	var i int
+
+	network := transport.NewMemory()
+	transport.Register("mem", network)
+
+	node1 := NewSpace("mem://node1/space")
+	node2 := NewSpace("mem://node2/space")
+	remote := NewRemoteSpace("mem://node2/space")
+
+	// Test that spaces are reached by name on each node.
+	if _, err := node1.Put("node", 1); err != nil {
+		t.Fatalf("Put() gave error %v, should place the tuple at node1", err)
+	}
+
+	if _, err := remote.Put("node", 2); err != nil {
+		t.Fatalf("Put() gave error %v, should place the tuple at node2", err)
+	}
+
+	if tp, err := node2.QueryP("node", &i); err != nil || tp.GetFieldAt(1) != 2 {
+		t.Errorf("QueryP() gave %v and error %v at node2, should be (node, 2)", tp, err)
+	}
+
+	if sz, err := node1.Size(); err != nil || sz != 1 {
+		t.Errorf("Size() gave %d and error %v at node1, should be 1", sz, err)
+	}
+
+	// Test that a space created twice is connected to rather than created again.
+	again := NewSpace("mem://node1/space")
+	if tp, err := again.QueryP("node", &i); err != nil || tp.GetFieldAt(1) != 1 {
+		t.Errorf("QueryP() gave %v and error %v through a space created twice, should be (node, 1)", tp, err)
+	}
+
+	// Test that spaces on nodes nobody listens at are unreachable.
+	unreachable := NewRemoteSpace("mem://node3/space")
+	if _, err := unreachable.Size(); err == nil {
+		t.Errorf("Size() gave nil for a node without spaces, should be an error")
+	}
+
+	// Test that waiting clients are served across nodes.
+	done := make(chan string)
+	go func() {
+		tp, _ := node2.Get("wake", &s)
+		done <- tp.GetFieldAt(1).(string)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	remote.Put("wake", "up")
+
+	select {
+	case msg := <-done:
+		if msg != "up" {
+			t.Errorf("Get() gave %s to a waiting client, should be up", msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Get() did not return after a matching tuple was placed")
+	}
+
+	// Test that dropped connections fail the operations using them.
+	network.Drop("node1", 1)
+	if _, err := node1.QueryP("node", &i); err == nil {
+		t.Errorf("QueryP() gave nil through a dropped connection, should be an error")
+	}
+
+	if _, err := node1.QueryP("node", &i); err != nil {
+		t.Errorf("QueryP() gave error %v after the dropped connection, should succeed", err)
+	}
+
+	// Test that partitioned nodes are unreachable until healed, while others are not affected.
+	network.Partition("node2")
+	if _, err := remote.QueryP("node", &i); err == nil {
+		t.Errorf("QueryP() gave nil for a partitioned node, should be an error")
+	}
+
+	if _, err := node1.QueryP("node", &i); err != nil {
+		t.Errorf("QueryP() gave error %v for a node outside the partition, should succeed", err)
+	}
+
+	placed := make(chan error)
+	go func() {
+		_, err := remote.Put("node", 3)
+		placed <- err
+	}()
+
+	select {
+	case <-placed:
+		t.Errorf("Put() returned for a partitioned node, should block until healed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	network.Heal()
+	if err := <-placed; err != nil {
+		t.Errorf("Put() gave error %v after healing, should place the tuple", err)
+	}
+
+	if ts, err := remote.QueryAll("node", &i); err != nil || len(ts) != 2 {
+		t.Errorf("QueryAll() gave %v and error %v after healing, should be (node, 2) and (node, 3)", ts, err)
+	}
+
+	// Test that nodes split from a node keep reaching each other, but not the isolated node.
+	NewSpace("mem://node3/space")
+
+	at1 := NewRemoteSpace("mem://node1/space")
+	at2 := NewRemoteSpace("mem://node2/space")
+	at3 := NewRemoteSpace("mem://node3/space")
+
+	node1to2 := at2.WithTransport(network.Host("node1"))
+	node2to1 := at1.WithTransport(network.Host("node2"))
+	node1to3 := at3.WithTransport(network.Host("node1"))
+	node3to1 := at1.WithTransport(network.Host("node3"))
+
+	network.Split([]string{"node1", "node2"}, []string{"node3"})
+
+	if _, err := node1to2.Size(); err != nil {
+		t.Errorf("Size() gave error %v from node1 at node2 in the same group, should succeed", err)
+	}
+
+	if _, err := node2to1.Size(); err != nil {
+		t.Errorf("Size() gave error %v from node2 at node1 in the same group, should succeed", err)
+	}
+
+	if _, err := node1to3.Size(); err == nil {
+		t.Errorf("Size() gave nil from node1 at the isolated node3, should be an error")
+	}
+
+	if _, err := node3to1.Size(); err == nil {
+		t.Errorf("Size() gave nil from the isolated node3 at node1, should be an error")
+	}
+
+	network.Heal()
+
+	if _, err := node3to1.Size(); err != nil {
+		t.Errorf("Size() gave error %v from node3 after healing, should succeed", err)
+	}
+
+	// Test that operations are delayed by the latency of the node.
+	network.SetLatency("node2", 20*time.Millisecond)
+
+	start := time.Now()
+	if _, err := remote.Size(); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Size() gave error %v after %v, should succeed after the latency of 20ms", err, time.Since(start))
+	}
+}
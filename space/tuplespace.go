@@ -2,30 +2,49 @@ package space
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
+	"github.com/pspaces/gospace/aggregation"
+	"github.com/pspaces/gospace/auth"
 	"github.com/pspaces/gospace/container"
 	"github.com/pspaces/gospace/function"
+	"github.com/pspaces/gospace/logging"
+	"github.com/pspaces/gospace/metrics"
 	"github.com/pspaces/gospace/policy"
 	"github.com/pspaces/gospace/protocol"
+	"github.com/pspaces/gospace/tracing"
+	"github.com/pspaces/gospace/transport"
 )
 
 // TupleSpace contains a set of tuples and it has a mutex lock associated with
 // it to secure mutual exclusion.
 // Furthermore a port number to locate it.
 type TupleSpace struct {
-	muTuples         *sync.RWMutex            // Lock for the tuples[].
-	muWaitingClients *sync.Mutex              // Lock for the waitingClients[].
-	tuples           []container.Tuple        // Tuples in the tuple space.
-	funReg           *function.Registry       // Function registry associated to the tuple space.
-	pol              *policy.Composable       // Policy associated to the tuple space.
-	port             string                   // Port number for the tuple space.
-	connc            chan *net.Conn           // Connection channel.
-	waitingClients   []protocol.WaitingClient // Structure for clients that couldn't initially find a matching tuple.
+	muTuples         *sync.RWMutex                         // Lock for the tuples[].
+	muWaitingClients *sync.Mutex                           // Lock for the waitingClients[].
+	tuples           []container.Tuple                     // Tuples in the tuple space, in the order they were placed.
+	seqs             []uint64                              // Sequence numbers of the tuples in tuples[], increasing in the order they were placed.
+	placed           uint64                                // Sequence number of the last tuple placed.
+	funReg           *function.Registry                    // Function registry associated to the tuple space.
+	muPolicy         *sync.RWMutex                         // Lock for the pol, polVersion and provenance.
+	pol              *policy.Composable                    // Policy associated to the tuple space.
+	polVersion       int                                   // Version of the policy, increased whenever it changes.
+	provenance       bool                                  // Whether aggregates carry the labels of the tuples they are derived from.
+	name             string                                // Name of the tuple space, or empty if it has none.
+	port             string                                // Port number for the tuple space.
+	connc            chan *net.Conn                        // Connection channel.
+	waitingClients   []protocol.WaitingClient              // Structure for clients that couldn't initially find a matching tuple.
+	waitingSince     map[chan<- *container.Tuple]time.Time // Time each waiting client started waiting, by response channel.
+	guard            *guard                                // Authentication, audit and limits of peers.
+	meter            *meter                                // Measurements of the tuple space.
+	transport        transport.Transport                   // Transport listened through, or nil for TCP.
 }
 
 // CreateTupleSpace creates a new tuple space.
@@ -33,12 +52,11 @@ func CreateTupleSpace(port int) (ts *TupleSpace) {
 	gob.Register(container.Template{})
 	gob.Register(container.Tuple{})
 	gob.Register(container.TypeField{})
+	gob.Register(container.FuncRef{})
 
 	muTuples := new(sync.RWMutex)
 	muWaitingClients := new(sync.Mutex)
 
-	// TODO: Exchange capabilities instead and
-	// TODO: make a mechanism capable of doing that.
 	if function.GlobalRegistry == nil {
 		fr := function.NewRegistry()
 		function.GlobalRegistry = &fr
@@ -50,9 +68,13 @@ func CreateTupleSpace(port int) (ts *TupleSpace) {
 		muWaitingClients: muWaitingClients,
 		tuples:           []container.Tuple{},
 		funReg:           &funcReg,
+		muPolicy:         new(sync.RWMutex),
 		pol:              nil,
 		port:             strconv.Itoa(port),
 		connc:            make(chan *net.Conn),
+		guard:            newGuard(),
+		meter:            newMeter(),
+		waitingSince:     make(map[chan<- *container.Tuple]time.Time),
 	}
 
 	go ts.Listen()
@@ -63,34 +85,42 @@ func CreateTupleSpace(port int) (ts *TupleSpace) {
 // Listen will listen and accept all incoming connections. Once a connection has
 // been established, the connection is passed on to the handler.
 func (ts *TupleSpace) Listen() {
-	defer handleRecover(ts.Listen)
+	defer ts.handleRecover(ts.Listen, nil)
 
-	var listener net.Listener
-	var err error
+	tr := (*ts).transport
 
-	proto := "tcp4"
+	if tr == nil {
+		tr = transport.TCP{}
+	}
 
-	listener, err = net.Listen(proto, (*ts).port)
+	listener, err := tr.Listen((*ts).port)
 
 	if err != nil {
 		err := fmt.Errorf("%s %s. %s: %s", "could not start listener at ", (*ts).port, "Error", err)
 		panic(err)
 	}
 
+	ts.serve(listener)
+}
+
+// serve will accept all incoming connections through listener, and pass them on to the handler.
+func (ts *TupleSpace) serve(listener net.Listener) {
+	defer ts.handleRecover(ts.serve, nil)
+
 	defer listener.Close()
 
 	// Accept remote connections.
-	go func(lp *net.Listener) {
-		l := *lp
+	go func(l net.Listener) {
 		for {
 			c, err := l.Accept()
 
 			if err == nil {
 				ts.connc <- &c
+			} else if errors.Is(err, net.ErrClosed) {
+				return
 			}
-
 		}
-	}(&listener)
+	}(listener)
 
 	// Process all request.
 	for connp := range ts.connc {
@@ -101,85 +131,221 @@ func (ts *TupleSpace) Listen() {
 // handle will read and decode the message from the connection.
 // The decoded message will be passed on to the respective method.
 func (ts *TupleSpace) handle(conn net.Conn) {
-	defer handleRecover(ts.handle)
+	defer ts.handleRecover(ts.handle, conn)
 
 	// Make sure the connection closes when method returns.
 	defer conn.Close()
 
-	// Create decoder to the connection to receive the message.
-	dec := gob.NewDecoder(conn)
+	// Count the connection and the bytes sent through it.
+	conn, done := ts.meterConnection(conn)
+	defer done()
+
+	// List the connection among those being handled, for administrators inspecting the space.
+	pr, untrack := ts.trackConnection(conn)
+	defer untrack()
+
+	// Prepare the connection for authenticating the peer.
+	sconn, err := ts.accept(conn)
+
+	if err != nil {
+		ts.logger().Warn("could not secure connection to peer", peerFields(conn, logging.Error, err)...)
+		return
+	}
 
-	// Read the message from the connection through the decoder.
+	defer sconn.Close()
+
+	// Authenticators inspect the secured connection itself, while messages pass through the codec of the connection.
+	secured := sconn
+	conn = newCodecConn(sconn)
+
+	// Exchange capabilities with the peer and receive its credentials before receiving the message.
+	_, creds, err := ts.handshake(conn)
+
+	if err != nil {
+		ts.logger().Warn("could not exchange capabilities with peer", peerFields(conn, logging.Error, err)...)
+		return
+	}
+
+	// Read the message from the connection through its decoder.
 	var message protocol.Message
-	err := dec.Decode(&message)
+	err = decoderOf(conn).Decode(&message)
+
+	// The peer may abandon the operation after the handshake, e.g. if this space is missing a function.
+	if err == io.EOF {
+		return
+	}
 
 	// Error check for receiving message.
 	if err != nil {
-		err := fmt.Errorf("%s %s. %s: %s", "Could not decode message from peer at", conn.RemoteAddr(), "Error", err)
-		panic(err)
+		ts.logger().Warn("could not decode message from peer", peerFields(conn, logging.Error, err)...)
+		return
 	}
 
 	operation := message.GetOperation()
 	fr := ts.funReg
 
+	start := time.Now()
+	defer func() {
+		ts.meter.observe(metrics.OperationDuration, metrics.Since(start), operationLabel(operation))
+	}()
+
+	// Trace handling the operation, as part of the trace of the peer if it sends one.
+	span := ts.tracer().Start(message.GetTrace(), operationName(operation), tracing.Server)
+	defer span.End()
+
+	span.SetAttribute("space", ts.name)
+	span.SetAttribute("remote", conn.RemoteAddr().String())
+
+	conn = traceConnection(conn, span)
+
+	// Refuse operations referencing functions that can not be resolved, rather than storing their references as strings.
+	err = unresolvedFunction(fr, message.GetBody())
+
+	// Resolve the functions of the body, such that policies can refer to them.
+	switch body := message.GetBody().(type) {
+	case container.Tuple:
+		funcDecode(fr, &body)
+	case container.Template:
+		funcDecode(fr, &body)
+	}
+
+	// Authenticate the peer, and count the operation towards its limit.
+	principal, errAuth := ts.authenticate(secured, creds, message.GetLabels())
+	ts.describeConnection(pr, principal.Name, operation)
+
+	if err == nil {
+		err = errAuth
+	}
+
+	if err == nil {
+		var release func()
+		release, err = ts.admit(principal)
+		defer release()
+	}
+
+	// Find the applicable policy and check that it permits the operation to the peer.
+	var ap *policy.Aggregation
+	var version int
+	if err == nil {
+		ap, version, err = ts.authorize(&message, principal)
+	} else {
+		_, version = ts.currentPolicy()
+	}
+
+	ts.auditOperation(conn, principal, operation, version, err)
+
+	outcome := metrics.Accepted
+	if err != nil {
+		outcome = metrics.Refused
+		span.SetError(err)
+		ts.logger().Debug("operation refused", peerFields(conn, logging.Operation, operationName(operation), logging.Template, fmt.Sprint(message.GetBody()), logging.Error, err)...)
+	}
+
+	ts.meter.count(metrics.Operations, 1, operationLabel(operation), metrics.L("outcome", outcome))
+
+	// Tell the peer whether the operation is accepted before handling it.
+	status := protocol.CreateAcceptance()
+	if err != nil {
+		err = fmt.Errorf("%s (policy version %d)", err, version)
+		status = protocol.CreateRefusal(err.Error())
+	}
+
+	enc := encoderOf(conn)
+	errStatus := enc.Encode(status)
+
+	if errStatus != nil {
+		ts.logger().Warn("could not encode status to peer", peerFields(conn, logging.Error, errStatus)...)
+		return
+	}
+
+	// The refusal has been logged and sent to the peer.
+	if err != nil {
+		return
+	}
+
 	switch operation {
 	case protocol.PutRequest:
 		// Body of message must be a tuple.
 		tuple := message.GetBody().(container.Tuple)
-		funcDecode(fr, &tuple)
+		tuple = tupleTransform(ap, tuple)
 		ts.handlePut(conn, tuple)
 	case protocol.PutPRequest:
 		// Body of message must be a tuple.
 		tuple := message.GetBody().(container.Tuple)
-		funcDecode(fr, &tuple)
+		tuple = tupleTransform(ap, tuple)
 		ts.handlePutP(tuple)
 	case protocol.PutAggRequest:
 		// Body of message must be a function and a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handlePutAgg(conn, template)
+		ts.handlePutAgg(conn, template, ap)
 	case protocol.GetRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleGet(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleGet(conn, template, ap)
 	case protocol.GetPRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleGetP(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleGetP(conn, template, ap)
 	case protocol.GetAllRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleGetAll(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleGetAll(conn, template, ap)
 	case protocol.GetAggRequest:
 		// Body of message must be a function and a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleGetAgg(conn, template)
+		ts.handleGetAgg(conn, template, ap)
+	case protocol.GetAggNRequest:
+		// Body of message must be a number, a function and a template.
+		template := message.GetBody().(container.Template)
+		ts.handleGetAggN(conn, template, ap)
 	case protocol.SizeRequest:
 		ts.handleSize(conn)
 	case protocol.QueryRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleQuery(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleQuery(conn, template, ap)
 	case protocol.QueryPRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleQueryP(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleQueryP(conn, template, ap)
 	case protocol.QueryAllRequest:
 		// Body of message must be a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleQueryAll(conn, template)
+		template = templateTransform(ap, template.Fields())
+		ts.handleQueryAll(conn, template, ap)
 	case protocol.QueryAggRequest:
 		// Body of message must be a function and a template.
 		template := message.GetBody().(container.Template)
-		funcDecode(fr, &template)
-		ts.handleQueryAgg(conn, template)
+		ts.handleQueryAgg(conn, template, ap)
+	case protocol.QueryAggNRequest:
+		// Body of message must be a number, a function and a template.
+		template := message.GetBody().(container.Template)
+		ts.handleQueryAggN(conn, template, ap)
+	case protocol.EvalRequest:
+		// Body of message must be a function and its arguments.
+		template := message.GetBody().(container.Template)
+		ts.handleEval(conn, template)
+	case protocol.SetPolicyRequest:
+		// Body of message must be a policy file.
+		template := message.GetBody().(container.Template)
+		ts.handleSetPolicy(conn, template)
+	case protocol.UpdatePolicyRequest:
+		// Body of message must be a policy file and the labels of the policies to remove.
+		template := message.GetBody().(container.Template)
+		ts.handleUpdatePolicy(conn, template)
+	case protocol.ExplainPolicyRequest:
+		// Body of message must be the name of an operation followed by its template.
+		template := message.GetBody().(container.Template)
+		ts.handleExplainPolicy(conn, template, principal.Labels)
+	case protocol.InspectTuplesRequest, protocol.InspectWaitingClientsRequest, protocol.InspectConnectionsRequest, protocol.InspectPolicyRequest, protocol.InspectFunctionsRequest:
+		// Body of message must be a template, holding the offset, limit and template of the tuples to inspect if any.
+		template := message.GetBody().(container.Template)
+		ts.handleInspect(conn, operation, template)
 	default:
 		err := fmt.Errorf("%s %s. %s: %s", "Unsupported operation requested by peer at", conn.RemoteAddr(), "Message sent", message)
 		panic(err)
@@ -188,6 +354,28 @@ func (ts *TupleSpace) handle(conn net.Conn) {
 	return
 }
 
+// handshake receives the capabilities caps of the peer at the other end of connection conn and replies with the capabilities of tuple space ts.
+// handshake then receives the credentials creds presented by the peer.
+func (ts *TupleSpace) handshake(conn net.Conn) (caps protocol.Capabilities, creds auth.Credentials, err error) {
+	dec := decoderOf(conn)
+
+	err = dec.Decode(&caps)
+
+	if err != nil {
+		return caps, creds, err
+	}
+
+	err = encodeResponse(conn, protocol.CreateCapabilities((*ts).funReg))
+
+	if err != nil {
+		return caps, creds, err
+	}
+
+	err = dec.Decode(&creds)
+
+	return caps, creds, err
+}
+
 // Size return the number of tuples in the tuple space.
 func (ts *TupleSpace) Size() int {
 	return len(ts.tuples)
@@ -203,8 +391,26 @@ func (ts *TupleSpace) put(t *container.Tuple, response chan<- bool) {
 // putP will put a lock on the tuple space add the tuple to the list of
 // tuples and unlock the list.
 func (ts *TupleSpace) putP(t *container.Tuple) {
+	// Waiting clients stay locked until the tuple is added, such that woken counting clients find it.
 	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	if ts.notifyWaitingClients(t) {
+		return
+	}
 
+	// No waiting client performing Get matched the tuple.
+	// Place lock on tuples[] before adding the new tuple.
+	ts.muTuples.Lock()
+	defer ts.muTuples.Unlock()
+
+	ts.appendTuple(*t)
+}
+
+// notifyWaitingClients sends tuple t to the waiting clients with a matching template.
+// notifyWaitingClients returns true if a waiting client took the tuple, and false otherwise.
+// The caller must hold the lock on waitingClients[].
+func (ts *TupleSpace) notifyWaitingClients(t *container.Tuple) (taken bool) {
 	// Perform a copy of the tuple.
 	fc := make([]interface{}, t.Length())
 	copy(fc, t.Fields())
@@ -219,7 +425,14 @@ func (ts *TupleSpace) putP(t *container.Tuple) {
 		// matches the tuple.
 		temp := waitingClient.GetTemplate()
 
-		if tc.Match(temp) {
+		if tc.Match(temp) && waitingClient.IsCounting() {
+			// Counting clients are woken once enough tuples have arrived, and retry their aggregation.
+			if ts.waitingClients[i].Decrement() {
+				ts.removeClientAt(i)
+				i--
+				waitingClient.GetResponseChan() <- nil
+			}
+		} else if tc.Match(temp) {
 			// If this is reached, the tuple matched the template and the
 			// tuple is send to the response channel of the waiting client.
 			clientResponse := waitingClient.GetResponseChan()
@@ -233,25 +446,17 @@ func (ts *TupleSpace) putP(t *container.Tuple) {
 			if clientOperation == protocol.GetRequest ||
 				clientOperation == protocol.GetAggRequest ||
 				clientOperation == protocol.PutAggRequest {
-				// Unlock before exiting the method.
-				ts.muWaitingClients.Unlock()
-				return
+				return true
 			}
 		}
 	}
 
-	// No waiting client performing Get matched the tuple. So unlock.
-	ts.muWaitingClients.Unlock()
-
-	// Place lock on tuples[] before adding the new tuple.
-	ts.muTuples.Lock()
-	defer ts.muTuples.Unlock()
-
-	ts.tuples = append(ts.tuples, *t)
+	return false
 }
 
 func (ts *TupleSpace) removeClientAt(i int) {
 	ts.waitingClients = append(ts.waitingClients[:i], ts.waitingClients[i+1:]...)
+	ts.meter.add(metrics.WaitingClients, -1)
 }
 
 // get will find the first tuple that matches the template temp and remove the
@@ -292,6 +497,58 @@ func (ts *TupleSpace) addNewClient(client protocol.WaitingClient) {
 	ts.muWaitingClients.Lock()
 	defer ts.muWaitingClients.Unlock()
 	ts.waitingClients = append(ts.waitingClients, client)
+	ts.markWaiting(client.GetResponseChan())
+	ts.meter.add(metrics.WaitingClients, 1)
+}
+
+// withdrawClient removes the waiting client with response channel response from the list of waiting clients.
+// withdrawClient returns true if the client was waiting, and false if it has been sent a tuple already.
+func (ts *TupleSpace) withdrawClient(response chan<- *container.Tuple) (b bool) {
+	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	for i, waitingClient := range ts.waitingClients {
+		if waitingClient.GetResponseChan() == response {
+			ts.removeClientAt(i)
+			return true
+		}
+	}
+
+	return false
+}
+
+// awaitTuple waits for a tuple to be sent through the buffered channel response, for as long as the peer at the other end of connection conn is connected.
+// awaitTuple returns the tuple t and true if it was sent first. If the peer hangs up first, the waiting client is withdrawn,
+// and awaitTuple returns false along with any tuple sent to the client before it could be withdrawn.
+func (ts *TupleSpace) awaitTuple(conn net.Conn, response chan *container.Tuple) (t *container.Tuple, b bool) {
+	select {
+	case t = <-response:
+		return t, true
+	case <-hangup(conn):
+	}
+
+	if ts.withdrawClient(response) {
+		return nil, false
+	}
+
+	// The tuple was sent before the client could be withdrawn.
+	t = <-response
+
+	return t, false
+}
+
+// hangup returns a channel which is closed once the peer at the other end of connection conn hangs up.
+// Peers send nothing while waiting for a response, hence anything they send is taken as hanging up.
+func hangup(conn net.Conn) (gone <-chan struct{}) {
+	c := make(chan struct{})
+
+	go func() {
+		var b [1]byte
+		conn.Read(b[:])
+		close(c)
+	}()
+
+	return c
 }
 
 // getP will find the first tuple that matches the template temp and remove the
@@ -370,8 +627,19 @@ func (ts *TupleSpace) findAllTuples(temp container.Template, response chan<- []c
 		defer ts.muTuples.RUnlock()
 	}
 
-	var tuples []container.Tuple
-	var removeIndex []int
+	tuples, removeIndex := ts.matchingTuples(temp)
+
+	// Remove tuples from tuple space if it is a get operations
+	if remove {
+		ts.removeTuplesAt(removeIndex)
+	}
+
+	response <- tuples
+}
+
+// matchingTuples returns copies of the tuples in the tuple space that match the template temp, together with their indices.
+// The caller must hold the lock on tuples[].
+func (ts *TupleSpace) matchingTuples(temp container.Template) (tuples []container.Tuple, indices []int) {
 	// Go through tuple space and collects matching tuples
 	for i, t := range ts.tuples {
 		// Perform a copy of the tuple.
@@ -380,54 +648,182 @@ func (ts *TupleSpace) findAllTuples(temp container.Template, response chan<- []c
 		tc := container.NewTuple(fc...)
 
 		if tc.Match(temp) {
-			if remove {
-				removeIndex = append(removeIndex, i)
-			}
-
+			indices = append(indices, i)
 			tuples = append(tuples, tc)
 		}
 	}
-	// Remove tuples from tuple space if it is a get operations
-	for i := len(removeIndex) - 1; i >= 0; i-- {
-		ts.removeTupleAt(removeIndex[i])
+
+	return tuples, indices
+}
+
+// foldTuples aggregates the tuples matching the template temp with the aggregation function fun under the aggregation policy ap of policy cp.
+// The tuples are aggregated in place and no other operation observes the tuple space while foldTuples runs.
+// The boolean remove will denote if the tuples consumed by the aggregation should be removed from the tuple space,
+// and the boolean place will denote if the aggregate should be placed in the tuple space.
+// Tuples are only consumed if the aggregation is permitted, i.e. if there is no policy or an applicable aggregation policy
+// whose exclusive labels are not mixed by the matched tuples.
+// foldTuples returns an error err if the matched tuples mix the exclusive labels of ap.
+func (ts *TupleSpace) foldTuples(temp container.Template, fun interface{}, ap *policy.Aggregation, cp *policy.Composable, remove bool, place bool) (result container.Intertuple, err error) {
+	if place {
+		// Waiting clients are locked first, as in putP, to hand over the aggregate atomically.
+		ts.muWaitingClients.Lock()
+		defer ts.muWaitingClients.Unlock()
 	}
 
-	response <- tuples
+	if remove || place {
+		ts.muTuples.Lock()
+		defer ts.muTuples.Unlock()
+	} else {
+		ts.muTuples.RLock()
+		defer ts.muTuples.RUnlock()
+	}
+
+	result, _, _, err = ts.fold(temp, fun, ap, cp, 0, remove, place)
+
+	return result, err
+}
+
+// foldTuplesN aggregates n tuples matching the template temp like foldTuples, if at least n such tuples exist.
+// Otherwise foldTuplesN registers a counting client with response channel response, which is woken once enough tuples may have been placed.
+// foldTuplesN returns true if the aggregation was performed or refused, and false otherwise.
+func (ts *TupleSpace) foldTuplesN(n int, temp container.Template, fun interface{}, ap *policy.Aggregation, cp *policy.Composable, remove bool, response chan<- *container.Tuple) (result container.Intertuple, b bool, err error) {
+	// Waiting clients are locked first, as in putP, such that no tuple is placed unnoticed.
+	ts.muWaitingClients.Lock()
+	defer ts.muWaitingClients.Unlock()
+
+	if remove {
+		ts.muTuples.Lock()
+		defer ts.muTuples.Unlock()
+	} else {
+		ts.muTuples.RLock()
+		defer ts.muTuples.RUnlock()
+	}
+
+	result, m, b, err := ts.fold(temp, fun, ap, cp, n, remove, false)
+
+	if !b {
+		ts.waitingClients = append(ts.waitingClients, protocol.CreateCountingClient(temp, response, remove, n-m))
+		ts.markWaiting(response)
+		ts.meter.add(metrics.WaitingClients, 1)
+	}
+
+	return result, b, err
+}
+
+// fold aggregates the first n tuples matching the template temp, or all of them if n is not positive.
+// fold only aggregates if at least n tuples match, and returns the number of matching tuples m and true if it aggregated.
+// fold returns an error err, and true, if the tuples to aggregate mix the exclusive labels of ap, in which case nothing is consumed or placed.
+// The caller must hold the locks on tuples[] and, if the aggregate is placed, on waitingClients[].
+func (ts *TupleSpace) fold(temp container.Template, fun interface{}, ap *policy.Aggregation, cp *policy.Composable, n int, remove bool, place bool) (result container.Intertuple, m int, b bool, err error) {
+	tuples, indices := ts.matchingTuples(temp)
+
+	matched, consumed := matchTransform(ap, cp, tuples)
+
+	permitted := cp == nil || ap != nil
+
+	m = len(matched)
+
+	if permitted && n > 0 {
+		if m < n {
+			return nil, m, false, nil
+		}
+
+		matched, consumed = matched[:n], consumed[:n]
+	}
+
+	// Tuples labelled by different exclusive labels of the policy are not aggregated together.
+	inputs := inputLabels(tuples, consumed)
+
+	if err = ap.Aggregable(inputs...); err != nil {
+		return nil, m, true, err
+	}
+
+	start := time.Now()
+
+	result = aggregate(ap, fun, matched)
+
+	result = resultTransform(ap, result)
+
+	if ts.tracksProvenance() {
+		result = withProvenance(result, inputs)
+	}
+
+	ts.meter.observe(metrics.AggregationDuration, metrics.Since(start))
+
+	if remove && permitted {
+		removeIndex := make([]int, len(consumed))
+		for i, j := range consumed {
+			removeIndex[i] = indices[j]
+		}
+
+		ts.removeTuplesAt(removeIndex)
+	}
+
+	if place && permitted && result != nil {
+		// Perform a copy of the aggregate, as it is also sent to the client.
+		rt := transformToTuple(result)
+		fc := make([]interface{}, rt.Length())
+		copy(fc, rt.Fields())
+		tuple := container.NewTuple(fc...)
+
+		if !ts.notifyWaitingClients(&tuple) {
+			ts.appendTuple(tuple)
+		}
+	}
+
+	return result, m, true, nil
 }
 
 // clearTupleSpace will reinitialise the list of tuples in the tuple space.
 func (ts *TupleSpace) clearTupleSpace() {
+	for _, t := range ts.tuples {
+		ts.tuplesChanged(t.Length(), -1)
+	}
+
 	ts.tuples = []container.Tuple{}
+	ts.seqs = nil
+}
+
+// appendTuple places tuple t after the tuples in the tuple space, numbering it after them.
+// The caller must hold the lock on tuples[].
+func (ts *TupleSpace) appendTuple(t container.Tuple) {
+	ts.placed++
+
+	ts.tuples = append(ts.tuples, t)
+	ts.seqs = append(ts.seqs, ts.placed)
+	ts.tuplesChanged(t.Length(), 1)
 }
 
-// removeTupleAt will removeTupleAt the tuple in the tuples space at index i.
+// removeTupleAt will remove the tuple in the tuple space at index i, keeping the remaining tuples in the order they were placed.
 func (ts *TupleSpace) removeTupleAt(i int) {
-	//moves last tuple to place i, then removes last element from slice
-	ts.tuples[i] = ts.tuples[ts.Size()-1]
-	ts.tuples = ts.tuples[:ts.Size()-1]
+	ts.removeTuplesAt([]int{i})
 }
 
-// returnUnmatched stores unmatched tuples back to the tuple space ts given an action a.
-// returnUnmatched returns true if tuples have been back to the tuple space, and false otherwise.
-func (ts *TupleSpace) returnUnmatched(a *policy.Action, unmatched []container.Intertuple) (b bool) {
-	var spc Space
-	b = a != nil && (*a).Sign.Func != container.NewSignature(1, spc.QueryAgg)
-
-	if b {
-		var tuple container.Tuple
-		for _, ut := range unmatched {
-			switch ut.(type) {
-			case *container.Tuple:
-				tuple = *(ut.(*container.Tuple))
-				ts.putP(&tuple)
-			case *container.LabelledTuple:
-				tuple = container.Tuple(*(ut.(*container.LabelledTuple)))
-				ts.putP(&tuple)
-			}
+// removeTuplesAt will remove the tuples in the tuple space at the indices, keeping the remaining tuples in the order they were placed.
+func (ts *TupleSpace) removeTuplesAt(indices []int) {
+	removed := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		removed[i] = true
+	}
+
+	// Move the remaining tuples forward in a single pass, such that the indices stay valid while removing.
+	n := 0
+	for i, t := range ts.tuples {
+		if removed[i] {
+			ts.tuplesChanged(t.Length(), -1)
+			continue
 		}
+
+		ts.tuples[n], ts.seqs[n] = t, ts.seqs[i]
+		n++
 	}
 
-	return b
+	// Clear the tuples left behind, such that they can be garbage collected.
+	for i := n; i < len(ts.tuples); i++ {
+		ts.tuples[i] = container.Tuple{}
+	}
+
+	ts.tuples, ts.seqs = ts.tuples[:n], ts.seqs[:n]
 }
 
 // handlePut is a blocking method.
@@ -435,16 +831,14 @@ func (ts *TupleSpace) returnUnmatched(a *policy.Action, unmatched []container.In
 // The method will send a boolean value to the connection conn to tell whether
 // or not the placement succeeded
 func (ts *TupleSpace) handlePut(conn net.Conn, t container.Tuple) {
-	defer handleRecover(ts.handlePut)
+	defer ts.handleRecover(ts.handlePut, conn)
 
 	readChannel := make(chan bool)
 	go ts.put(&t, readChannel)
 	result := <-readChannel
 	close(readChannel)
 
-	enc := gob.NewEncoder(conn)
-
-	err := enc.Encode(result)
+	err := encodeResponse(conn, result)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -459,28 +853,12 @@ func (ts *TupleSpace) handlePutP(t container.Tuple) {
 
 // handlePutAgg is a non-blocking method that will return an aggregated tuple from the tuple
 // space and put it back into the tuple space.
-func (ts *TupleSpace) handlePutAgg(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handlePutAgg)
+func (ts *TupleSpace) handlePutAgg(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handlePutAgg, conn)
 
-	fun := (temp.GetFieldAt(0)).(func(...container.Intertuple) container.Intertuple)
+	fun := temp.GetFieldAt(0)
 
-	var spc Space
-	var cp *policy.Composable
-	var ap *policy.Aggregation
-	var a *policy.Action
-
-	// Find an applicable policy through the current action and template.
-	cp = (*ts).pol
-	if cp != nil {
-		qa := policy.NewAction(spc.PutAgg, temp.Fields()...)
-		l := cp.Find(qa)
-
-		if l != nil {
-			ap = ts.pol.Retrieve(*l)
-		}
-
-		a = qa
-	}
+	cp, _ := ts.currentPolicy()
 
 	fields := make([]interface{}, temp.Length()-1)
 	for i := 1; i < temp.Length(); i++ {
@@ -489,41 +867,15 @@ func (ts *TupleSpace) handlePutAgg(conn net.Conn, temp container.Template) {
 
 	template := templateTransform(ap, fields)
 
-	readChannel := make(chan []container.Tuple)
-	go ts.getAll(template, readChannel)
-	tuples := <-readChannel
-	close(readChannel)
-
-	matched, unmatched := matchTransform(ap, cp, tuples)
-
-	if cp != nil && ap == nil {
-		ts.returnUnmatched(a, matched)
-	}
-	ts.returnUnmatched(a, unmatched)
-
-	result := aggregate(ap, fun, matched)
-
-	result = resultTransform(ap, result)
-
-	var tuple container.Tuple
-	if ap != nil {
-		switch result.(type) {
-		case *container.Tuple:
-			tuple = *(result.(*container.Tuple))
-		case *container.LabelledTuple:
-			tuple = container.Tuple(*(result.(*container.LabelledTuple)))
-		}
-		ts.putP(&tuple)
-	} else {
-		tuple = *(result.(*container.Tuple))
-	}
+	result, errAgg := ts.foldTuples(template, fun, ap, cp, true, true)
 
 	fr := (*ts).funReg
-	if fr != nil {
+	if fr != nil && result != nil {
 		defer funcDecode(fr, result)
 		funcEncode(fr, result)
 	}
 
+	var tuple container.Tuple
 	if ap != nil {
 		switch result.(type) {
 		case *container.Tuple:
@@ -534,11 +886,10 @@ func (ts *TupleSpace) handlePutAgg(conn net.Conn, temp container.Template) {
 	} else if cp != nil {
 		tuple = container.NewTuple(nil)
 	} else {
-		tuple = *(result.(*container.Tuple))
+		tuple = transformToTuple(result)
 	}
 
-	enc := gob.NewEncoder(conn)
-	err := enc.Encode(tuple)
+	err := ts.encodeAggregate(conn, tuple, errAgg)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -549,23 +900,38 @@ func (ts *TupleSpace) handlePutAgg(conn net.Conn, temp container.Template) {
 
 // handleGet is a blocking method.
 // It will find a tuple matching the template temp and return it.
-func (ts *TupleSpace) handleGet(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleGet)
-
-	readChannel := make(chan *container.Tuple)
-	go ts.get(temp, readChannel)
-	resultTuplePtr := <-readChannel
+func (ts *TupleSpace) handleGet(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleGet, conn)
+
+	wait := spanOf(conn).Child("waitingClient")
+	readChannel := make(chan *container.Tuple, 1)
+	ts.get(temp, readChannel)
+	resultTuplePtr, connected := ts.awaitTuple(conn, readChannel)
+	wait.End()
+	ts.unmarkWaiting(readChannel)
 	close(readChannel)
 
+	// Tuples taken for peers which hung up are placed back, such that they are not lost.
+	if !connected {
+		if resultTuplePtr != nil {
+			ts.putP(resultTuplePtr)
+		}
+
+		return
+	}
+
+	// The tuple may be shared with other waiting clients, hence it is transformed into a tuple of its own.
+	var rt container.Tuple
+	if resultTuplePtr != nil {
+		rt = ts.transformRetrieved(ap, *resultTuplePtr)
+	}
+
 	fr := (*ts).funReg
 	if fr != nil && resultTuplePtr != nil {
-		defer funcDecode(fr, resultTuplePtr)
-		funcEncode(fr, resultTuplePtr)
+		funcEncode(fr, &rt)
 	}
 
-	enc := gob.NewEncoder(conn)
-
-	err := enc.Encode(*resultTuplePtr)
+	err := encodeResponse(conn, rt)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -577,34 +943,37 @@ func (ts *TupleSpace) handleGet(conn net.Conn, temp container.Template) {
 // from the tuple space.
 // As it may not find it, the method will send a boolean as well as the tuple
 // to the connection conn.
-func (ts *TupleSpace) handleGetP(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleGetP)
+func (ts *TupleSpace) handleGetP(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleGetP, conn)
 
 	readChannel := make(chan *container.Tuple)
 	go ts.getP(temp, readChannel)
 	resultTuplePtr := <-readChannel
 	close(readChannel)
 
+	// The tuple may be shared with other waiting clients, hence it is transformed into a tuple of its own.
+	var rt container.Tuple
+	if resultTuplePtr != nil {
+		rt = ts.transformRetrieved(ap, *resultTuplePtr)
+	}
+
 	fr := (*ts).funReg
 	if fr != nil && resultTuplePtr != nil {
-		defer funcDecode(fr, resultTuplePtr)
-		funcEncode(fr, resultTuplePtr)
+		funcEncode(fr, &rt)
 	}
 
-	enc := gob.NewEncoder(conn)
-
 	if resultTuplePtr == nil {
 		result := []interface{}{false, container.NewTuple()}
 
-		err := enc.Encode(result)
+		err := encodeResponse(conn, result)
 
 		if err != nil {
 			panic("Could not encode the empty tuple")
 		}
 	} else {
-		result := []interface{}{true, *resultTuplePtr}
+		result := []interface{}{true, rt}
 
-		err := enc.Encode(result)
+		err := encodeResponse(conn, result)
 
 		if err != nil {
 			panic("Could not encode the tuple")
@@ -614,14 +983,18 @@ func (ts *TupleSpace) handleGetP(conn net.Conn, temp container.Template) {
 
 // handleGetAll is a nonblocking method that will remove all tuples from the tuple
 // space and send them in a list through the connection conn.
-func (ts *TupleSpace) handleGetAll(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleGetAll)
+func (ts *TupleSpace) handleGetAll(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleGetAll, conn)
 
 	readChannel := make(chan []container.Tuple)
 	go ts.getAll(temp, readChannel)
 	tupleList := <-readChannel
 	close(readChannel)
 
+	for i := range tupleList {
+		tupleList[i] = ts.transformRetrieved(ap, tupleList[i])
+	}
+
 	fr := (*ts).funReg
 	if fr != nil {
 		for _, t := range tupleList {
@@ -630,9 +1003,7 @@ func (ts *TupleSpace) handleGetAll(conn net.Conn, temp container.Template) {
 		}
 	}
 
-	enc := gob.NewEncoder(conn)
-
-	err := enc.Encode(tupleList)
+	err := encodeResponse(conn, tupleList)
 
 	if err != nil {
 		panic("Could not encode tuples")
@@ -641,28 +1012,12 @@ func (ts *TupleSpace) handleGetAll(conn net.Conn, temp container.Template) {
 
 // handleGetAgg is a blocking method that will return an aggregated tuple from the tuple
 // space in a list.
-func (ts *TupleSpace) handleGetAgg(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleGetAgg)
+func (ts *TupleSpace) handleGetAgg(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleGetAgg, conn)
 
-	fun := (temp.GetFieldAt(0)).(func(...container.Intertuple) container.Intertuple)
+	fun := temp.GetFieldAt(0)
 
-	spc := new(Space)
-	var cp *policy.Composable
-	var ap *policy.Aggregation
-	var a *policy.Action
-
-	// Find an applicable policy through the current action and template.
-	cp = (*ts).pol
-	if cp != nil {
-		qa := policy.NewAction(spc.GetAgg, temp.Fields()...)
-		l := cp.Find(qa)
-
-		if l != nil {
-			ap = ts.pol.Retrieve(*l)
-		}
-
-		a = qa
-	}
+	cp, _ := ts.currentPolicy()
 
 	fields := make([]interface{}, temp.Length()-1)
 	for i := 1; i < temp.Length(); i++ {
@@ -671,24 +1026,10 @@ func (ts *TupleSpace) handleGetAgg(conn net.Conn, temp container.Template) {
 
 	template := templateTransform(ap, fields)
 
-	readChannel := make(chan []container.Tuple)
-	go ts.getAll(template, readChannel)
-	tuples := <-readChannel
-	close(readChannel)
-
-	matched, unmatched := matchTransform(ap, cp, tuples)
-
-	if cp != nil && ap == nil {
-		ts.returnUnmatched(a, matched)
-	}
-	ts.returnUnmatched(a, unmatched)
-
-	result := aggregate(ap, fun, matched)
-
-	result = resultTransform(ap, result)
+	result, errAgg := ts.foldTuples(template, fun, ap, cp, true, false)
 
 	fr := (*ts).funReg
-	if fr != nil {
+	if fr != nil && result != nil {
 		defer funcDecode(fr, result)
 		funcEncode(fr, result)
 	}
@@ -704,11 +1045,10 @@ func (ts *TupleSpace) handleGetAgg(conn net.Conn, temp container.Template) {
 	} else if cp != nil {
 		tuple = container.NewTuple(nil)
 	} else {
-		tuple = *(result.(*container.Tuple))
+		tuple = transformToTuple(result)
 	}
 
-	enc := gob.NewEncoder(conn)
-	err := enc.Encode(tuple)
+	err := ts.encodeAggregate(conn, tuple, errAgg)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -719,15 +1059,13 @@ func (ts *TupleSpace) handleGetAgg(conn net.Conn, temp container.Template) {
 
 // handleSize returns the size of this tuple space at this instant.
 func (ts *TupleSpace) handleSize(conn net.Conn) {
-	defer handleRecover(ts.handleSize)
+	defer ts.handleRecover(ts.handleSize, conn)
 
 	ts.muTuples.Lock()
 	sz := ts.Size()
 	ts.muTuples.Unlock()
 
-	enc := gob.NewEncoder(conn)
-
-	err := enc.Encode(sz)
+	err := encodeResponse(conn, sz)
 
 	if err != nil {
 		panic("Could not encode tuple space size")
@@ -739,25 +1077,33 @@ func (ts *TupleSpace) handleSize(conn net.Conn) {
 // handleQuery is a blocking method.
 // It will find a tuple matching the template temp.
 // The found tuple will be send to the connection conn.
-func (ts *TupleSpace) handleQuery(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleQuery)
-
-	readChannel := make(chan *container.Tuple)
-	go ts.query(temp, readChannel)
-	resultTuplePtr := <-readChannel
+func (ts *TupleSpace) handleQuery(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleQuery, conn)
+
+	wait := spanOf(conn).Child("waitingClient")
+	readChannel := make(chan *container.Tuple, 1)
+	ts.query(temp, readChannel)
+	resultTuplePtr, connected := ts.awaitTuple(conn, readChannel)
+	wait.End()
+	ts.unmarkWaiting(readChannel)
 	close(readChannel)
 
-	fr := (*ts).funReg
-	if fr != nil && resultTuplePtr != nil {
-		defer funcDecode(fr, resultTuplePtr)
-		funcEncode(fr, resultTuplePtr)
+	if !connected {
+		return
 	}
 
-	enc := gob.NewEncoder(conn)
+	// The tuple may be shared with other waiting clients, hence it is transformed into a tuple of its own.
+	var rt container.Tuple
+	if resultTuplePtr != nil {
+		rt = ts.transformRetrieved(ap, *resultTuplePtr)
+	}
 
-	err := enc.Encode(*resultTuplePtr)
+	fr := (*ts).funReg
+	if fr != nil && resultTuplePtr != nil {
+		funcEncode(fr, &rt)
+	}
 
-	funcDecode(fr, resultTuplePtr)
+	err := encodeResponse(conn, rt)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -767,53 +1113,58 @@ func (ts *TupleSpace) handleQuery(conn net.Conn, temp container.Template) {
 // handleQueryP is a nonblocking method.
 // It will try to find a tuple matching the template temp.
 // As it may not find it, the method returns a boolean as well as the tuple.
-func (ts *TupleSpace) handleQueryP(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleQueryP)
+func (ts *TupleSpace) handleQueryP(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleQueryP, conn)
 
 	readChannel := make(chan *container.Tuple)
 	go ts.queryP(temp, readChannel)
 	resultTuplePtr := <-readChannel
 	close(readChannel)
 
+	// The tuple may be shared with other waiting clients, hence it is transformed into a tuple of its own.
+	var rt container.Tuple
+	if resultTuplePtr != nil {
+		rt = ts.transformRetrieved(ap, *resultTuplePtr)
+	}
+
 	fr := (*ts).funReg
 	if fr != nil && resultTuplePtr != nil {
-		defer funcDecode(fr, resultTuplePtr)
-		funcEncode(fr, resultTuplePtr)
+		funcEncode(fr, &rt)
 	}
 
-	enc := gob.NewEncoder(conn)
-
 	if resultTuplePtr == nil {
 		result := []interface{}{false, container.NewTuple()}
 
-		err := enc.Encode(result)
+		err := encodeResponse(conn, result)
 
 		if err != nil {
 			panic("Could not encode the empty tuple")
 		}
 	} else {
-		result := []interface{}{true, *resultTuplePtr}
+		result := []interface{}{true, rt}
 
-		err := enc.Encode(result)
+		err := encodeResponse(conn, result)
 
 		if err != nil {
 			panic("Could not encode tuple")
 		}
 	}
-
-	funcDecode(fr, resultTuplePtr)
 }
 
 // handleQueryAll is a blocking method that will return all tuples from the tuple
 // space in a list.
-func (ts *TupleSpace) handleQueryAll(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleQueryAll)
+func (ts *TupleSpace) handleQueryAll(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleQueryAll, conn)
 
 	readChannel := make(chan []container.Tuple)
 	go ts.queryAll(temp, readChannel)
 	tupleList := <-readChannel
 	close(readChannel)
 
+	for i := range tupleList {
+		tupleList[i] = ts.transformRetrieved(ap, tupleList[i])
+	}
+
 	fr := (*ts).funReg
 	if fr != nil {
 		for _, t := range tupleList {
@@ -822,9 +1173,7 @@ func (ts *TupleSpace) handleQueryAll(conn net.Conn, temp container.Template) {
 		}
 	}
 
-	enc := gob.NewEncoder(conn)
-
-	err := enc.Encode(tupleList)
+	err := encodeResponse(conn, tupleList)
 
 	if err != nil {
 		panic("Could not encode tuple")
@@ -833,24 +1182,12 @@ func (ts *TupleSpace) handleQueryAll(conn net.Conn, temp container.Template) {
 
 // handleQueryAgg is a blocking method that will return an aggregated tuple from the tuple
 // space in a list.
-func (ts *TupleSpace) handleQueryAgg(conn net.Conn, temp container.Template) {
-	defer handleRecover(ts.handleQueryAgg)
+func (ts *TupleSpace) handleQueryAgg(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleQueryAgg, conn)
 
-	fun := (temp.GetFieldAt(0)).(func(...container.Intertuple) container.Intertuple)
+	fun := temp.GetFieldAt(0)
 
-	spc := new(Space)
-	var cp *policy.Composable
-	var ap *policy.Aggregation
-
-	// Find an applicable policy through the current action and template.
-	cp = (*ts).pol
-	if cp != nil {
-		qa := policy.NewAction(spc.QueryAgg, temp.Fields()...)
-		l := cp.Find(qa)
-		if l != nil {
-			ap = ts.pol.Retrieve(*l)
-		}
-	}
+	cp, _ := ts.currentPolicy()
 
 	fields := make([]interface{}, temp.Length()-1)
 	for i := 1; i < temp.Length(); i++ {
@@ -859,19 +1196,10 @@ func (ts *TupleSpace) handleQueryAgg(conn net.Conn, temp container.Template) {
 
 	template := templateTransform(ap, fields)
 
-	readChannel := make(chan []container.Tuple)
-	go ts.queryAll(template, readChannel)
-	tuples := <-readChannel
-	close(readChannel)
-
-	matched, _ := matchTransform(ap, cp, tuples)
-
-	result := aggregate(ap, fun, matched)
-
-	result = resultTransform(ap, result)
+	result, errAgg := ts.foldTuples(template, fun, ap, cp, false, false)
 
 	fr := (*ts).funReg
-	if fr != nil {
+	if fr != nil && result != nil {
 		defer funcDecode(fr, result)
 		funcEncode(fr, result)
 	}
@@ -887,22 +1215,155 @@ func (ts *TupleSpace) handleQueryAgg(conn net.Conn, temp container.Template) {
 	} else if cp != nil {
 		tuple = container.NewTuple(nil)
 	} else {
-		tuple = *(result.(*container.Tuple))
+		tuple = transformToTuple(result)
 	}
 
-	enc := gob.NewEncoder(conn)
-	err := enc.Encode(tuple)
+	err := ts.encodeAggregate(conn, tuple, errAgg)
 
 	if err != nil {
 		panic("Could not encode tuple")
 	}
 }
 
+// handleGetAggN is a blocking method that will wait until a number of tuples match a template
+// and return their aggregate after removing them from the tuple space.
+func (ts *TupleSpace) handleGetAggN(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleGetAggN, conn)
+
+	ts.aggregateN(conn, temp, ap, true)
+}
+
+// handleQueryAggN is a blocking method that will wait until a number of tuples match a template
+// and return their aggregate.
+func (ts *TupleSpace) handleQueryAggN(conn net.Conn, temp container.Template, ap *policy.Aggregation) {
+	defer ts.handleRecover(ts.handleQueryAggN, conn)
+
+	ts.aggregateN(conn, temp, ap, false)
+}
+
+// aggregateN waits until the number of tuples given by the first field of template temp match the remaining template,
+// and sends the aggregate of exactly that many tuples to the connection conn.
+// The second field of temp is the aggregation function, and the action of the operation is given by operation.
+// The boolean remove will denote if the aggregated tuples should be removed from the tuple space.
+// If the peer hangs up while waiting, its counting client is withdrawn and nothing is aggregated.
+func (ts *TupleSpace) aggregateN(conn net.Conn, temp container.Template, ap *policy.Aggregation, remove bool) {
+	n := temp.GetFieldAt(0).(int)
+	fun := temp.GetFieldAt(1)
+
+	cp, _ := ts.currentPolicy()
+
+	fields := make([]interface{}, temp.Length()-2)
+	for i := 2; i < temp.Length(); i++ {
+		fields[i-2] = temp.GetFieldAt(i)
+	}
+
+	template := templateTransform(ap, fields)
+
+	// Retry the aggregation every time the counting client is woken, for as long as the peer is connected.
+	// The channel is buffered, such that waking the client never blocks once it has been withdrawn.
+	readChannel := make(chan *container.Tuple, 1)
+	result, b, errAgg := ts.foldTuplesN(n, template, fun, ap, cp, remove, readChannel)
+	if !b {
+		wait := spanOf(conn).Child("waitingClient")
+		gone := hangup(conn)
+		for !b {
+			select {
+			case <-readChannel:
+				result, b, errAgg = ts.foldTuplesN(n, template, fun, ap, cp, remove, readChannel)
+			case <-gone:
+				// Nothing has been aggregated, hence the tuples counted so far are left in place.
+				ts.withdrawClient(readChannel)
+				wait.End()
+				ts.unmarkWaiting(readChannel)
+				return
+			}
+		}
+		wait.End()
+		ts.unmarkWaiting(readChannel)
+	}
+	close(readChannel)
+
+	fr := (*ts).funReg
+	if fr != nil && result != nil {
+		defer funcDecode(fr, result)
+		funcEncode(fr, result)
+	}
+
+	var tuple container.Tuple
+	if ap == nil && cp != nil {
+		tuple = container.NewTuple(nil)
+	} else {
+		tuple = transformToTuple(result)
+	}
+
+	err := ts.encodeAggregate(conn, tuple, errAgg)
+
+	if err != nil {
+		panic("Could not encode tuple")
+	}
+}
+
+// encodeAggregate sends the status of an aggregation to connection conn, followed by the aggregate tuple t if the aggregation was not refused.
+// The aggregation is refused by the error errAgg of folding the tuples, which is only known after the operation has been accepted.
+func (ts *TupleSpace) encodeAggregate(conn net.Conn, t container.Tuple, errAgg error) (err error) {
+	status := protocol.CreateAcceptance()
+	if errAgg != nil {
+		_, version := ts.currentPolicy()
+		errAgg = fmt.Errorf("%s (policy version %d)", errAgg, version)
+		spanOf(conn).SetError(errAgg)
+		ts.logger().Debug("aggregation refused", peerFields(conn, logging.Error, errAgg)...)
+		status = protocol.CreateRefusal(errAgg.Error())
+	}
+
+	err = encodeResponse(conn, status)
+
+	if err != nil || errAgg != nil {
+		return err
+	}
+
+	err = encodeResponse(conn, t)
+
+	return err
+}
+
+// handleEval is a non-blocking method that evaluates a function in the background.
+// The first field of template temp is the function and the remaining fields are its arguments.
+// The resulting tuple, or an error tuple if the evaluation fails, is placed in the tuple space ts.
+func (ts *TupleSpace) handleEval(conn net.Conn, temp container.Template) {
+	defer ts.handleRecover(ts.handleEval, conn)
+
+	var fun interface{}
+	if temp.Length() > 0 {
+		fun = temp.GetFieldAt(0)
+	}
+
+	args := make([]interface{}, 0, temp.Length())
+	for i := 1; i < temp.Length(); i++ {
+		args = append(args, temp.GetFieldAt(i))
+	}
+
+	go func() {
+		defer ts.handleRecover(ts.handleEval, conn)
+
+		tuple := evaluate(fun, args)
+		ts.putP(&tuple)
+	}()
+
+	err := encodeResponse(conn, true)
+
+	if err != nil {
+		panic("Could not encode evaluation status")
+	}
+}
+
 // aggregate performs the aggregation of the tuple given an aggregation function fun and tuples ts.
+// Aggregators from the aggregation package are applied to all tuples at once, while other functions are applied pairwise.
 func aggregate(ap *policy.Aggregation, fun interface{}, ts []container.Intertuple) (result container.Intertuple) {
 	b := fun != nil
 
-	if b {
+	if b && aggregation.IsAggregator(fun) {
+		result = aggregation.Aggregate(fun, ts...)
+	} else if b {
 		fun := fun.(func(...container.Intertuple) container.Intertuple)
 
 		if len(ts) > 1 {
@@ -956,7 +1417,8 @@ func templateTransform(ap *policy.Aggregation, fields []interface{}) (template c
 }
 
 // matchTransform takes an actions label and an aggregation policy ap and applies the policy to the matched tuples.
-func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []container.Tuple) (mt []container.Intertuple, ut []container.Intertuple) {
+// matchTransform returns the transformed tuples mt together with the indices mi of the tuples they originate from.
+func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []container.Tuple) (mt []container.Intertuple, mi []int) {
 	var trans *policy.Transformation
 	if ap != nil && cp != nil {
 		trs := ap.AggRule.Transformations()
@@ -981,7 +1443,6 @@ func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []cont
 		a := ap.Action()
 		al := ap.Label()
 		ets := make([]int, 0, len(lts))
-		nts := make([]int, 0, len(lts))
 		for _, i := range lts {
 			t := tuples[i]
 			lt := container.LabelledTuple(t)
@@ -1005,17 +1466,12 @@ func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []cont
 					}
 				}
 			}
-
-			if !exact {
-				nts = append(nts, i)
-			}
 		}
 
-		mt = make([]container.Intertuple, len(uts)+len(ets))
-		ut = make([]container.Intertuple, len(nts))
+		mt = make([]container.Intertuple, 0, len(uts)+len(ets))
+		mi = make([]int, 0, len(uts)+len(ets))
 
 		// Transform unlabelled tuples by applying matching transformation.
-		j := 0
 		for _, i := range uts {
 			ut := tuples[i]
 			val, err := trans.Apply(ut.Fields()...)
@@ -1026,8 +1482,8 @@ func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []cont
 				copy(ltf[:1], []interface{}{lbl})
 				copy(ltf[1:], tf)
 				tlt := container.NewLabelledTuple(ltf...)
-				mt[j] = &tlt
-				j++
+				mt = append(mt, &tlt)
+				mi = append(mi, i)
 			}
 		}
 
@@ -1042,18 +1498,20 @@ func matchTransform(ap *policy.Aggregation, cp *policy.Composable, tuples []cont
 				copy(ltf[:1], []interface{}{lbl})
 				copy(ltf[1:], tf)
 				tlt := container.NewLabelledTuple(ltf...)
-				mt[j] = &tlt
-				j++
+				mt = append(mt, &tlt)
+				mi = append(mi, i)
 			}
 		}
 	} else {
 		mt = make([]container.Intertuple, 0, len(tuples))
+		mi = make([]int, 0, len(tuples))
 		for i := range tuples {
 			mt = append(mt, &(tuples[i]))
+			mi = append(mi, i)
 		}
 	}
 
-	return mt, ut
+	return mt, mi
 }
 
 // resultTransform rewrites a tuple result given an aggregation policy ap.
@@ -1084,22 +1542,42 @@ func resultTransform(ap *policy.Aggregation, res container.Intertuple) (mod cont
 }
 
 // transformToTuple takes an tuple interface and converts it to a tuple.
-func transformToTuple(it container.Intertuple, ap *policy.Aggregation) (t container.Tuple) {
-	if ap != nil {
-		switch it.(type) {
-		case *container.Tuple:
-			t = *(it.(*container.Tuple))
-		case *container.LabelledTuple:
-			t = container.Tuple(*(it.(*container.LabelledTuple)))
-		}
-	} else {
+func transformToTuple(it container.Intertuple) (t container.Tuple) {
+	switch it.(type) {
+	case *container.Tuple:
 		t = *(it.(*container.Tuple))
+	case *container.LabelledTuple:
+		t = container.Tuple(*(it.(*container.LabelledTuple)))
 	}
 
 	return t
 }
 
+// unresolvedFunction returns an error if a tuple or template body references a function which can not be resolved through function registry reg.
+func unresolvedFunction(reg *function.Registry, body interface{}) (err error) {
+	var fields []interface{}
+
+	switch t := body.(type) {
+	case container.Tuple:
+		fields = t.Fields()
+	case container.Template:
+		fields = t.Fields()
+	}
+
+	for _, field := range fields {
+		ref, ok := field.(container.FuncRef)
+
+		if ok && (reg == nil || reg.Decode(function.NewNamespace(ref.Namespace)) == nil) {
+			err = fmt.Errorf("function %s is not registered at this space", ref)
+			break
+		}
+	}
+
+	return err
+}
+
 // funcEncode performs encoding of functions in a tuple or a template t.
+// Functions are replaced by references to their namespaces.
 func funcEncode(reg *function.Registry, i interface{}) {
 	var fr *function.Registry
 
@@ -1110,17 +1588,13 @@ func funcEncode(reg *function.Registry, i interface{}) {
 	if fr != nil {
 		t := i.(container.Applier)
 
-		// Assume that any string field might contain a reference to a function.
-		// TODO: Encapsulate the enconding of functions better than sending strings.
-		// TODO: A reference type is actually needed in the tuple space, as this will
-		// TODO: solve any issues with the code mobility via dictionary approach.
 		t.Apply(func(field interface{}) interface{} {
 			var val interface{}
 
 			if field != nil && function.IsFunc(field) {
 				fun := field
 				fr.Register(fun)
-				val = fr.Encode(fun).String()
+				val = container.NewFuncRef(fr.Encode(fun).String())
 			} else {
 				val = field
 			}
@@ -1133,6 +1607,7 @@ func funcEncode(reg *function.Registry, i interface{}) {
 }
 
 // funcDecode performs function decoding on tuples or templates.
+// Function references are replaced by the functions they refer to, and are kept as is if the function is not registered.
 func funcDecode(reg *function.Registry, i interface{}) {
 	var fr *function.Registry
 
@@ -1143,15 +1618,11 @@ func funcDecode(reg *function.Registry, i interface{}) {
 	if fr != nil {
 		t := i.(container.Applier)
 
-		// Assume that any string field might contain a reference to a function.
-		// TODO: The decoding mechanism of functions might convert any tuples that contain namespace strings.
-		// TODO: A reference type is actually needed in the tuple space, as this will
-		// TODO: solve any issues with the code mobility via dictionary approach.
 		t.Apply(func(field interface{}) interface{} {
 			var val interface{}
 
-			if field != nil && reflect.TypeOf(field) == reflect.TypeOf("") {
-				fun := fr.Decode(function.NewNamespace(field.(string)))
+			if ref, ok := field.(container.FuncRef); ok {
+				fun := fr.Decode(function.NewNamespace(ref.Namespace))
 
 				if fun != nil {
 					val = (*fun)
@@ -1168,9 +1639,3 @@ func funcDecode(reg *function.Registry, i interface{}) {
 
 	return
 }
-
-func handleRecover(caller interface{}) {
-	if error := recover(); error != nil {
-		fmt.Printf("%s: %s: \n\t%s: %s.\n", "gospace", function.Name(caller), "Recovered from error", error)
-	}
-}
@@ -1,53 +1,50 @@
 package space
 
 import (
-	"bytes"
 	"encoding/gob"
-	"log"
+	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/pspaces/gospace/auth"
 	"github.com/pspaces/gospace/container"
 	"github.com/pspaces/gospace/function"
 	"github.com/pspaces/gospace/policy"
 	"github.com/pspaces/gospace/protocol"
 	"github.com/pspaces/gospace/space/uri"
+	"github.com/pspaces/gospace/transport"
 )
 
-// Constants for logging errors occuring in this file.
-var (
-	tsAltBuf    bytes.Buffer
-	tsAltLogger = log.New(&tsAltBuf, "gospace: ", log.Ldate|log.Ltime|log.Lmicroseconds|log.LUTC|log.Lshortfile)
-)
-
-// tsAltLog logs errors occuring in this file.
-func tsAltLog(fun interface{}, e *error) {
-	if *e != nil {
-		tsAltLogger.Printf("%s: %s\n", function.Name(fun), *e)
-	}
-}
+// errOperationFailed is returned when a space reports that it could not perform an operation.
+var errOperationFailed = errors.New("space could not perform the operation")
 
 // TODO: This is a part of hack, don't touch this.
 // TODO: This can be removed once rearchitecting begins.
 var localChanMap = new(sync.Map)
 
 // NewSpaceAlt creates a representation of a new tuple space.
+// Several composable policies cp are composed sequentially, use policy.Compose for other ways of combining them.
 func NewSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.PointToPoint, ts *TupleSpace) {
 	registerTypes()
 
 	u, err := uri.NewSpaceURI(url)
 
 	if err == nil {
-		// TODO: Exchange capabilities instead and
-		// TODO: make a mechanism capable of doing that.
 		if function.GlobalRegistry == nil {
 			fr := function.NewRegistry()
 			function.GlobalRegistry = &fr
 		}
 		funcReg := *function.GlobalRegistry
 
+		// Spaces reached through transports other than TCP need neither name resolution nor local connection channels.
+		if tr, exists := transport.Lookup(u.Scheme()); exists && tr != (transport.TCP{}) {
+			ptp, ts = newTransportSpace(tr, u, &funcReg, true, cp...)
+			return ptp, ts
+		}
+
 		// TODO: Create a better condition if a hosts name resolves to a local address.
 		ips, err := net.LookupIP(u.Hostname())
 
@@ -64,7 +61,7 @@ func NewSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.PointToPoi
 
 		// TODO: Embrace the following hack, and remove it with architecting differently.
 		connc := make(chan *net.Conn)
-		val, exists := localChanMap.LoadOrStore(u, connc)
+		val, exists := localChanMap.LoadOrStore(u.String(), connc)
 
 		//if exists {
 		//close(connc)
@@ -72,32 +69,14 @@ func NewSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.PointToPoi
 		//}
 
 		if !exists && err == nil {
-			muTuples := new(sync.RWMutex)
-			muWaitingClients := new(sync.Mutex)
-			tuples := []container.Tuple{}
-
-			addr := strings.Join([]string{u.Hostname(), u.Port()}, ":")
-
-			ts = &TupleSpace{
-				muTuples:         muTuples,
-				muWaitingClients: muWaitingClients,
-				tuples:           tuples,
-				pol:              nil,
-				funReg:           &funcReg,
-				port:             addr,
-				connc:            connc,
-			}
-
-			if len(cp) == 1 {
-				(*ts).pol = cp[0]
-			}
+			ts = newTupleSpace(u, &funcReg, connc, cp...)
 
 			go ts.Listen()
 
 			ptp = protocol.CreatePointToPoint(u.Space(), u.Hostname(), "0", connc, &funcReg)
 		} else {
 			for localhost && !exists {
-				val, exists = localChanMap.Load(u)
+				val, exists = localChanMap.Load(u.String())
 
 				if exists {
 					connc = (val.(chan *net.Conn))
@@ -130,14 +109,17 @@ func NewRemoteSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.Poin
 	u, err := uri.NewSpaceURI(url)
 
 	if err == nil {
-		// TODO: Exchange capabilities instead and
-		// TODO: make a mechanism capable of doing that.
 		if function.GlobalRegistry == nil {
 			fr := function.NewRegistry()
 			function.GlobalRegistry = &fr
 		}
 		funcReg := *function.GlobalRegistry
 
+		if tr, exists := transport.Lookup(u.Scheme()); exists && tr != (transport.TCP{}) {
+			ptp, ts = newTransportSpace(tr, u, &funcReg, false, cp...)
+			return ptp, ts
+		}
+
 		// TODO: Create a better condition if a hosts name resolves to a local address.
 		ips, _ := net.LookupIP(u.Hostname())
 
@@ -150,7 +132,7 @@ func NewRemoteSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.Poin
 		var connc chan *net.Conn
 
 		for localhost {
-			val, exists := localChanMap.Load(u)
+			val, exists := localChanMap.Load(u.String())
 
 			if exists {
 				connc = (val.(chan *net.Conn))
@@ -178,49 +160,103 @@ func NewRemoteSpaceAlt(url string, cp ...*policy.Composable) (ptp *protocol.Poin
 	return ptp, ts
 }
 
+// newTupleSpace creates the tuple space located at URL u, with function registry fr and connection channel connc.
+// Several composable policies cp are composed sequentially.
+func newTupleSpace(u *uri.SpaceURI, fr *function.Registry, connc chan *net.Conn, cp ...*policy.Composable) (ts *TupleSpace) {
+	ts = &TupleSpace{
+		muTuples:         new(sync.RWMutex),
+		muWaitingClients: new(sync.Mutex),
+		tuples:           []container.Tuple{},
+		muPolicy:         new(sync.RWMutex),
+		pol:              nil,
+		funReg:           fr,
+		name:             u.Space(),
+		port:             strings.Join([]string{u.Hostname(), u.Port()}, ":"),
+		connc:            connc,
+		guard:            newGuard(),
+		meter:            newMeter(),
+		waitingSince:     make(map[chan<- *container.Tuple]time.Time),
+	}
+
+	if len(cp) == 1 {
+		(*ts).pol = cp[0]
+	} else if len(cp) > 1 {
+		(*ts).pol = policy.Compose(policy.Sequential, cp...)
+	}
+
+	return ts
+}
+
+// newTransportSpace creates a representation of the tuple space located at URL u, reached through transport tr.
+// If host is true and nobody listens at the address of the tuple space yet, the tuple space is created and listens at it.
+func newTransportSpace(tr transport.Transport, u *uri.SpaceURI, fr *function.Registry, host bool, cp ...*policy.Composable) (ptp *protocol.PointToPoint, ts *TupleSpace) {
+	if host {
+		l, err := tr.Listen(strings.Join([]string{u.Hostname(), u.Port()}, ":"))
+
+		if err == nil {
+			ts = newTupleSpace(u, fr, make(chan *net.Conn), cp...)
+			(*ts).transport = tr
+
+			go ts.serve(l)
+		}
+	}
+
+	ptp = protocol.CreatePointToPoint(u.Space(), u.Hostname(), u.Port(), nil, fr)
+	ptp.SetTransport(tr)
+
+	return ptp, ts
+}
+
 // registerTypes registers all the types necessary for the implementation.
 func registerTypes() {
 	gob.Register(container.Label{})
 	gob.Register(container.Labels{})
+	gob.Register(container.LabelMatch{})
 	gob.Register(container.Template{})
 	gob.Register(container.Tuple{})
 	gob.Register(container.TypeField{})
+	gob.Register(container.FuncRef{})
 	gob.Register([]interface{}{})
 }
 
 // Size will open a TCP connection to the PointToPoint and request the size of the tuple space.
 func Size(ptp protocol.PointToPoint) (sz int, b bool) {
+	sz, err := sizeOperation(ptp)
+	b = err == nil
+	return sz, b
+}
+
+// sizeOperation requests the size of the tuple space at the PointToPoint.
+// sizeOperation returns an error err if the operation fails.
+func sizeOperation(ptp protocol.PointToPoint) (sz int, err error) {
 	var conn *net.Conn
-	var err error
 
-	defer tsAltLog(Size, &err)
+	defer logOperation(ptp, protocol.SizeRequest, nil, &err)
+	defer traceOperation(&ptp, protocol.SizeRequest)(&err)
 
 	sz = -1
-	b = false
 
-	conn, err = establishConnection(ptp)
+	conn, _, err = establishConnection(ptp)
 
 	if err != nil {
-		return sz, b
+		return sz, err
 	}
 
 	defer (*conn).Close()
 
-	err = sendMessage(conn, protocol.SizeRequest, "")
+	err = sendMessage(conn, ptp, protocol.SizeRequest, "")
 
 	if err != nil {
-		return sz, b
+		return sz, err
 	}
 
 	sz, err = receiveMessageInt(conn)
 
 	if err != nil {
 		sz = -1
-	} else {
-		b = true
 	}
 
-	return sz, b
+	return sz, err
 }
 
 // Put will open a TCP connection to the PointToPoint and send the message,
@@ -228,17 +264,28 @@ func Size(ptp protocol.PointToPoint) (sz int, b bool) {
 // The method returns a boolean to inform if the operation was carried out with
 // success or not.
 func Put(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tuple, b bool) {
-	var conn *net.Conn
-	var err error
+	t, err := putOperation(ptp, tupleFields...)
+	b = err == nil
+	return t, b
+}
 
-	defer tsAltLog(Put, &err)
+// putOperation performs a blocking placement of a tuple at the PointToPoint.
+// putOperation returns an error err if the operation fails.
+func putOperation(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tuple, err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
+	var b bool
 
-	b = false
+	defer logOperation(ptp, protocol.PutRequest, tupleFields, &err)
+	defer traceOperation(&ptp, protocol.PutRequest)(&err)
 
 	t = container.NewTuple(tupleFields...)
 
+	funcEncode(ptp.GetRegistry(), &t)
+	defer funcDecode(ptp.GetRegistry(), &t)
+
 	// Never time out and block until connection will be established.
-	conn, err = establishConnection(ptp)
+	conn, caps, err = establishConnection(ptp)
 
 	// TODO: Yes this is a bad idea, and we are doing it for now until semantics
 	// TODO: for what it means to block is established.
@@ -251,28 +298,34 @@ func Put(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tup
 			(*conn).Close()
 		}
 
-		conn, err = establishConnection(ptp)
+		conn, caps, err = establishConnection(ptp)
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &t)
-	defer funcDecode(ptp.GetRegistry(), &t)
+	err = checkCapabilities(ptp, caps, &t)
 
-	err = sendMessage(conn, protocol.PutRequest, t)
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	err = sendMessage(conn, ptp, protocol.PutRequest, t)
 
 	if err != nil {
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
 	b, err = receiveMessageBool(conn)
 
+	if err == nil && !b {
+		err = errOperationFailed
+	}
+
 	if err != nil {
-		b = false
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
-	return t, b
+	return t, err
 }
 
 // PutP will open a TCP connection to the PointToPoint and send the message,
@@ -282,35 +335,46 @@ func Put(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tup
 // The method returns a boolean to inform if the operation was carried out with
 // any errors with communication.
 func PutP(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tuple, b bool) {
-	var conn *net.Conn
-	var err error
+	t, err := putPOperation(ptp, tupleFields...)
+	b = err == nil
+	return t, b
+}
 
-	defer tsAltLog(PutP, &err)
+// putPOperation performs a non-blocking placement of a tuple at the PointToPoint.
+// putPOperation returns an error err if the operation fails.
+func putPOperation(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tuple, err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
 
-	b = false
+	defer logOperation(ptp, protocol.PutPRequest, tupleFields, &err)
+	defer traceOperation(&ptp, protocol.PutPRequest)(&err)
 
 	t = container.NewTuple(tupleFields...)
 
-	conn, err = establishConnection(ptp)
+	funcEncode(ptp.GetRegistry(), &t)
+	defer funcDecode(ptp.GetRegistry(), &t)
+
+	conn, caps, err = establishConnection(ptp)
 
 	if err != nil {
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &t)
-	defer funcDecode(ptp.GetRegistry(), &t)
-
-	err = sendMessage(conn, protocol.PutPRequest, t)
+	err = checkCapabilities(ptp, caps, &t)
 
 	if err != nil {
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
-	b = true
+	err = sendMessage(conn, ptp, protocol.PutPRequest, t)
 
-	return t, b
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	return t, err
 }
 
 // Get will open a TCP connection to the PointToPoint and send the message,
@@ -318,7 +382,8 @@ func PutP(ptp protocol.PointToPoint, tupleFields ...interface{}) (t container.Tu
 // The method returns a boolean to inform if the operation was carried out with
 // any errors with communication.
 func Get(ptp protocol.PointToPoint, tempFields ...interface{}) (t container.Tuple, b bool) {
-	t, b = getAndQuery(ptp, protocol.GetRequest, tempFields...)
+	t, err := getAndQueryUntil(ptp, protocol.GetRequest, nil, tempFields...)
+	b = err == nil
 	return t, b
 }
 
@@ -327,22 +392,28 @@ func Get(ptp protocol.PointToPoint, tempFields ...interface{}) (t container.Tupl
 // The method returns a boolean to inform if the operation was carried out with
 // any errors with communication.
 func Query(ptp protocol.PointToPoint, tempFields ...interface{}) (t container.Tuple, b bool) {
-	t, b = getAndQuery(ptp, protocol.QueryRequest, tempFields...)
+	t, err := getAndQueryUntil(ptp, protocol.QueryRequest, nil, tempFields...)
+	b = err == nil
 	return t, b
 }
 
-func getAndQuery(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (t container.Tuple, b bool) {
+// getAndQueryUntil performs a blocking retrieval or query which can be abandoned.
+// getAndQueryUntil closes the connection and returns once the channel done is closed.
+// A nil channel done never abandons the operation.
+// getAndQueryUntil returns an error err if the operation fails.
+func getAndQueryUntil(ptp protocol.PointToPoint, operation string, done <-chan struct{}, tempFields ...interface{}) (t container.Tuple, err error) {
 	var conn *net.Conn
-	var err error
-
-	defer tsAltLog(getAndQuery, &err)
+	var caps protocol.Capabilities
 
-	b = false
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
 
 	tp := container.NewTemplate(tempFields...)
 
+	funcEncode(ptp.GetRegistry(), &tp)
+
 	// Never time out and block until connection will be established.
-	conn, err = establishConnection(ptp)
+	conn, caps, err = establishConnection(ptp)
 
 	// Busy loop until a successful connection is established.
 	// TODO: Yes this is a bad idea, and we are doing it for now until semantics
@@ -356,31 +427,52 @@ func getAndQuery(ptp protocol.PointToPoint, operation string, tempFields ...inte
 			(*conn).Close()
 		}
 
-		conn, err = establishConnection(ptp)
+		select {
+		case <-done:
+			return container.NewTuple(nil), err
+		default:
+		}
+
+		conn, caps, err = establishConnection(ptp)
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &tp)
-	defer funcDecode(ptp.GetRegistry(), &t)
+	// Abandon the operation by closing the connection.
+	if done != nil {
+		finished := make(chan struct{})
+		defer close(finished)
+
+		go func(c net.Conn) {
+			select {
+			case <-done:
+				c.Close()
+			case <-finished:
+			}
+		}(*conn)
+	}
 
-	err = sendMessage(conn, operation, tp)
+	err = checkCapabilities(ptp, caps, &tp)
 
 	if err != nil {
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
-	t, err = receiveMessageTuple(conn)
+	err = sendMessage(conn, ptp, operation, tp)
 
 	if err != nil {
-		return container.NewTuple(nil), b
+		return container.NewTuple(nil), err
 	}
 
-	b = true
+	t, err = receiveMessageTuple(conn)
+
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
 
 	funcDecode(ptp.GetRegistry(), &t)
 
-	return t, b
+	return t, err
 }
 
 // GetP will open a TCP connection to the PointToPoint and send the message,
@@ -388,7 +480,8 @@ func getAndQuery(ptp protocol.PointToPoint, operation string, tempFields ...inte
 // The function will return two bool values. The first denotes if a tuple was
 // found, the second if there were any erors with communication.
 func GetP(ptp protocol.PointToPoint, tempFields ...interface{}) (container.Tuple, bool, bool) {
-	return getPAndQueryP(ptp, protocol.GetPRequest, tempFields...)
+	t, tb, err := getPAndQueryP(ptp, protocol.GetPRequest, tempFields...)
+	return t, tb, err == nil
 }
 
 // QueryP will open a TCP connection to the PointToPoint and send the message,
@@ -396,46 +489,53 @@ func GetP(ptp protocol.PointToPoint, tempFields ...interface{}) (container.Tuple
 // The function will return two bool values. The first denotes if a tuple was
 // found, the second if there were any erors with communication.
 func QueryP(ptp protocol.PointToPoint, tempFields ...interface{}) (container.Tuple, bool, bool) {
-	return getPAndQueryP(ptp, protocol.QueryPRequest, tempFields...)
+	t, tb, err := getPAndQueryP(ptp, protocol.QueryPRequest, tempFields...)
+	return t, tb, err == nil
 }
 
-func getPAndQueryP(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (t container.Tuple, tb bool, sb bool) {
+// getPAndQueryP performs a non-blocking retrieval or query at the PointToPoint.
+// getPAndQueryP returns true if a tuple was found, and an error err if the operation fails.
+func getPAndQueryP(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (t container.Tuple, tb bool, err error) {
 	var conn *net.Conn
-	var err error
+	var caps protocol.Capabilities
 
-	defer tsAltLog(getPAndQueryP, &err)
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
 
 	tb = false
-	sb = false
 
 	tp := container.NewTemplate(tempFields...)
 
-	conn, err = establishConnection(ptp)
+	funcEncode(ptp.GetRegistry(), &tp)
+	defer funcDecode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
 
 	if err != nil {
-		return container.NewTuple(nil), tb, sb
+		return container.NewTuple(nil), tb, err
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &tp)
-	defer funcDecode(ptp.GetRegistry(), &tp)
+	err = checkCapabilities(ptp, caps, &tp)
+
+	if err != nil {
+		return container.NewTuple(nil), tb, err
+	}
 
-	err = sendMessage(conn, operation, tp)
+	err = sendMessage(conn, ptp, operation, tp)
 
 	if err != nil {
-		return container.NewTuple(nil), tb, sb
+		return container.NewTuple(nil), tb, err
 	}
 
 	tb, t, err = receiveMessageBoolAndTuple(conn)
 
 	if err != nil {
-		return container.NewTuple(nil), tb, sb
+		return container.NewTuple(nil), tb, err
 	}
 
-	sb = true
-
-	return t, tb, sb
+	return t, tb, err
 }
 
 // GetAll will open a TCP connection to the PointToPoint and send the message,
@@ -444,7 +544,8 @@ func getPAndQueryP(ptp protocol.PointToPoint, operation string, tempFields ...in
 // space as well as a bool to denote if there were any errors with the
 // communication.
 func GetAll(ptp protocol.PointToPoint, tempFields ...interface{}) (ts []container.Tuple, b bool) {
-	ts, b = getAllAndQueryAll(ptp, protocol.GetAllRequest, tempFields...)
+	ts, err := getAllAndQueryAll(ptp, protocol.GetAllRequest, tempFields...)
+	b = err == nil
 	return ts, b
 }
 
@@ -454,50 +555,57 @@ func GetAll(ptp protocol.PointToPoint, tempFields ...interface{}) (ts []containe
 // space as well as a bool to denote if there were any errors with the
 // communication.
 func QueryAll(ptp protocol.PointToPoint, tempFields ...interface{}) (ts []container.Tuple, b bool) {
-	ts, b = getAllAndQueryAll(ptp, protocol.QueryAllRequest, tempFields...)
+	ts, err := getAllAndQueryAll(ptp, protocol.QueryAllRequest, tempFields...)
+	b = err == nil
 	return ts, b
 }
 
-func getAllAndQueryAll(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (ts []container.Tuple, b bool) {
+// getAllAndQueryAll performs a non-blocking retrieval or query for all tuples at the PointToPoint.
+// getAllAndQueryAll returns an error err if the operation fails.
+func getAllAndQueryAll(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (ts []container.Tuple, err error) {
 	var conn *net.Conn
-	var err error
+	var caps protocol.Capabilities
 
-	defer tsAltLog(getAllAndQueryAll, &err)
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
 
 	ts = []container.Tuple{}
-	b = false
 
 	tp := container.NewTemplate(tempFields...)
 
-	conn, err = establishConnection(ptp)
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
 
 	if err != nil {
-		return ts, b
+		return ts, err
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &tp)
+	err = checkCapabilities(ptp, caps, &tp)
 
-	err = sendMessage(conn, operation, tp)
+	if err != nil {
+		return ts, err
+	}
+
+	err = sendMessage(conn, ptp, operation, tp)
 
 	if err != nil {
-		return ts, b
+		return ts, err
 	}
 
 	ts, err = receiveMessageTupleList(conn)
 
 	if err != nil {
-		return ts, b
+		return []container.Tuple{}, err
 	}
 
-	b = true
-
 	for _, t := range ts {
 		funcDecode(ptp.GetRegistry(), &t)
 	}
 
-	return ts, b
+	return ts, err
 }
 
 // PutAgg will connect to a space and aggregate on matched tuples from the space according to a template.
@@ -505,7 +613,8 @@ func getAllAndQueryAll(ptp protocol.PointToPoint, operation string, tempFields .
 // with the communication. The tuple returned is the aggregation of tuples in the space.
 // If no tuples are found it will create and put a new tuple from the template itself.
 func PutAgg(ptp protocol.PointToPoint, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
-	t, b = aggOperation(ptp, protocol.PutAggRequest, fun, tempFields...)
+	t, err := aggOperation(ptp, protocol.PutAggRequest, fun, tempFields...)
+	b = err == nil
 	return t, b
 }
 
@@ -514,7 +623,8 @@ func PutAgg(ptp protocol.PointToPoint, fun interface{}, tempFields ...interface{
 // as well as a boolean state to denote if there were any errors with the communication.
 // The resulting tuple is empty if no matching occurs or the aggregation function can not aggregate the matched tuples.
 func GetAgg(ptp protocol.PointToPoint, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
-	t, b = aggOperation(ptp, protocol.GetAggRequest, fun, tempFields...)
+	t, err := aggOperation(ptp, protocol.GetAggRequest, fun, tempFields...)
+	b = err == nil
 	return t, b
 }
 
@@ -523,64 +633,463 @@ func GetAgg(ptp protocol.PointToPoint, fun interface{}, tempFields ...interface{
 // The method is nonblocking and will return a tuple found by aggregating the matched typles.
 // The resulting tuple is empty if no matching occurs or the aggregation function can not aggregate the matched tuples.
 func QueryAgg(ptp protocol.PointToPoint, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
-	t, b = aggOperation(ptp, protocol.QueryAggRequest, fun, tempFields...)
+	t, err := aggOperation(ptp, protocol.QueryAggRequest, fun, tempFields...)
+	b = err == nil
 	return t, b
 }
 
-func aggOperation(ptp protocol.PointToPoint, operation string, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
-	var conn *net.Conn
-	var err error
+// GetAggN will connect to a space and aggregate on n matched tuples from the space.
+// This method is blocking and waits until at least n tuples match the template, and then removes and aggregates exactly n of them.
+// The method will return the aggregated tuple as well as a boolean state to denote if there were any errors with the communication.
+func GetAggN(ptp protocol.PointToPoint, n int, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
+	t, err := aggNOperation(ptp, protocol.GetAggNRequest, n, fun, tempFields...)
+	b = err == nil
+	return t, b
+}
 
-	defer tsAltLog(aggOperation, &err)
+// QueryAggN will connect to a space and aggregate on n matched tuples from the space.
+// This method is blocking and waits until at least n tuples match the template, and then aggregates exactly n of them.
+// The method will return the aggregated tuple as well as a boolean state to denote if there were any errors with the communication.
+func QueryAggN(ptp protocol.PointToPoint, n int, fun interface{}, tempFields ...interface{}) (t container.Tuple, b bool) {
+	t, err := aggNOperation(ptp, protocol.QueryAggNRequest, n, fun, tempFields...)
+	b = err == nil
+	return t, b
+}
 
-	t = container.NewTuple()
-	b = false
+// aggOperation performs an aggregation operation with function fun at the PointToPoint.
+// aggOperation returns an error err if the operation fails.
+func aggOperation(ptp protocol.PointToPoint, operation string, fun interface{}, tempFields ...interface{}) (t container.Tuple, err error) {
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
 
 	fields := make([]interface{}, len(tempFields)+1)
 	fields[0] = fun
 	copy(fields[1:], tempFields)
 	tp := container.NewTemplate(fields...)
 
-	conn, err = establishConnection(ptp)
+	t, err = aggTemplateOperation(ptp, operation, tp)
+
+	return t, err
+}
+
+// aggNOperation performs an aggregation operation on n tuples with function fun at the PointToPoint.
+// aggNOperation returns an error err if the operation fails.
+func aggNOperation(ptp protocol.PointToPoint, operation string, n int, fun interface{}, tempFields ...interface{}) (t container.Tuple, err error) {
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
+
+	fields := make([]interface{}, len(tempFields)+2)
+	fields[0] = n
+	fields[1] = fun
+	copy(fields[2:], tempFields)
+	tp := container.NewTemplate(fields...)
+
+	t, err = aggTemplateOperation(ptp, operation, tp)
+
+	return t, err
+}
+
+// aggTemplateOperation sends the aggregation operation with template tp to the PointToPoint and receives the aggregated tuple t.
+// aggTemplateOperation returns an error err if the operation fails.
+func aggTemplateOperation(ptp protocol.PointToPoint, operation string, tp container.Template) (t container.Tuple, err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
+
+	t = container.NewTuple()
+
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
 
 	if err != nil {
-		return t, b
+		return t, err
 	}
 
 	defer (*conn).Close()
 
-	funcEncode(ptp.GetRegistry(), &tp)
+	err = checkCapabilities(ptp, caps, &tp)
 
-	err = sendMessage(conn, operation, tp)
+	if err != nil {
+		return t, err
+	}
+
+	err = sendMessage(conn, ptp, operation, tp)
 
 	if err != nil {
-		return t, b
+		return t, err
 	}
 
-	t, err = receiveMessageTuple(conn)
+	// The space refuses aggregations the policy forbids once it has matched the tuples to aggregate.
+	err = receiveStatus(conn)
 
 	if err != nil {
-		return t, b
+		return t, err
 	}
 
-	b = true
+	t, err = receiveMessageTuple(conn)
+
+	if err != nil {
+		return t, err
+	}
 
 	funcDecode(ptp.GetRegistry(), &t)
 
+	return t, err
+}
+
+// Eval will connect to a space and request the evaluation of function fun with arguments args at the host of the space.
+// This method is nonblocking and returns once the space has accepted the request, together with a boolean state
+// to denote if there were any errors with the communication. The tuple returned contains the function and its arguments.
+// The space places the result of the evaluation, or an error tuple, in itself once the evaluation finishes.
+func Eval(ptp protocol.PointToPoint, fun interface{}, args ...interface{}) (t container.Tuple, b bool) {
+	t, err := evalOperation(ptp, fun, args...)
+	b = err == nil
 	return t, b
 }
 
+// evalOperation requests the evaluation of function fun with arguments args at the PointToPoint.
+// evalOperation returns an error err if the operation fails.
+func evalOperation(ptp protocol.PointToPoint, fun interface{}, args ...interface{}) (t container.Tuple, err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
+	var b bool
+
+	defer logOperation(ptp, protocol.EvalRequest, args, &err)
+	defer traceOperation(&ptp, protocol.EvalRequest)(&err)
+
+	fields := make([]interface{}, len(args)+1)
+	fields[0] = fun
+	copy(fields[1:], args)
+	t = container.NewTuple(fields...)
+	tp := container.NewTemplate(fields...)
+
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
+
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	defer (*conn).Close()
+
+	err = checkCapabilities(ptp, caps, &tp)
+
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	err = sendMessage(conn, ptp, protocol.EvalRequest, tp)
+
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	b, err = receiveMessageBool(conn)
+
+	if err == nil && !b {
+		err = errOperationFailed
+	}
+
+	if err != nil {
+		return container.NewTuple(nil), err
+	}
+
+	return t, err
+}
+
+// SetPolicy will open a TCP connection to the PointToPoint and replace the policy of the space by the composable policy cp.
+// SetPolicy returns the version v of the new policy, and a boolean b telling whether the operation succeeded.
+func SetPolicy(ptp protocol.PointToPoint, cp *policy.Composable) (v int, b bool) {
+	v, err := setPolicyOperation(ptp, cp)
+	b = err == nil
+	return v, b
+}
+
+// UpdatePolicy will open a TCP connection to the PointToPoint and patch the policy of the space.
+// The aggregation policies of cp replace those with the same label or are added, and those labelled by remove are removed.
+// UpdatePolicy returns the version v of the patched policy, and a boolean b telling whether the operation succeeded.
+func UpdatePolicy(ptp protocol.PointToPoint, cp *policy.Composable, remove ...container.Label) (v int, b bool) {
+	v, err := updatePolicyOperation(ptp, cp, remove...)
+	b = err == nil
+	return v, b
+}
+
+// setPolicyOperation replaces the policy of the space at the PointToPoint by the composable policy cp.
+// setPolicyOperation returns an error err if the operation fails.
+func setPolicyOperation(ptp protocol.PointToPoint, cp *policy.Composable) (v int, err error) {
+	defer logOperation(ptp, protocol.SetPolicyRequest, nil, &err)
+	defer traceOperation(&ptp, protocol.SetPolicyRequest)(&err)
+
+	data, err := policy.Marshal(cp, ptp.GetRegistry())
+
+	if err != nil {
+		return -1, err
+	}
+
+	v, err = policyOperation(ptp, protocol.SetPolicyRequest, container.NewTemplate(string(data)))
+
+	return v, err
+}
+
+// updatePolicyOperation patches the policy of the space at the PointToPoint with the composable policy cp and the labels remove.
+// updatePolicyOperation returns an error err if the operation fails.
+func updatePolicyOperation(ptp protocol.PointToPoint, cp *policy.Composable, remove ...container.Label) (v int, err error) {
+	defer logOperation(ptp, protocol.UpdatePolicyRequest, nil, &err)
+	defer traceOperation(&ptp, protocol.UpdatePolicyRequest)(&err)
+
+	data, err := policy.Marshal(cp, ptp.GetRegistry())
+
+	if err != nil {
+		return -1, err
+	}
+
+	ids := make([]string, len(remove))
+	for i, l := range remove {
+		ids[i] = l.ID()
+	}
+
+	v, err = policyOperation(ptp, protocol.UpdatePolicyRequest, container.NewTemplate(string(data), ids))
+
+	return v, err
+}
+
+// policyOperation sends the policy change operation with body tp to the space at the PointToPoint.
+// policyOperation returns the version v of the changed policy, and an error err if the operation fails.
+func policyOperation(ptp protocol.PointToPoint, operation string, tp container.Template) (v int, err error) {
+	var conn *net.Conn
+
+	v = -1
+
+	conn, _, err = establishConnection(ptp)
+
+	if err != nil {
+		return v, err
+	}
+
+	defer (*conn).Close()
+
+	err = sendMessage(conn, ptp, operation, tp)
+
+	if err == nil {
+		err = receiveStatus(conn)
+	}
+
+	if err == nil {
+		v, err = receiveMessageInt(conn)
+	}
+
+	if err != nil {
+		v = -1
+	}
+
+	return v, err
+}
+
+// ExplainPolicy will open a TCP connection to the PointToPoint and explain how the policy of the space decides
+// the operation named operation on template tempFields for the caller, without performing the operation.
+// ExplainPolicy returns the explanation e, and a boolean b telling whether the operation succeeded.
+func ExplainPolicy(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (e policy.Explanation, b bool) {
+	e, err := explainPolicyOperation(ptp, operation, tempFields...)
+	b = err == nil
+	return e, b
+}
+
+// explainPolicyOperation requests an explanation of the policy of the space at the PointToPoint for the operation named operation on template tempFields.
+// explainPolicyOperation returns an error err if the operation fails.
+func explainPolicyOperation(ptp protocol.PointToPoint, operation string, tempFields ...interface{}) (e policy.Explanation, err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
+
+	defer logOperation(ptp, protocol.ExplainPolicyRequest, tempFields, &err)
+	defer traceOperation(&ptp, protocol.ExplainPolicyRequest)(&err)
+
+	fields := make([]interface{}, len(tempFields)+1)
+	fields[0] = operation
+	copy(fields[1:], tempFields)
+	tp := container.NewTemplate(fields...)
+
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
+
+	if err != nil {
+		return e, err
+	}
+
+	defer (*conn).Close()
+
+	err = checkCapabilities(ptp, caps, &tp)
+
+	if err == nil {
+		err = sendMessage(conn, ptp, protocol.ExplainPolicyRequest, tp)
+	}
+
+	if err == nil {
+		err = receiveStatus(conn)
+	}
+
+	if err == nil {
+		dec := decoderOf(*conn)
+		err = dec.Decode(&e)
+	}
+
+	return e, err
+}
+
+// InspectTuples will open a TCP connection to the PointToPoint and request the page of at most limit tuples matching tempFields,
+// starting at the match with index offset. All tuples are inspected if tempFields is empty, and all matches from offset if limit is less than 1.
+// InspectTuples returns the page, and a boolean b telling whether the operation succeeded.
+func InspectTuples(ptp protocol.PointToPoint, offset int, limit int, tempFields ...interface{}) (page TuplePage, b bool) {
+	fields := make([]interface{}, len(tempFields)+2)
+	fields[0] = offset
+	fields[1] = limit
+	copy(fields[2:], tempFields)
+
+	err := inspectOperation(ptp, protocol.InspectTuplesRequest, fields, &page)
+	b = err == nil
+	return page, b
+}
+
+// InspectTuplesAfter will open a TCP connection to the PointToPoint and request the page of at most limit tuples matching tempFields,
+// placed after cursor. The page tells the cursor of its last tuple, from which the following page is inspected.
+// InspectTuplesAfter returns the page, and a boolean b telling whether the operation succeeded.
+func InspectTuplesAfter(ptp protocol.PointToPoint, cursor TupleCursor, limit int, tempFields ...interface{}) (page TuplePage, b bool) {
+	fields := make([]interface{}, len(tempFields)+2)
+	fields[0] = cursor
+	fields[1] = limit
+	copy(fields[2:], tempFields)
+
+	err := inspectOperation(ptp, protocol.InspectTuplesRequest, fields, &page)
+	b = err == nil
+	return page, b
+}
+
+// InspectWaitingClients will open a TCP connection to the PointToPoint and request the operations waiting for tuples.
+// InspectWaitingClients returns the waiting operations, and a boolean b telling whether the operation succeeded.
+func InspectWaitingClients(ptp protocol.PointToPoint) (waiting []WaitingClientInfo, b bool) {
+	err := inspectOperation(ptp, protocol.InspectWaitingClientsRequest, nil, &waiting)
+	b = err == nil
+	return waiting, b
+}
+
+// InspectConnections will open a TCP connection to the PointToPoint and request the connections from peers being handled.
+// InspectConnections returns the connections, and a boolean b telling whether the operation succeeded.
+func InspectConnections(ptp protocol.PointToPoint) (conns []ConnectionInfo, b bool) {
+	err := inspectOperation(ptp, protocol.InspectConnectionsRequest, nil, &conns)
+	b = err == nil
+	return conns, b
+}
+
+// InspectPolicy will open a TCP connection to the PointToPoint and request the policy governing the space.
+// InspectPolicy returns the policy, and a boolean b telling whether the operation succeeded.
+func InspectPolicy(ptp protocol.PointToPoint) (pi PolicyInfo, b bool) {
+	err := inspectOperation(ptp, protocol.InspectPolicyRequest, nil, &pi)
+	b = err == nil
+	return pi, b
+}
+
+// InspectFunctions will open a TCP connection to the PointToPoint and request the namespaces of the functions registered at the space.
+// InspectFunctions returns the namespaces, and a boolean b telling whether the operation succeeded.
+func InspectFunctions(ptp protocol.PointToPoint) (names []string, b bool) {
+	err := inspectOperation(ptp, protocol.InspectFunctionsRequest, nil, &names)
+	b = err == nil
+	return names, b
+}
+
+// inspectOperation requests the snapshot of the space at the PointToPoint taken by the introspection operation with template fields tempFields.
+// inspectOperation decodes the snapshot into v, and returns an error err if the operation fails.
+func inspectOperation(ptp protocol.PointToPoint, operation string, tempFields []interface{}, v interface{}) (err error) {
+	var conn *net.Conn
+	var caps protocol.Capabilities
+
+	defer logOperation(ptp, operation, tempFields, &err)
+	defer traceOperation(&ptp, operation)(&err)
+
+	tp := container.NewTemplate(tempFields...)
+
+	funcEncode(ptp.GetRegistry(), &tp)
+
+	conn, caps, err = establishConnection(ptp)
+
+	if err != nil {
+		return err
+	}
+
+	defer (*conn).Close()
+
+	err = checkCapabilities(ptp, caps, &tp)
+
+	if err == nil {
+		err = sendMessage(conn, ptp, operation, tp)
+	}
+
+	if err == nil {
+		err = receiveStatus(conn)
+	}
+
+	if err == nil {
+		dec := decoderOf(*conn)
+		err = dec.Decode(v)
+	}
+
+	return err
+}
+
 // establishConnection will establish a connection to the PointToPoint ptp and
-// return the Conn and error.
-func establishConnection(ptp protocol.PointToPoint, timeout ...time.Duration) (*net.Conn, error) {
+// return the Conn, the capabilities of the space and error.
+// The capabilities are exchanged in a handshake as soon as the connection is established.
+func establishConnection(ptp protocol.PointToPoint, timeout ...time.Duration) (*net.Conn, protocol.Capabilities, error) {
 	var conn net.Conn
+	var caps protocol.Capabilities
 	var err error
 
+	span := startSpan(ptp, "establishConnection")
+	defer span.End()
+
 	addr := ptp.GetAddress()
 
-	host, _, err := net.SplitHostPort(addr)
+	var wait time.Duration
+
+	if len(timeout) > 0 {
+		wait = timeout[0]
+	}
+
+	if tr := ptp.GetTransport(); tr != nil {
+		conn, err = tr.Dial(addr, wait)
+	} else {
+		conn, err = dialLocal(ptp, wait)
+	}
+
+	id := ptp.GetIdentity()
+
+	if err == nil && id != nil {
+		var idconn net.Conn
+		idconn, err = id.Connect(conn)
+
+		if err == nil {
+			conn = idconn
+		} else {
+			conn.Close()
+		}
+	}
+
+	if err == nil {
+		conn = newCodecConn(conn)
+		caps, err = handshake(&conn, ptp.GetRegistry(), id)
+	}
+
+	span.SetError(err)
+
+	return &conn, caps, err
+}
+
+// dialLocal will connect to the PointToPoint ptp through a pipe if the tuple space is local,
+// and otherwise through TCP, giving up after timeout unless it is 0.
+func dialLocal(ptp protocol.PointToPoint, timeout time.Duration) (conn net.Conn, err error) {
+	addr := ptp.GetAddress()
 
-	proto := "tcp4"
+	host, _, err := net.SplitHostPort(addr)
 
 	ips, err := net.LookupIP(host)
 
@@ -598,32 +1107,110 @@ func establishConnection(ptp protocol.PointToPoint, timeout ...time.Duration) (*
 			conn = r
 			(*connc) <- &w
 		} else {
-			if len(timeout) == 0 {
-				conn, err = net.Dial(proto, addr)
-			} else {
-				conn, err = net.DialTimeout(proto, addr, timeout[0])
-			}
+			conn, err = transport.TCP{}.Dial(addr, timeout)
+		}
+	}
+
+	return conn, err
+}
+
+// handshake sends the capabilities of the client with function registry fr through the connection conn,
+// followed by the credentials of identity id once the capabilities of the space have been received.
+// handshake returns the capabilities caps of the space at the other end of the connection.
+func handshake(conn *net.Conn, fr *function.Registry, id auth.Identity) (caps protocol.Capabilities, err error) {
+	enc := encoderOf(*conn)
+
+	err = enc.Encode(protocol.CreateCapabilities(fr))
+
+	if err != nil {
+		return caps, err
+	}
+
+	dec := decoderOf(*conn)
+
+	err = dec.Decode(&caps)
+
+	if err != nil {
+		return caps, err
+	}
+
+	var creds auth.Credentials
+	if id != nil {
+		creds, err = id.Credentials()
+
+		if err != nil {
+			return caps, err
 		}
 	}
 
-	return &conn, err
+	err = enc.Encode(creds)
+
+	return caps, err
 }
 
-func sendMessage(conn *net.Conn, operation string, t interface{}) (err error) {
+// checkCapabilities checks that all functions referenced in a tuple or template t can be resolved by a space with capabilities caps.
+// checkCapabilities returns an error err naming the first function that the space can not resolve.
+func checkCapabilities(ptp protocol.PointToPoint, caps protocol.Capabilities, t interface{}) (err error) {
+	a := t.(container.Applier)
+
+	a.Apply(func(field interface{}) interface{} {
+		if ref, ok := field.(container.FuncRef); ok && err == nil && !caps.Supports(ref.Namespace) {
+			err = fmt.Errorf("function %s is not registered at the space at %s running %s", ref, ptp.GetAddress(), caps.GetVersion())
+		}
+
+		return field
+	})
+
+	return err
+}
+
+// sendMessage sends the operation with body t, and the labels and span context of the sender using the PointToPoint ptp, to a space.
+// sendMessage returns an error err if the message could not be sent or the space refused the operation.
+func sendMessage(conn *net.Conn, ptp protocol.PointToPoint, operation string, t interface{}) (err error) {
+	span := startSpan(ptp, "sendMessage")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	gob.Register(t)
 	gob.Register(container.TypeField{})
 
-	enc := gob.NewEncoder(*conn)
+	enc := encoderOf(*conn)
 
 	message := protocol.CreateMessage(operation, t)
+	message.SetLabels(ptp.GetLabels())
+	message.SetTrace(ptp.GetTrace())
 
 	err = enc.Encode(message)
 
+	if err != nil {
+		return err
+	}
+
+	// The space replies whether it accepts the operation before responding to it.
+	err = receiveStatus(conn)
+
+	return err
+}
+
+// receiveStatus receives the status of an operation from a space.
+// receiveStatus returns an error err if the status could not be received or the space refused the operation.
+func receiveStatus(conn *net.Conn) (err error) {
+	dec := decoderOf(*conn)
+
+	var status protocol.Status
+	err = dec.Decode(&status)
+
+	if err == nil {
+		err = status.GetError()
+	}
+
 	return err
 }
 
 func receiveMessageBool(conn *net.Conn) (b bool, err error) {
-	dec := gob.NewDecoder(*conn)
+	dec := decoderOf(*conn)
 
 	err = dec.Decode(&b)
 
@@ -631,7 +1218,7 @@ func receiveMessageBool(conn *net.Conn) (b bool, err error) {
 }
 
 func receiveMessageInt(conn *net.Conn) (i int, err error) {
-	dec := gob.NewDecoder(*conn)
+	dec := decoderOf(*conn)
 
 	err = dec.Decode(&i)
 
@@ -639,7 +1226,7 @@ func receiveMessageInt(conn *net.Conn) (i int, err error) {
 }
 
 func receiveMessageTuple(conn *net.Conn) (t container.Tuple, err error) {
-	dec := gob.NewDecoder(*conn)
+	dec := decoderOf(*conn)
 
 	err = dec.Decode(&t)
 
@@ -647,11 +1234,15 @@ func receiveMessageTuple(conn *net.Conn) (t container.Tuple, err error) {
 }
 
 func receiveMessageBoolAndTuple(conn *net.Conn) (b bool, t container.Tuple, err error) {
-	dec := gob.NewDecoder(*conn)
+	dec := decoderOf(*conn)
 
 	var result []interface{}
 	err = dec.Decode(&result)
 
+	if err != nil {
+		return b, t, err
+	}
+
 	b = result[0].(bool)
 	t = result[1].(container.Tuple)
 
@@ -659,7 +1250,7 @@ func receiveMessageBoolAndTuple(conn *net.Conn) (b bool, t container.Tuple, err
 }
 
 func receiveMessageTupleList(conn *net.Conn) (ts []container.Tuple, err error) {
-	dec := gob.NewDecoder(*conn)
+	dec := decoderOf(*conn)
 
 	err = dec.Decode(&ts)
 
@@ -1,8 +1,12 @@
 package space
 
 import (
+	"bytes"
+	"encoding/gob"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pspaces/gospace/container"
 	"github.com/pspaces/gospace/protocol"
@@ -133,3 +137,36 @@ func TestGetAllAndQueryAll(t *testing.T) {
 		t.Errorf("i was overwritten")
 	}
 }
+
+func TestReceiveSingleWrite(t *testing.T) {
+	// Setup
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// The status and the response arrive in a single write, such that decoding the status may read ahead into the response.
+	go func() {
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+
+		enc.Encode(protocol.CreateAcceptance())
+		enc.Encode(true)
+		server.Write(buf.Bytes())
+	}()
+
+	conn := net.Conn(newCodecConn(client))
+
+	// The client would otherwise wait for a response it has already received.
+	client.SetReadDeadline(time.Now().Add(time.Second))
+
+	// Test
+	err := receiveStatus(&conn)
+	if err != nil {
+		t.Fatalf("receiveStatus() gave %v, should be nil", err)
+	}
+
+	b, err := receiveMessageBool(&conn)
+	if err != nil || !b {
+		t.Errorf("receiveMessageBool() gave %t and %v, should be true and nil", b, err)
+	}
+}
@@ -1,10 +1,15 @@
 package space
 
 import (
+	"bytes"
+	"encoding/gob"
+	"net"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/pspaces/gospace/auth"
 	. "github.com/pspaces/gospace/container"
 	. "github.com/pspaces/gospace/protocol"
 )
@@ -492,6 +497,117 @@ func TestQueryAll(t *testing.T) {
 	}
 }
 
+func TestFoldTuplesRemove(t *testing.T) {
+	// Setup
+	testTupleSpace := createTestTupleSpace(9024)
+	testTuple := NewTuple([]interface{}{"Matching field", 2}...)
+	otherTuple := NewTuple([]interface{}{"Other field", 3}...)
+	testTupleSpace.putP(&testTuple)
+	testTupleSpace.putP(&otherTuple)
+	testTupleSpace.putP(&testTuple)
+
+	var i int
+	testTemplate := NewTemplate([]interface{}{"Matching field", &i}...)
+	testResponse, err := testTupleSpace.foldTuples(testTemplate, foldTestSum, nil, nil, true, false)
+
+	expected := NewTuple([]interface{}{"Matching field", 4}...)
+	if err != nil || !reflect.DeepEqual(testResponse, &expected) {
+		t.Errorf("foldTuples() gave %+v and %v but was expected to return %+v.", testResponse, err, expected)
+	}
+
+	if testTupleSpace.Size() != 1 || !reflect.DeepEqual(testTupleSpace.tuples[0], otherTuple) {
+		t.Errorf("The tuples %+v were expected to be only %+v.", testTupleSpace.tuples, otherTuple)
+	}
+}
+
+func TestFoldTuplesPlace(t *testing.T) {
+	// Setup
+	testTupleSpace := createTestTupleSpace(9025)
+	testTuple := NewTuple([]interface{}{"Matching field", 2}...)
+	testTupleSpace.putP(&testTuple)
+	testTupleSpace.putP(&testTuple)
+
+	var i int
+	testTemplate := NewTemplate([]interface{}{"Matching field", &i}...)
+	testTupleSpace.foldTuples(testTemplate, foldTestSum, nil, nil, true, true)
+
+	expected := NewTuple([]interface{}{"Matching field", 4}...)
+	if testTupleSpace.Size() != 1 || !reflect.DeepEqual(testTupleSpace.tuples[0], expected) {
+		t.Errorf("The tuples %+v were expected to be only %+v.", testTupleSpace.tuples, expected)
+	}
+}
+
+func TestFoldTuplesNoRemove(t *testing.T) {
+	// Setup
+	testTupleSpace := createTestTupleSpace(9026)
+	testTuple := NewTuple([]interface{}{"Matching field", 2}...)
+	testTupleSpace.putP(&testTuple)
+	testTupleSpace.putP(&testTuple)
+
+	var i int
+	testTemplate := NewTemplate([]interface{}{"Matching field", &i}...)
+	testTupleSpace.foldTuples(testTemplate, foldTestSum, nil, nil, false, false)
+
+	if testTupleSpace.Size() != 2 {
+		t.Errorf("The size of %+v was %d but was expected to have size 2", testTupleSpace.tuples, testTupleSpace.Size())
+	}
+}
+
+func foldTestSum(ts ...Intertuple) Intertuple {
+	x, y := ts[0], ts[1]
+	sum := NewTuple(x.GetFieldAt(0), x.GetFieldAt(1).(int)+y.GetFieldAt(1).(int))
+	return &sum
+}
+
 func createTestTupleSpace(testPort int) *TupleSpace {
 	return CreateTupleSpace(testPort)
 }
+
+func TestHandleSingleWrite(t *testing.T) {
+	// Setup
+	spc := NewSpace("tcp://localhost:31738/codec")
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	spc.ts.connc <- &server
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	enc.Encode(CreateCapabilities(nil))
+	client.Write(buf.Bytes())
+	buf.Reset()
+
+	dec := gob.NewDecoder(client)
+
+	var caps Capabilities
+	err := dec.Decode(&caps)
+	if err != nil {
+		t.Fatalf("Decode() gave %v for the capabilities, should be nil", err)
+	}
+
+	// Test
+	// The credentials and the message arrive in a single write, such that decoding the credentials may read ahead into the message.
+	tuple := NewTuple("codec", 1)
+	gob.Register(tuple)
+
+	enc.Encode(auth.Credentials{})
+	enc.Encode(CreateMessage(PutRequest, tuple))
+	client.Write(buf.Bytes())
+
+	// The space would otherwise wait for a message it has already received.
+	client.SetReadDeadline(time.Now().Add(time.Second))
+
+	var status Status
+	err = dec.Decode(&status)
+	if err != nil || status.GetError() != nil {
+		t.Fatalf("Decode() gave %v and status %v, should be nil and an acceptance", err, status.GetError())
+	}
+
+	var b bool
+	err = dec.Decode(&b)
+	if err != nil || !b {
+		t.Errorf("Decode() gave %v and %t for the Put() response, should be nil and true", err, b)
+	}
+}
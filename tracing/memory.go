@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"sync"
+)
+
+// InMemoryExporter is an exporter keeping ended spans in memory, e.g. for inspecting them in tests.
+type InMemoryExporter struct {
+	mu    *sync.Mutex
+	spans []*Span
+}
+
+// NewInMemoryExporter creates an exporter exp keeping no spans yet.
+func NewInMemoryExporter() (exp *InMemoryExporter) {
+	exp = &InMemoryExporter{mu: new(sync.Mutex)}
+	return exp
+}
+
+// Export keeps span s in exporter exp.
+func (exp *InMemoryExporter) Export(s *Span) {
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+
+	exp.spans = append(exp.spans, s)
+}
+
+// Spans returns the spans ss kept by exporter exp, in the order they ended.
+func (exp *InMemoryExporter) Spans() (ss []*Span) {
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+
+	ss = make([]*Span, len(exp.spans))
+	copy(ss, exp.spans)
+
+	return ss
+}
+
+// Trace returns the spans ss of the trace identified by id kept by exporter exp, in the order they ended.
+func (exp *InMemoryExporter) Trace(id TraceID) (ss []*Span) {
+	for _, s := range exp.Spans() {
+		if s.Context.TraceID == id {
+			ss = append(ss, s)
+		}
+	}
+
+	return ss
+}
+
+// Reset discards the spans kept by exporter exp.
+func (exp *InMemoryExporter) Reset() {
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+
+	exp.spans = nil
+}
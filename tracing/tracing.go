@@ -0,0 +1,209 @@
+// Package tracing provides distributed tracing of operations on spaces.
+//
+// Operations are traced as spans, in the style of OpenTelemetry: a peer traces an operation on a remote space as a client span,
+// and sends its span context along with the operation, such that the space traces handling it as a server span of the same trace.
+// Ended spans are handed to an Exporter, which can forward them to any tracing system.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// String returns the trace identifier id in hexadecimal.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the span identifier id in hexadecimal.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a span across peers, and is sent along with the operations it traces.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid returns true if span context sc identifies a span, and false if it is empty.
+func (sc SpanContext) IsValid() (b bool) {
+	b = sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+	return b
+}
+
+// String returns span context sc in the format of the W3C traceparent header.
+func (sc SpanContext) String() string {
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// ParseSpanContext parses the span context sc from s in the format of the W3C traceparent header,
+// e.g. such that operations can continue a trace received over HTTP.
+func ParseSpanContext(s string) (sc SpanContext, err error) {
+	parts := strings.Split(s, "-")
+
+	if len(parts) != 4 || len(parts[1]) != 2*len(sc.TraceID) || len(parts[2]) != 2*len(sc.SpanID) {
+		err = fmt.Errorf("malformed traceparent %q", s)
+		return sc, err
+	}
+
+	_, err = hex.Decode(sc.TraceID[:], []byte(parts[1]))
+
+	if err == nil {
+		_, err = hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	}
+
+	if err == nil && !sc.IsValid() {
+		err = fmt.Errorf("traceparent %q identifies no span", s)
+	}
+
+	if err != nil {
+		sc = SpanContext{}
+	}
+
+	return sc, err
+}
+
+// Kind describes the role of a span in an operation.
+type Kind int
+
+// Kinds of spans.
+const (
+	// Internal spans trace work within a peer or a space.
+	Internal Kind = iota
+	// Client spans trace operations requested by a peer.
+	Client
+	// Server spans trace operations handled by a space.
+	Server
+)
+
+// String returns the name of kind k.
+func (k Kind) String() (s string) {
+	switch k {
+	case Client:
+		s = "client"
+	case Server:
+		s = "server"
+	default:
+		s = "internal"
+	}
+
+	return s
+}
+
+// Exporter is an interface for receiving ended spans.
+// Exporters must be safe for concurrent use.
+type Exporter interface {
+	// Export receives span s once it has ended.
+	Export(s *Span)
+}
+
+// Tracer starts spans and hands them to an exporter once they end.
+type Tracer struct {
+	exp Exporter
+}
+
+// NewTracer creates a tracer t exporting ended spans to exp.
+func NewTracer(exp Exporter) (t *Tracer) {
+	t = &Tracer{exp: exp}
+	return t
+}
+
+// Start starts a span s named name of the given kind, as a child of the span identified by parent,
+// or as the first span of a new trace if parent is not valid.
+// Start returns nil if tracer t is nil, and all methods of spans can be called on nil.
+func (t *Tracer) Start(parent SpanContext, name string, kind Kind) (s *Span) {
+	if t == nil {
+		return nil
+	}
+
+	s = &Span{Name: name, Kind: kind, StartTime: time.Now(), Attributes: make(map[string]string), tracer: t}
+
+	if parent.IsValid() {
+		s.Context.TraceID = parent.TraceID
+		s.Parent = parent.SpanID
+	} else {
+		rand.Read(s.Context.TraceID[:])
+	}
+
+	rand.Read(s.Context.SpanID[:])
+
+	return s
+}
+
+// Span is an operation, or a part of it, timed from start to end.
+// Spans are not safe for concurrent use, and must not be changed once they have ended.
+type Span struct {
+	Name       string            // Name of the operation traced.
+	Kind       Kind              // Role of the span in the operation.
+	Context    SpanContext       // Span context identifying the span.
+	Parent     SpanID            // Span identifier of the parent span, or empty if the span starts the trace.
+	StartTime  time.Time         // Time the span started.
+	EndTime    time.Time         // Time the span ended, or zero if it has not.
+	Attributes map[string]string // Attributes describing the operation, e.g. its remote address.
+	Err        string            // Error the operation failed with, or empty if it did not fail.
+	tracer     *Tracer
+}
+
+// SpanContext returns the span context sc of span s, or an empty span context if s is nil.
+func (s *Span) SpanContext() (sc SpanContext) {
+	if s != nil {
+		sc = s.Context
+	}
+
+	return sc
+}
+
+// Child starts a span c named name as an internal child of span s, or returns nil if s is nil.
+func (s *Span) Child(name string) (c *Span) {
+	if s != nil {
+		c = s.tracer.Start(s.Context, name, Internal)
+	}
+
+	return c
+}
+
+// SetAttribute sets the attribute key of span s to value.
+func (s *Span) SetAttribute(key string, value string) {
+	if s != nil {
+		s.Attributes[key] = value
+	}
+}
+
+// SetError records that the operation traced by span s failed with err, unless err is nil.
+func (s *Span) SetError(err error) {
+	if s != nil && err != nil {
+		s.Err = err.Error()
+	}
+}
+
+// End ends span s and exports it. Ending a span again has no effect.
+func (s *Span) End() {
+	if s == nil || !s.EndTime.IsZero() {
+		return
+	}
+
+	s.EndTime = time.Now()
+
+	if s.tracer.exp != nil {
+		s.tracer.exp.Export(s)
+	}
+}
+
+// Duration returns the time from the start to the end of span s.
+func (s *Span) Duration() (d time.Duration) {
+	if s != nil && !s.EndTime.IsZero() {
+		d = s.EndTime.Sub(s.StartTime)
+	}
+
+	return d
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	exp := NewInMemoryExporter()
+	tr := NewTracer(exp)
+
+	root := tr.Start(SpanContext{}, "Get", Client)
+	child := root.Child("sendMessage")
+	child.SetError(errors.New("broken pipe"))
+	child.End()
+	child.End()
+	root.End()
+
+	spans := exp.Spans()
+
+	if len(spans) != 2 || spans[0] != child || spans[1] != root {
+		t.Fatalf("Spans() gave %v, should be the child followed by the root", spans)
+	}
+
+	if !root.SpanContext().IsValid() || root.Parent != (SpanID{}) {
+		t.Errorf("Start() gave span context %s with parent %s, should start a new trace", root.SpanContext(), root.Parent)
+	}
+
+	if child.Context.TraceID != root.Context.TraceID || child.Parent != root.Context.SpanID || child.Kind != Internal {
+		t.Errorf("Child() gave span %s with parent %s, should be an internal child of %s", child.Context, child.Parent, root.Context)
+	}
+
+	if child.Err != "broken pipe" || root.Err != "" {
+		t.Errorf("Spans failed with %q and %q, should be %q and none", child.Err, root.Err, "broken pipe")
+	}
+
+	if len(exp.Trace(root.Context.TraceID)) != 2 || len(exp.Trace(TraceID{})) != 0 {
+		t.Errorf("Trace() did not give the spans of the trace")
+	}
+
+	exp.Reset()
+
+	if len(exp.Spans()) != 0 {
+		t.Errorf("Spans() gave %v after Reset(), should be empty", exp.Spans())
+	}
+
+	var none *Tracer
+	if s := none.Start(root.Context, "Get", Client); s != nil || s.Child("sendMessage") != nil || s.SpanContext().IsValid() {
+		t.Errorf("Start() on a nil tracer gave %v, should be nil", s)
+	}
+}
+
+func TestParseSpanContext(t *testing.T) {
+	sc, err := ParseSpanContext("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	if err != nil || sc.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("ParseSpanContext() gave %s and error %v, should give back the traceparent", sc, err)
+	}
+
+	for _, s := range []string{"", "00-4bf92f35-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-0000000000000000-01", "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01"} {
+		if sc, err := ParseSpanContext(s); err == nil || sc.IsValid() {
+			t.Errorf("ParseSpanContext(%q) gave %s, should give an error", s, sc)
+		}
+	}
+}
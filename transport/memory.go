@@ -0,0 +1,321 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrRefused is the error of connections to addresses nobody listens at.
+var ErrRefused = errors.New("connection refused")
+
+// ErrUnreachable is the error of connections dropped or cut off by a partition.
+var ErrUnreachable = errors.New("host unreachable")
+
+// ErrTimeout is the error of connections delayed beyond their timeout.
+var ErrTimeout = errors.New("connection timed out")
+
+// ErrInUse is the error of listening at an address somebody listens at already.
+var ErrInUse = errors.New("address already in use")
+
+// Memory is a network of peers and spaces within a process, connected through pipes rather than sockets.
+// Addresses are host names and ports as with TCP, but hosts are only names, e.g. node1:31415.
+// Peers dial from a host through the transport returned by Host, and from outside of every host through the network itself.
+// Drops and partitions apply to the connections made after they are injected, while latency applies to every write.
+type Memory struct {
+	mu        *sync.Mutex
+	listeners map[string]*memListener
+	latency   map[string]time.Duration
+	drops     map[string]int
+	cut       map[string]bool
+	groups    map[string]int
+	dialed    int
+}
+
+// MemoryHost is the transport of peers on a host of an in-memory network.
+// Connections are made from the host, such that partitions between hosts apply to them.
+type MemoryHost struct {
+	m    *Memory
+	name string
+}
+
+// NewMemory creates an in-memory network m without any listeners or faults.
+func NewMemory() (m *Memory) {
+	m = &Memory{
+		mu:        new(sync.Mutex),
+		listeners: make(map[string]*memListener),
+		latency:   make(map[string]time.Duration),
+		drops:     make(map[string]int),
+		cut:       make(map[string]bool),
+		groups:    make(map[string]int),
+	}
+
+	return m
+}
+
+// Host returns the transport h of peers on the host name of network m.
+func (m *Memory) Host(name string) (h *MemoryHost) {
+	h = &MemoryHost{m: m, name: name}
+	return h
+}
+
+// Dial connects from host h to the space listening at address, giving up after timeout unless it is 0.
+func (h *MemoryHost) Dial(address string, timeout time.Duration) (conn net.Conn, err error) {
+	return h.m.dial(h.name, address, timeout)
+}
+
+// Listen listens for connections to address, which must not be listened at already.
+func (h *MemoryHost) Listen(address string) (ln net.Listener, err error) {
+	return h.m.Listen(address)
+}
+
+// hostOf returns the host of address, or address itself if it has no port.
+func hostOf(address string) (host string) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	return host
+}
+
+// SetLatency delays connecting to and from host, and every write on its connections, by d, or stops delaying them if d is 0.
+// Connections between two delayed hosts are delayed by the latencies of both.
+func (m *Memory) SetLatency(host string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.latency[host] = d
+}
+
+// Drop makes the next n connections to host fail.
+func (m *Memory) Drop(host string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.drops[host] = n
+}
+
+// Partition cuts hosts off from the network, such that connections to and from them fail until they are healed.
+func (m *Memory) Partition(hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, host := range hosts {
+		m.cut[host] = true
+	}
+}
+
+// Split partitions the hosts of the network into groups, such that connections between hosts of different groups fail until they are healed.
+// Hosts in no group, and peers dialing from outside of every host, still reach every host which is not cut off. Split replaces previous groups.
+func (m *Memory) Split(groups ...[]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, host := range group {
+			m.groups[host] = i + 1
+		}
+	}
+}
+
+// Heal reconnects hosts cut off by Partition or split from others by Split, or all of them if no hosts are given.
+func (m *Memory) Heal(hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(hosts) == 0 {
+		m.cut = make(map[string]bool)
+		m.groups = make(map[string]int)
+	}
+
+	for _, host := range hosts {
+		delete(m.cut, host)
+		delete(m.groups, host)
+	}
+}
+
+// Dial connects to the space listening at address from outside of every host, giving up after timeout unless it is 0.
+func (m *Memory) Dial(address string, timeout time.Duration) (conn net.Conn, err error) {
+	return m.dial("", address, timeout)
+}
+
+// reachable returns true if host to can be connected to from host from, or from outside of every host if from is empty.
+// The caller must hold the lock of m.
+func (m *Memory) reachable(from string, to string) (b bool) {
+	if m.cut[to] || (from != "" && m.cut[from]) {
+		return false
+	}
+
+	g, h := m.groups[from], m.groups[to]
+	b = from == "" || g == 0 || h == 0 || g == h
+
+	return b
+}
+
+// delay returns the latency d of connections between host from and host to.
+func (m *Memory) delay(from string, to string) (d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d = m.latency[to]
+	if from != "" && from != to {
+		d += m.latency[from]
+	}
+
+	return d
+}
+
+// dial connects from host from, or from outside of every host if from is empty, to the space listening at address,
+// giving up after timeout unless it is 0.
+func (m *Memory) dial(from string, address string, timeout time.Duration) (conn net.Conn, err error) {
+	host := hostOf(address)
+
+	latency := m.delay(from, host)
+
+	m.mu.Lock()
+
+	l := m.listeners[address]
+	unreachable := !m.reachable(from, host) || m.drops[host] > 0
+
+	if m.drops[host] > 0 {
+		m.drops[host]--
+	}
+
+	m.dialed++
+	peer := memAddr(fmt.Sprintf("peer-%d", m.dialed))
+	if from != "" {
+		peer = memAddr(fmt.Sprintf("%s:peer-%d", from, m.dialed))
+	}
+
+	m.mu.Unlock()
+
+	if timeout > 0 && latency > timeout {
+		time.Sleep(timeout)
+		return nil, dialError(address, ErrTimeout)
+	}
+
+	time.Sleep(latency)
+
+	if unreachable {
+		return nil, dialError(address, ErrUnreachable)
+	}
+
+	if l == nil {
+		return nil, dialError(address, ErrRefused)
+	}
+
+	client, server := net.Pipe()
+
+	select {
+	case l.conns <- &memConn{Conn: server, local: l.addr, remote: peer, m: m, from: from, to: host}:
+	case <-l.done:
+		client.Close()
+		server.Close()
+		return nil, dialError(address, ErrRefused)
+	}
+
+	conn = &memConn{Conn: client, local: peer, remote: l.addr, m: m, from: from, to: host}
+
+	return conn, nil
+}
+
+// dialError returns the error of connecting to address, caused by err.
+func dialError(address string, err error) error {
+	return &net.OpError{Op: "dial", Net: "mem", Addr: memAddr(address), Err: err}
+}
+
+// Listen listens for connections to address, which must not be listened at already.
+func (m *Memory) Listen(address string) (ln net.Listener, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.listeners[address]; exists {
+		return nil, &net.OpError{Op: "listen", Net: "mem", Addr: memAddr(address), Err: ErrInUse}
+	}
+
+	l := &memListener{m: m, addr: memAddr(address), conns: make(chan net.Conn), done: make(chan struct{}), once: new(sync.Once)}
+	m.listeners[address] = l
+
+	return l, nil
+}
+
+// memAddr is an address in an in-memory network.
+type memAddr string
+
+// Network returns the name of the network of address a.
+func (a memAddr) Network() string {
+	return "mem"
+}
+
+// String returns address a.
+func (a memAddr) String() string {
+	return string(a)
+}
+
+// memConn is a connection in an in-memory network, from host from to host to.
+type memConn struct {
+	net.Conn
+	local  net.Addr
+	remote net.Addr
+	m      *Memory
+	from   string
+	to     string
+}
+
+// Write writes b to connection c once the latency between its hosts has passed.
+func (c *memConn) Write(b []byte) (n int, err error) {
+	time.Sleep(c.m.delay(c.from, c.to))
+
+	return c.Conn.Write(b)
+}
+
+// LocalAddr returns the address of the end of connection c.
+func (c *memConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the address of the other end of connection c.
+func (c *memConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// memListener listens for connections to an address in an in-memory network.
+type memListener struct {
+	m     *Memory
+	addr  memAddr
+	conns chan net.Conn
+	done  chan struct{}
+	once  *sync.Once
+}
+
+// Accept waits for the next connection to listener l.
+func (l *memListener) Accept() (conn net.Conn, err error) {
+	select {
+	case conn = <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, &net.OpError{Op: "accept", Net: "mem", Addr: l.addr, Err: net.ErrClosed}
+	}
+}
+
+// Close stops listener l, such that its address can be listened at again.
+func (l *memListener) Close() (err error) {
+	l.once.Do(func() {
+		close(l.done)
+
+		l.m.mu.Lock()
+		delete(l.m.listeners, string(l.addr))
+		l.m.mu.Unlock()
+	})
+
+	return nil
+}
+
+// Addr returns the address listener l listens at.
+func (l *memListener) Addr() net.Addr {
+	return l.addr
+}
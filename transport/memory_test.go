@@ -0,0 +1,251 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	// Setup
+	m := NewMemory()
+
+	l, err := m.Listen("node1:31415")
+	if err != nil {
+		t.Fatalf("Listen() gave error %v, should listen", err)
+	}
+
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	// Test that connections reach the listener.
+	conn, err := m.Dial("node1:31415", 0)
+	if err != nil {
+		t.Fatalf("Dial() gave error %v, should connect", err)
+	}
+
+	buf := make([]byte, 5)
+	conn.Write([]byte("hello"))
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("Dial() gave a connection reading %q and error %v, should echo %q", buf, err, "hello")
+	}
+
+	if conn.LocalAddr().Network() != "mem" || conn.RemoteAddr().String() != "node1:31415" {
+		t.Errorf("Dial() gave a connection from %v to %v, should be to node1:31415 in mem", conn.LocalAddr(), conn.RemoteAddr())
+	}
+
+	conn.Close()
+
+	// Test that addresses are listened at once.
+	if _, err := m.Listen("node1:31415"); !errors.Is(err, ErrInUse) {
+		t.Errorf("Listen() gave error %v for an address in use, should be %v", err, ErrInUse)
+	}
+
+	// Test that connections to addresses nobody listens at are refused.
+	if _, err := m.Dial("node2:31415", 0); !errors.Is(err, ErrRefused) {
+		t.Errorf("Dial() gave error %v for an address nobody listens at, should be %v", err, ErrRefused)
+	}
+
+	// Test that exactly the dropped connections fail.
+	m.Drop("node1", 2)
+	for i, want := range []error{ErrUnreachable, ErrUnreachable, nil} {
+		conn, err := m.Dial("node1:31415", 0)
+		if !errors.Is(err, want) {
+			t.Errorf("Dial() gave error %v for connection %d after dropping 2, should be %v", err, i+1, want)
+		}
+
+		if err == nil {
+			conn.Close()
+		}
+	}
+
+	// Test that partitioned hosts are unreachable until healed.
+	m.Partition("node1", "node2")
+	if _, err := m.Dial("node1:31415", 0); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("Dial() gave error %v for a partitioned host, should be %v", err, ErrUnreachable)
+	}
+
+	m.Heal("node2")
+	if _, err := m.Dial("node1:31415", 0); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("Dial() gave error %v for a host partitioned after healing another, should be %v", err, ErrUnreachable)
+	}
+
+	m.Heal()
+	if conn, err := m.Dial("node1:31415", 0); err != nil {
+		t.Errorf("Dial() gave error %v for a healed host, should connect", err)
+	} else {
+		conn.Close()
+	}
+
+	// Test that connections are delayed by the latency of the host, and time out if it is too high.
+	m.SetLatency("node1", 20*time.Millisecond)
+
+	start := time.Now()
+	if conn, err := m.Dial("node1:31415", time.Second); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Dial() gave error %v after %v, should connect after the latency of 20ms", err, time.Since(start))
+	} else {
+		conn.Close()
+	}
+
+	var ne net.Error
+	if _, err := m.Dial("node1:31415", time.Millisecond); !errors.Is(err, ErrTimeout) || !errors.As(err, &ne) {
+		t.Errorf("Dial() gave error %v with a timeout below the latency, should be %v", err, ErrTimeout)
+	}
+
+	m.SetLatency("node1", 0)
+
+	// Test that closed listeners stop accepting and free their address.
+	l.Close()
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept() gave error %v after Close(), should be %v", err, net.ErrClosed)
+	}
+
+	if _, err := m.Dial("node1:31415", 0); !errors.Is(err, ErrRefused) {
+		t.Errorf("Dial() gave error %v after Close(), should be %v", err, ErrRefused)
+	}
+
+	if l, err := m.Listen("node1:31415"); err != nil {
+		t.Errorf("Listen() gave error %v after Close(), should listen again", err)
+	} else {
+		l.Close()
+	}
+}
+
+func TestMemoryHosts(t *testing.T) {
+	// Setup
+	m := NewMemory()
+
+	for _, host := range []string{"node1", "node2", "node3"} {
+		l, err := m.Listen(host + ":31415")
+		if err != nil {
+			t.Fatalf("Listen() gave error %v at %s, should listen", err, host)
+		}
+
+		defer l.Close()
+
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					io.Copy(conn, conn)
+					conn.Close()
+				}()
+			}
+		}()
+	}
+
+	node1, node2, node3 := m.Host("node1"), m.Host("node2"), m.Host("node3")
+
+	reaches := func(tr Transport, address string) (err error) {
+		conn, err := tr.Dial(address, 0)
+		if err == nil {
+			conn.Close()
+		}
+
+		return err
+	}
+
+	// Test that connections tell the host they are made from.
+	conn, err := node1.Dial("node2:31415", 0)
+	if err != nil || !strings.HasPrefix(conn.LocalAddr().String(), "node1:") {
+		t.Fatalf("Dial() gave error %v, should connect from node1", err)
+	}
+
+	conn.Close()
+
+	// Test that hosts split into groups only reach the hosts of their own group.
+	m.Split([]string{"node1", "node2"}, []string{"node3"})
+
+	for _, c := range []struct {
+		from    Transport
+		name    string
+		address string
+		want    error
+	}{
+		{node1, "node1", "node2:31415", nil},
+		{node2, "node2", "node1:31415", nil},
+		{node1, "node1", "node3:31415", ErrUnreachable},
+		{node3, "node3", "node1:31415", ErrUnreachable},
+		{m, "outside", "node3:31415", nil},
+	} {
+		if err := reaches(c.from, c.address); !errors.Is(err, c.want) {
+			t.Errorf("Dial(%s) from %s gave error %v after splitting, should be %v", c.address, c.name, err, c.want)
+		}
+	}
+
+	m.Heal("node3")
+	if err := reaches(node3, "node1:31415"); err != nil {
+		t.Errorf("Dial() from node3 gave error %v after healing it, should connect", err)
+	}
+
+	// Test that partitioned hosts can neither be reached nor reach others.
+	m.Partition("node2")
+	if err := reaches(node2, "node1:31415"); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("Dial() from a partitioned host gave error %v, should be %v", err, ErrUnreachable)
+	}
+
+	m.Heal()
+	if err := reaches(node2, "node1:31415"); err != nil {
+		t.Errorf("Dial() from node2 gave error %v after healing, should connect", err)
+	}
+
+	// Test that latency delays every write of established connections, in both directions.
+	conn, err = node1.Dial("node2:31415", 0)
+	if err != nil {
+		t.Fatalf("Dial() gave error %v, should connect", err)
+	}
+
+	defer conn.Close()
+
+	m.SetLatency("node2", 20*time.Millisecond)
+
+	buf := make([]byte, 2)
+	start := time.Now()
+	conn.Write([]byte("hi"))
+	if _, err := io.ReadFull(conn, buf); err != nil || time.Since(start) < 40*time.Millisecond {
+		t.Errorf("Write() echoed %q with error %v after %v, should take two latencies of 20ms", buf, err, time.Since(start))
+	}
+}
+
+func TestRegister(t *testing.T) {
+	// Test that TCP and memory transports are registered.
+	if tr, exists := Lookup("tcp"); !exists || tr != (TCP{}) {
+		t.Errorf("Lookup(tcp) gave %v and %t, should be TCP", tr, exists)
+	}
+
+	if _, exists := Lookup("mem"); !exists {
+		t.Errorf("Lookup(mem) gave false, should be registered")
+	}
+
+	// Test that registered transports are looked up by their scheme.
+	m := NewMemory()
+	Register("test", m)
+
+	if tr, exists := Lookup("test"); !exists || tr != m {
+		t.Errorf("Lookup(test) gave %v and %t, should be the registered transport", tr, exists)
+	}
+
+	if _, exists := Lookup("unknown"); exists {
+		t.Errorf("Lookup(unknown) gave true, should be false")
+	}
+}
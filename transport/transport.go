@@ -0,0 +1,68 @@
+// Package transport provides the connections between peers and the spaces they operate on.
+//
+// Spaces are reached through the transport registered for the scheme of their URL:
+// tcp:// spaces through TCP sockets, and mem:// spaces through an in-memory network within the process,
+// which needs neither ports nor host name resolution and can delay, drop and partition connections on demand.
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Transport is an interface for connecting peers to spaces.
+// Transports must be safe for concurrent use.
+type Transport interface {
+	// Dial connects to the space listening at address, giving up after timeout unless it is 0.
+	Dial(address string, timeout time.Duration) (net.Conn, error)
+	// Listen listens for connections to address.
+	Listen(address string) (net.Listener, error)
+}
+
+// TCP is the transport connecting peers to spaces through TCP sockets over IPv4.
+type TCP struct{}
+
+// Dial connects to the space listening at address, giving up after timeout unless it is 0.
+func (TCP) Dial(address string, timeout time.Duration) (conn net.Conn, err error) {
+	if timeout > 0 {
+		return net.DialTimeout("tcp4", address, timeout)
+	}
+
+	return net.Dial("tcp4", address)
+}
+
+// Listen listens for connections to address.
+func (TCP) Listen(address string) (l net.Listener, err error) {
+	return net.Listen("tcp4", address)
+}
+
+// transports contains the transports used for the schemes of URLs.
+var transports = struct {
+	mu      *sync.RWMutex
+	schemes map[string]Transport
+}{mu: new(sync.RWMutex), schemes: make(map[string]Transport)}
+
+func init() {
+	Register("tcp", TCP{})
+	Register("mem", NewMemory())
+}
+
+// Register makes spaces with URLs of scheme reachable through transport t, replacing any transport registered for scheme before.
+// Tests can register a fresh memory network for each test, e.g. to partition it without affecting others.
+func Register(scheme string, t Transport) {
+	transports.mu.Lock()
+	defer transports.mu.Unlock()
+
+	transports.schemes[scheme] = t
+}
+
+// Lookup returns the transport t registered for scheme, and false if there is none.
+func Lookup(scheme string) (t Transport, b bool) {
+	transports.mu.RLock()
+	defer transports.mu.RUnlock()
+
+	t, b = transports.schemes[scheme]
+
+	return t, b
+}